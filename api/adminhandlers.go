@@ -0,0 +1,98 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/phlashdev/recipe-keeper-api/backup"
+	"github.com/phlashdev/recipe-keeper-api/core"
+)
+
+const (
+	mediaTypeZip = "application/zip"
+
+	maxBackupSize = 256 << 20
+)
+
+type BackupHandler struct {
+	recipeRepository core.RecipeRepository
+	sourceRepository core.SourceRepository
+}
+
+func NewBackupHandler(recipeRepository core.RecipeRepository, sourceRepository core.SourceRepository) *BackupHandler {
+	return &BackupHandler{
+		recipeRepository: recipeRepository,
+		sourceRepository: sourceRepository,
+	}
+}
+
+func (handler *BackupHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	archive, err := backup.Create(ctx, handler.recipeRepository, handler.sourceRepository)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var buffer bytes.Buffer
+	err = backup.Write(&buffer, archive)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	fileName := fmt.Sprintf("recipe-keeper-backup-%s", archive.Manifest.CreatedAt.Format("20060102-150405"))
+	w.Header().Set("Content-Type", mediaTypeZip)
+	w.Header().Set("Content-Disposition", attachmentDisposition(fileName, ".zip"))
+	_, err = w.Write(buffer.Bytes())
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+type RestoreHandler struct {
+	recipeRepository core.RecipeRepository
+	sourceRepository core.SourceRepository
+}
+
+func NewRestoreHandler(recipeRepository core.RecipeRepository, sourceRepository core.SourceRepository) *RestoreHandler {
+	return &RestoreHandler{
+		recipeRepository: recipeRepository,
+		sourceRepository: sourceRepository,
+	}
+}
+
+func (handler *RestoreHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	data, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxBackupSize))
+	if err != nil {
+		log.Print(err)
+		writeProblem(w, r, newProblem(http.StatusRequestEntityTooLarge, problemTypeTooLarge, err.Error()))
+		return
+	}
+
+	archive, err := backup.Read(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	err = backup.Restore(ctx, archive, handler.recipeRepository, handler.sourceRepository)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,202 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/phlashdev/recipe-keeper-api/auth"
+	"github.com/phlashdev/recipe-keeper-api/core"
+)
+
+type loginModel struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type sessionModel struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	User      userModel `json:"user"`
+}
+
+type userModel struct {
+	ID          string `json:"id"`
+	Username    string `json:"username"`
+	DisplayName string `json:"displayName"`
+	Role        string `json:"role"`
+}
+
+func newUserModel(user core.User) userModel {
+	return userModel{
+		ID:          user.ID.Hex(),
+		Username:    user.Username,
+		DisplayName: user.DisplayName,
+		Role:        user.Role,
+	}
+}
+
+// LoginHandler returns a session token for the username and password, it is sent as bearer token
+// with the following requests.
+type LoginHandler struct {
+	authenticator *auth.Authenticator
+}
+
+func NewLoginHandler(authenticator *auth.Authenticator) *LoginHandler {
+	return &LoginHandler{
+		authenticator: authenticator,
+	}
+}
+
+func (handler *LoginHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var login loginModel
+	err := json.NewDecoder(r.Body).Decode(&login)
+	if err != nil {
+		writeError(w, r, malformedRequest(err))
+		return
+	}
+
+	token, session, user, err := handler.authenticator.Login(ctx, strings.TrimSpace(login.Username), login.Password)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	jsonSession, err := json.Marshal(sessionModel{
+		Token:     token,
+		ExpiresAt: session.ExpiresAt,
+		User:      newUserModel(user),
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", mediaTypeJSON)
+	w.Header().Set("Cache-Control", "no-store")
+	_, err = w.Write(jsonSession)
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// LogoutHandler ends the session of the request, requests without a session are ignored.
+type LogoutHandler struct {
+	authenticator *auth.Authenticator
+}
+
+func NewLogoutHandler(authenticator *auth.Authenticator) *LogoutHandler {
+	return &LogoutHandler{
+		authenticator: authenticator,
+	}
+}
+
+func (handler *LogoutHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err := handler.authenticator.Logout(ctx, auth.RequestToken(r))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetCurrentUserHandler returns the signed in user.
+type GetCurrentUserHandler struct{}
+
+func NewGetCurrentUserHandler() *GetCurrentUserHandler {
+	return &GetCurrentUserHandler{}
+}
+
+func (handler *GetCurrentUserHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		writeError(w, r, &auth.NotAuthenticatedError{})
+		return
+	}
+
+	jsonUser, err := json.Marshal(newUserModel(user))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	_, err = w.Write(jsonUser)
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// AuthMiddleware lets only signed in users with the required role pass. API requests without a
+// valid session get a problem, pages redirect to the login page. Public paths are passed as they
+// are, a path ending with a slash includes all paths below it.
+type AuthMiddleware struct {
+	authenticator *auth.Authenticator
+	loginPath     string
+	publicPaths   []string
+}
+
+func NewAuthMiddleware(authenticator *auth.Authenticator, loginPath string, publicPaths ...string) *AuthMiddleware {
+	return &AuthMiddleware{
+		authenticator: authenticator,
+		loginPath:     loginPath,
+		publicPaths:   publicPaths,
+	}
+}
+
+func (middleware *AuthMiddleware) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if middleware.isPublic(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		user, err := middleware.authenticator.Authenticate(ctx, auth.RequestToken(r))
+		cancel()
+		if err == nil && !user.HasRole(auth.RequiredRole(r)) {
+			err = &auth.ForbiddenError{Role: auth.RequiredRole(r)}
+		}
+		if err != nil {
+			middleware.reject(w, r, err)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(auth.WithUser(r.Context(), user)))
+	})
+}
+
+func (middleware *AuthMiddleware) isPublic(path string) bool {
+	for _, publicPath := range middleware.publicPaths {
+		if path == publicPath || (strings.HasSuffix(publicPath, "/") && strings.HasPrefix(path, publicPath)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (middleware *AuthMiddleware) reject(w http.ResponseWriter, r *http.Request, err error) {
+	var notAuthenticatedErr *auth.NotAuthenticatedError
+	if strings.HasPrefix(r.URL.Path, "/api/") || !errors.As(err, &notAuthenticatedErr) {
+		writeError(w, r, err)
+		return
+	}
+
+	http.Redirect(w, r, middleware.loginPath+"?next="+url.QueryEscape(r.URL.RequestURI()), http.StatusSeeOther)
+}
@@ -0,0 +1,306 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/phlashdev/recipe-keeper-api/core"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const maxBatchSize = 500
+
+type recipeOperationModel struct {
+	Operation string `json:"op"`
+	ID        string `json:"id"`
+	// Version is checked like If-Match if it is set.
+	Version int              `json:"version"`
+	Recipe  *recipeModelBase `json:"recipe"`
+}
+
+type sourceOperationModel struct {
+	Operation string           `json:"op"`
+	ID        string           `json:"id"`
+	Version   int              `json:"version"`
+	Source    *sourceModelBase `json:"source"`
+}
+
+type batchResultModel struct {
+	Atomic  bool                   `json:"atomic"`
+	Results []batchItemResultModel `json:"results"`
+}
+
+type batchItemResultModel struct {
+	Status  int           `json:"status"`
+	ID      string        `json:"id,omitempty"`
+	Version int           `json:"version,omitempty"`
+	Error   *problemModel `json:"error,omitempty"`
+}
+
+func newBatchResultModel(r *http.Request, operations []string, result core.BatchResult) batchResultModel {
+	items := make([]batchItemResultModel, 0, len(result.Items))
+	for i, item := range result.Items {
+		if item.Err != nil {
+			problem := newErrorProblem(r, item.Err)
+			problem.Instance = fmt.Sprintf("%s#%d", r.URL.Path, i)
+			items = append(items, batchItemResultModel{
+				Status: problem.Status,
+				Error:  &problem,
+			})
+			continue
+		}
+
+		status := http.StatusOK
+		switch operations[i] {
+		case core.BatchOperationCreate:
+			status = http.StatusCreated
+		case core.BatchOperationDelete:
+			status = http.StatusNoContent
+		}
+		items = append(items, batchItemResultModel{
+			Status:  status,
+			ID:      item.ID,
+			Version: item.Version,
+		})
+	}
+
+	return batchResultModel{
+		Atomic:  result.Atomic,
+		Results: items,
+	}
+}
+
+func checkBatchSize(count int) error {
+	if count == 0 {
+		return malformedRequest(errors.New("batch contains no operations"))
+	}
+	if count > maxBatchSize {
+		return malformedRequest(fmt.Errorf("batch contains more than %d operations", maxBatchSize))
+	}
+
+	return nil
+}
+
+func checkOperationVersion(id string, version int, stored int) error {
+	if version != 0 && version != stored {
+		return &core.VersionConflictError{ID: id, Version: version}
+	}
+
+	return nil
+}
+
+func writeBatchResult(w http.ResponseWriter, r *http.Request, model batchResultModel) {
+	jsonResult, err := json.Marshal(model)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", mediaTypeJSON)
+	_, err = w.Write(jsonResult)
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// BatchRecipesHandler creates, updates and deletes several recipes with one request. All
+// operations are checked before the first one is applied, if one of them is not valid none is
+// applied. The response holds a result per operation in the order of the request.
+type BatchRecipesHandler struct {
+	recipeRepository   core.RecipeRepository
+	revisionRepository core.RevisionRepository
+}
+
+func NewBatchRecipesHandler(recipeRepository core.RecipeRepository, revisionRepository core.RevisionRepository) *BatchRecipesHandler {
+	return &BatchRecipesHandler{
+		recipeRepository:   recipeRepository,
+		revisionRepository: revisionRepository,
+	}
+}
+
+func (handler *BatchRecipesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	var operationModels []recipeOperationModel
+	err := json.NewDecoder(r.Body).Decode(&operationModels)
+	if err != nil {
+		writeError(w, r, malformedRequest(err))
+		return
+	}
+	if err := checkBatchSize(len(operationModels)); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	operations := make([]core.RecipeOperation, len(operationModels))
+	operationNames := make([]string, len(operationModels))
+	previous := make([]*core.Recipe, len(operationModels))
+	errs := make([]error, len(operationModels))
+	failed := false
+	for i, operationModel := range operationModels {
+		operationNames[i] = operationModel.Operation
+		operations[i], previous[i], errs[i] = handler.prepare(ctx, operationModel)
+		failed = failed || errs[i] != nil
+	}
+
+	var result core.BatchResult
+	if failed {
+		result = core.AbortBatch(errs)
+	} else {
+		result, err = handler.recipeRepository.BatchRecipes(ctx, operations)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+	}
+
+	for i, item := range result.Items {
+		if item.Err != nil || operations[i].Operation == core.BatchOperationDelete {
+			continue
+		}
+
+		recipe := operations[i].Recipe
+		recipe.ID, _ = primitive.ObjectIDFromHex(item.ID)
+		recipe.Version = item.Version
+		err = core.RecordRevision(ctx, handler.revisionRepository, previous[i], recipe, requestAuthor(r))
+		if err != nil {
+			// the batch is already stored, only the history misses the revision
+			log.Print(err)
+		}
+	}
+
+	writeBatchResult(w, r, newBatchResultModel(r, operationNames, result))
+}
+
+// prepare turns the operation model into the operation of the repository. For updates it also
+// returns the recipe before the update.
+func (handler *BatchRecipesHandler) prepare(ctx context.Context, operationModel recipeOperationModel) (core.RecipeOperation, *core.Recipe, error) {
+	var recipe core.Recipe
+	var previous *core.Recipe
+	switch operationModel.Operation {
+	case core.BatchOperationCreate:
+	case core.BatchOperationUpdate, core.BatchOperationDelete:
+		var err error
+		recipe, err = handler.recipeRepository.GetRecipeByID(ctx, operationModel.ID)
+		if err != nil {
+			return core.RecipeOperation{}, nil, err
+		}
+		if err := checkOperationVersion(operationModel.ID, operationModel.Version, recipe.Version); err != nil {
+			return core.RecipeOperation{}, nil, err
+		}
+		stored := recipe
+		previous = &stored
+	default:
+		return core.RecipeOperation{}, nil, invalidParam("op", &core.BatchOperationNotValidError{Operation: operationModel.Operation})
+	}
+
+	if operationModel.Operation != core.BatchOperationDelete {
+		if operationModel.Recipe == nil {
+			return core.RecipeOperation{}, nil, invalidParam("recipe", errors.New("must not be empty"))
+		}
+
+		sourceID, err := primitive.ObjectIDFromHex(operationModel.Recipe.SourceID)
+		if err != nil {
+			return core.RecipeOperation{}, nil, invalidParam("sourceId", err)
+		}
+		applyRecipeModel(&recipe, *operationModel.Recipe, sourceID)
+
+		if err := core.ValidateRecipe(recipe); err != nil {
+			return core.RecipeOperation{}, nil, err
+		}
+	}
+
+	return core.RecipeOperation{Operation: operationModel.Operation, Recipe: recipe}, previous, nil
+}
+
+// BatchSourcesHandler creates, updates and deletes several sources with one request, see
+// BatchRecipesHandler.
+type BatchSourcesHandler struct {
+	sourceRepository core.SourceRepository
+}
+
+func NewBatchSourcesHandler(sourceRepository core.SourceRepository) *BatchSourcesHandler {
+	return &BatchSourcesHandler{
+		sourceRepository: sourceRepository,
+	}
+}
+
+func (handler *BatchSourcesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	var operationModels []sourceOperationModel
+	err := json.NewDecoder(r.Body).Decode(&operationModels)
+	if err != nil {
+		writeError(w, r, malformedRequest(err))
+		return
+	}
+	if err := checkBatchSize(len(operationModels)); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	operations := make([]core.SourceOperation, len(operationModels))
+	operationNames := make([]string, len(operationModels))
+	errs := make([]error, len(operationModels))
+	failed := false
+	for i, operationModel := range operationModels {
+		operationNames[i] = operationModel.Operation
+		operations[i], errs[i] = handler.prepare(ctx, operationModel)
+		failed = failed || errs[i] != nil
+	}
+
+	var result core.BatchResult
+	if failed {
+		result = core.AbortBatch(errs)
+	} else {
+		result, err = handler.sourceRepository.BatchSources(ctx, operations)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+	}
+
+	writeBatchResult(w, r, newBatchResultModel(r, operationNames, result))
+}
+
+func (handler *BatchSourcesHandler) prepare(ctx context.Context, operationModel sourceOperationModel) (core.SourceOperation, error) {
+	var source core.Source
+	switch operationModel.Operation {
+	case core.BatchOperationCreate:
+	case core.BatchOperationUpdate, core.BatchOperationDelete:
+		var err error
+		source, err = handler.sourceRepository.GetSourceByID(ctx, operationModel.ID)
+		if err != nil {
+			return core.SourceOperation{}, err
+		}
+		if err := checkOperationVersion(operationModel.ID, operationModel.Version, source.Version); err != nil {
+			return core.SourceOperation{}, err
+		}
+	default:
+		return core.SourceOperation{}, invalidParam("op", &core.BatchOperationNotValidError{Operation: operationModel.Operation})
+	}
+
+	if operationModel.Operation != core.BatchOperationDelete {
+		if operationModel.Source == nil {
+			return core.SourceOperation{}, invalidParam("source", errors.New("must not be empty"))
+		}
+
+		source.Title = operationModel.Source.Title
+		source.Type = operationModel.Source.SourceType
+
+		if err := core.ValidateSource(source); err != nil {
+			return core.SourceOperation{}, err
+		}
+	}
+
+	return core.SourceOperation{Operation: operationModel.Operation, Source: source}, nil
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	mediaTypeJSON     = "application/json"
+	mediaTypeJSONLD   = "application/ld+json"
+	mediaTypeCooklang = "text/x-cooklang"
+	mediaTypeCSV      = "text/csv"
+	mediaTypeMarkdown = "text/markdown"
+)
+
+// negotiateContentType returns the offer best matching the Accept header of the request.
+// The first offer is used if the request has no Accept header or nothing matches.
+func negotiateContentType(r *http.Request, offers ...string) string {
+	accept := r.Header.Get("Accept")
+	if len(accept) == 0 {
+		return offers[0]
+	}
+
+	bestOffer := offers[0]
+	bestQuality := 0.0
+	bestSpecificity := -1
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			quality, err = strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+		}
+		if quality == 0 {
+			continue
+		}
+
+		for _, offer := range offers {
+			specificity := mediaTypeSpecificity(mediaType, offer)
+			if specificity < 0 {
+				continue
+			}
+			if quality > bestQuality || (quality == bestQuality && specificity > bestSpecificity) {
+				bestOffer = offer
+				bestQuality = quality
+				bestSpecificity = specificity
+			}
+		}
+	}
+
+	return bestOffer
+}
+
+// requestContentType returns the media type of the request body without parameters.
+func requestContentType(r *http.Request) string {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return ""
+	}
+
+	return mediaType
+}
+
+func mediaTypeSpecificity(mediaRange string, offer string) int {
+	switch {
+	case mediaRange == offer:
+		return 2
+	case strings.HasSuffix(mediaRange, "/*") && strings.HasPrefix(offer, strings.TrimSuffix(mediaRange, "*")):
+		return 1
+	case mediaRange == "*/*":
+		return 0
+	default:
+		return -1
+	}
+}
+
+// attachmentDisposition returns a Content-Disposition header value offering the response as download
+// with a file name derived from the given title.
+func attachmentDisposition(title string, extension string) string {
+	name := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|`, r) || r < 0x20 {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(title))
+	if len(name) == 0 {
+		name = "recipe"
+	}
+
+	return mime.FormatMediaType("attachment", map[string]string{"filename": name + extension})
+}
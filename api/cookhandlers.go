@@ -0,0 +1,341 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/phlashdev/recipe-keeper-api/cooking"
+	"github.com/phlashdev/recipe-keeper-api/core"
+)
+
+const mediaTypeEventStream = "text/event-stream"
+
+// cookHeartbeatInterval keeps idle event streams from being closed by proxies.
+const cookHeartbeatInterval = 30 * time.Second
+
+type cookModel struct {
+	RecipeID    string            `json:"recipeId"`
+	Title       string            `json:"title"`
+	Servings    int               `json:"servings"`
+	StepCount   int               `json:"stepCount"`
+	Ingredients []ingredientModel `json:"ingredients"`
+	Cookware    []string          `json:"cookware"`
+	Timers      []cookTimerModel  `json:"timers"`
+}
+
+type cookStepModel struct {
+	Number    int `json:"number"`
+	StepCount int `json:"stepCount"`
+	// Segments split the text, segments naming an ingredient of the step have it set.
+	Segments    []textSegmentModel `json:"segments"`
+	Text        string             `json:"text"`
+	Ingredients []ingredientModel  `json:"ingredients"`
+	Timers      []timerModel       `json:"timers"`
+}
+
+type textSegmentModel struct {
+	Text       string `json:"text"`
+	Ingredient string `json:"ingredient,omitempty"`
+}
+
+type cookTimerModel struct {
+	ID              string    `json:"id"`
+	StepIndex       int       `json:"stepIndex"`
+	Name            string    `json:"name"`
+	DurationSeconds int64     `json:"durationSeconds"`
+	StartedAt       time.Time `json:"startedAt"`
+	EndsAt          time.Time `json:"endsAt"`
+	// RemainingSeconds lets clients count down without depending on their clock.
+	RemainingSeconds float64 `json:"remainingSeconds"`
+	Finished         bool    `json:"finished"`
+}
+
+type cookTimerForStartModel struct {
+	StepIndex  int `json:"stepIndex"`
+	TimerIndex int `json:"timerIndex"`
+}
+
+func newCookTimerModels(timers []cooking.Timer) []cookTimerModel {
+	now := time.Now()
+	timerModels := make([]cookTimerModel, 0, len(timers))
+	for _, timer := range timers {
+		timerModels = append(timerModels, newCookTimerModel(timer, now))
+	}
+
+	return timerModels
+}
+
+func newCookTimerModel(timer cooking.Timer, now time.Time) cookTimerModel {
+	remaining := timer.Remaining(now)
+	return cookTimerModel{
+		ID:               timer.ID,
+		StepIndex:        timer.StepIndex,
+		Name:             timer.Name,
+		DurationSeconds:  int64(timer.Duration / time.Second),
+		StartedAt:        timer.StartedAt,
+		EndsAt:           timer.EndsAt,
+		RemainingSeconds: remaining.Seconds(),
+		Finished:         remaining == 0,
+	}
+}
+
+// GetCookHandler returns the overview of a recipe in cook mode, the steps are fetched one at a time.
+type GetCookHandler struct {
+	recipeRepository core.RecipeRepository
+	timers           *cooking.Timers
+}
+
+func NewGetCookHandler(recipeRepository core.RecipeRepository, timers *cooking.Timers) *GetCookHandler {
+	return &GetCookHandler{
+		recipeRepository: recipeRepository,
+		timers:           timers,
+	}
+}
+
+func (handler *GetCookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	vars := mux.Vars(r)
+	recipe, err := handler.recipeRepository.GetRecipeByID(ctx, vars["id"])
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	jsonCook, err := json.Marshal(cookModel{
+		RecipeID:    recipe.ID.Hex(),
+		Title:       recipe.Title,
+		Servings:    recipe.Servings,
+		StepCount:   len(recipe.Steps),
+		Ingredients: newIngredientModels(recipe.Ingredients),
+		Cookware:    recipe.Cookware,
+		Timers:      newCookTimerModels(handler.timers.List(recipe.ID.Hex())),
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	_, err = w.Write(jsonCook)
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetCookStepHandler returns a step with the ingredients it uses, steps are numbered starting at 1.
+type GetCookStepHandler struct {
+	recipeRepository core.RecipeRepository
+}
+
+func NewGetCookStepHandler(recipeRepository core.RecipeRepository) *GetCookStepHandler {
+	return &GetCookStepHandler{
+		recipeRepository: recipeRepository,
+	}
+}
+
+func (handler *GetCookStepHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	vars := mux.Vars(r)
+	number, err := strconv.Atoi(vars["number"])
+	if err != nil {
+		log.Print(err)
+		writeProblem(w, r, newProblem(http.StatusNotFound, problemTypeNotFound, err.Error()))
+		return
+	}
+
+	recipe, err := handler.recipeRepository.GetRecipeByID(ctx, vars["id"])
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	step, err := cooking.Step(recipe, number)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	ingredients := cooking.StepIngredients(recipe, step)
+	var segments []textSegmentModel
+	for _, segment := range cooking.Highlight(step.Text, ingredients) {
+		segments = append(segments, textSegmentModel{Text: segment.Text, Ingredient: segment.Ingredient})
+	}
+
+	jsonStep, err := json.Marshal(cookStepModel{
+		Number:      number,
+		StepCount:   len(recipe.Steps),
+		Segments:    segments,
+		Text:        step.Text,
+		Ingredients: newIngredientModels(ingredients),
+		Timers:      newStepModels([]core.Step{step})[0].Timers,
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	_, err = w.Write(jsonStep)
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+type GetCookTimersHandler struct {
+	timers *cooking.Timers
+}
+
+func NewGetCookTimersHandler(timers *cooking.Timers) *GetCookTimersHandler {
+	return &GetCookTimersHandler{
+		timers: timers,
+	}
+}
+
+func (handler *GetCookTimersHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	jsonTimers, err := json.Marshal(newCookTimerModels(handler.timers.List(vars["id"])))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	_, err = w.Write(jsonTimers)
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// StartCookTimerHandler starts a timer of a step, it runs on the server so every device sees it.
+type StartCookTimerHandler struct {
+	recipeRepository core.RecipeRepository
+	timers           *cooking.Timers
+}
+
+func NewStartCookTimerHandler(recipeRepository core.RecipeRepository, timers *cooking.Timers) *StartCookTimerHandler {
+	return &StartCookTimerHandler{
+		recipeRepository: recipeRepository,
+		timers:           timers,
+	}
+}
+
+func (handler *StartCookTimerHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	vars := mux.Vars(r)
+	recipe, err := handler.recipeRepository.GetRecipeByID(ctx, vars["id"])
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var timerForStart cookTimerForStartModel
+	err = json.NewDecoder(r.Body).Decode(&timerForStart)
+	if err != nil {
+		writeError(w, r, malformedRequest(err))
+		return
+	}
+
+	stepTimer, err := cooking.StepTimer(recipe, timerForStart.StepIndex, timerForStart.TimerIndex)
+	if err != nil {
+		writeError(w, r, invalidParam("timerIndex", err))
+		return
+	}
+
+	timer, err := handler.timers.Start(recipe.ID.Hex(), timerForStart.StepIndex, stepTimer.Name, stepTimer.Duration)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeCreated(w, r, timer.ID, newCookTimerModel(timer, time.Now()))
+}
+
+// DeleteCookTimerHandler cancels a running timer or dismisses a finished one.
+type DeleteCookTimerHandler struct {
+	timers *cooking.Timers
+}
+
+func NewDeleteCookTimerHandler(timers *cooking.Timers) *DeleteCookTimerHandler {
+	return &DeleteCookTimerHandler{
+		timers: timers,
+	}
+}
+
+func (handler *DeleteCookTimerHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	err := handler.timers.Remove(vars["id"], vars["timerId"])
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CookEventsHandler streams the timers of a recipe as server-sent events. A "timers" event with
+// all timers is sent on connect and after every change, devices replace their timers with it.
+type CookEventsHandler struct {
+	timers *cooking.Timers
+}
+
+func NewCookEventsHandler(timers *cooking.Timers) *CookEventsHandler {
+	return &CookEventsHandler{
+		timers: timers,
+	}
+}
+
+func (handler *CookEventsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, r, fmt.Errorf("streaming not supported by %T", w))
+		return
+	}
+
+	vars := mux.Vars(r)
+	updates, unsubscribe := handler.timers.Subscribe(vars["id"])
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", mediaTypeEventStream)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(cookHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		var err error
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			_, err = fmt.Fprint(w, ": heartbeat\n\n")
+		case timers := <-updates:
+			var jsonTimers []byte
+			jsonTimers, err = json.Marshal(newCookTimerModels(timers))
+			if err == nil {
+				_, err = fmt.Fprintf(w, "event: timers\ndata: %s\n\n", jsonTimers)
+			}
+		}
+		if err != nil {
+			log.Print(err)
+			return
+		}
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,25 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"path"
+)
+
+// writeCreated answers a POST with the created resource and its location below the request path.
+func writeCreated(w http.ResponseWriter, r *http.Request, id string, model interface{}) {
+	jsonModel, err := json.Marshal(model)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", mediaTypeJSON)
+	w.Header().Set("Location", path.Join(r.URL.Path, id))
+	w.WriteHeader(http.StatusCreated)
+	_, err = w.Write(jsonModel)
+	if err != nil {
+		log.Print(err)
+	}
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/phlashdev/recipe-keeper-api/core"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// recipeCSVHeader uses the same column names the CSV import maps by default.
+var recipeCSVHeader = []string{"id", "title", "source", "sourceType", "sourceAnnotation", "category", "allergens", "tags", "servings"}
+
+func writeRecipesCSV(w io.Writer, recipes []core.Recipe, sources []core.Source) error {
+	sourcesByID := make(map[primitive.ObjectID]core.Source, len(sources))
+	for _, source := range sources {
+		sourcesByID[source.ID] = source
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(recipeCSVHeader); err != nil {
+		return fmt.Errorf("error while writing csv: %v", err)
+	}
+
+	for _, recipe := range recipes {
+		source := sourcesByID[recipe.Source]
+
+		servings := ""
+		if recipe.Servings > 0 {
+			servings = strconv.Itoa(recipe.Servings)
+		}
+
+		record := []string{
+			recipe.ID.Hex(),
+			recipe.Title,
+			source.Title,
+			source.Type,
+			recipe.SourceAnnotation,
+			recipe.Category,
+			strings.Join(recipe.Allergens, ", "),
+			strings.Join(recipe.Tags, ", "),
+			servings,
+		}
+		if err := writer.Write(record); err != nil {
+			return fmt.Errorf("error while writing csv: %v", err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("error while writing csv: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"bytes"
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+var docsTemplate = template.Must(template.New("docs").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Recipe Keeper API</title>
+<style>
+body { font-family: sans-serif; max-width: 60em; margin: 2em auto; padding: 0 1em; }
+.operation { border-top: 1px solid #ddd; padding: 0.5em 0; }
+.method { display: inline-block; min-width: 4.5em; font-weight: bold; }
+code { background: #f4f4f4; padding: 0 0.2em; }
+</style>
+</head>
+<body>
+<h1>Recipe Keeper API</h1>
+<p>The machine-readable description is the <a href="{{.DocumentPath}}">OpenAPI document</a>. Errors are returned as <code>application/problem+json</code>.</p>
+{{range .Operations}}
+<div class="operation">
+<div><span class="method">{{.Method}}</span> <code>{{.Path}}</code> {{.Summary}}</div>
+<ul>
+{{range .Parameters}}<li>{{.In}} <code>{{.Name}}</code>: {{.Description}}</li>{{end}}
+{{range .Request}}<li>request <code>{{.}}</code></li>{{end}}
+{{range .Responses}}<li>{{.}}</li>{{end}}
+</ul>
+</div>
+{{end}}
+</body>
+</html>
+`))
+
+type docsOperationModel struct {
+	Method     string
+	Path       string
+	Summary    string
+	Parameters []apiParameter
+	Request    []string
+	Responses  []string
+}
+
+// APIDocsHandler renders the operations of the OpenAPI document as HTML page.
+type APIDocsHandler struct {
+	documentPath string
+}
+
+func NewAPIDocsHandler(documentPath string) *APIDocsHandler {
+	return &APIDocsHandler{
+		documentPath: documentPath,
+	}
+}
+
+func (handler *APIDocsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	operations := make([]docsOperationModel, 0, len(apiOperations))
+	for _, operation := range apiOperations {
+		var request []string
+		for _, content := range operation.Request {
+			request = append(request, content.MediaType)
+		}
+		var responses []string
+		for _, response := range operation.Responses {
+			description := strconv.Itoa(response.Status) + " " + response.Description
+			for _, content := range response.Content {
+				description += " (" + content.MediaType + ")"
+			}
+			responses = append(responses, description)
+		}
+
+		operations = append(operations, docsOperationModel{
+			Method:     operation.Method,
+			Path:       operation.Path,
+			Summary:    operation.Summary,
+			Parameters: operation.Parameters,
+			Request:    request,
+			Responses:  responses,
+		})
+	}
+
+	var buffer bytes.Buffer
+	err := docsTemplate.Execute(&buffer, struct {
+		DocumentPath string
+		Operations   []docsOperationModel
+	}{
+		DocumentPath: handler.documentPath,
+		Operations:   operations,
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, err = w.Write(buffer.Bytes())
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
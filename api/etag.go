@@ -0,0 +1,105 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/phlashdev/recipe-keeper-api/core"
+)
+
+// etagVariants names the recipe representations in their entity tags.
+var etagVariants = map[string]string{
+	mediaTypeJSONLD:   "jsonld",
+	mediaTypeCooklang: "cooklang",
+	mediaTypeMarkdown: "markdown",
+}
+
+// formatETag builds the entity tag of a version. Representations other than JSON get the variant
+// appended, they differ in content but still name the same version for If-Match.
+func formatETag(version int, variant string) string {
+	if len(variant) == 0 {
+		return `"` + strconv.Itoa(version) + `"`
+	}
+
+	return `"` + strconv.Itoa(version) + "-" + variant + `"`
+}
+
+// parseETagVersion returns the version named by an entity tag written by formatETag.
+func parseETagVersion(tag string) (int, bool) {
+	tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+	if len(tag) < 2 || !strings.HasPrefix(tag, `"`) || !strings.HasSuffix(tag, `"`) {
+		return 0, false
+	}
+
+	tag = tag[1 : len(tag)-1]
+	if index := strings.Index(tag, "-"); index >= 0 {
+		tag = tag[:index]
+	}
+
+	version, err := strconv.Atoi(tag)
+	return version, err == nil
+}
+
+// etagListMatches reports whether a list of entity tags from If-Match or If-None-Match contains
+// the version.
+func etagListMatches(header string, version int) bool {
+	for _, tag := range strings.Split(header, ",") {
+		if strings.TrimSpace(tag) == "*" {
+			return true
+		}
+		if tagVersion, ok := parseETagVersion(tag); ok && tagVersion == version {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkIfMatch answers with 412 Precondition Failed if the request names another version in
+// If-Match. Requests without If-Match always pass.
+func checkIfMatch(w http.ResponseWriter, r *http.Request, version int) bool {
+	header := r.Header.Get("If-Match")
+	if len(header) == 0 || etagListMatches(header, version) {
+		return true
+	}
+
+	writeProblem(w, r, newProblem(http.StatusPreconditionFailed, problemTypeVersionConflict, "the resource has been modified"))
+	return false
+}
+
+// checkIfNoneMatch sets the ETag and answers with 304 Not Modified if the client already has
+// the version.
+func checkIfNoneMatch(w http.ResponseWriter, r *http.Request, version int, variant string) bool {
+	etag := formatETag(version, variant)
+	w.Header().Set("ETag", etag)
+
+	header := r.Header.Get("If-None-Match")
+	if len(header) == 0 {
+		return true
+	}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return false
+		}
+	}
+
+	return true
+}
+
+// versionConflictStatus is 412 if the client sent a precondition that turned out outdated while
+// writing, otherwise the write raced with another one and the status is 409.
+func versionConflictStatus(r *http.Request, err error) (int, bool) {
+	var e *core.VersionConflictError
+	if !errors.As(err, &e) {
+		return 0, false
+	}
+	if len(r.Header.Get("If-Match")) > 0 {
+		return http.StatusPreconditionFailed, true
+	}
+
+	return http.StatusConflict, true
+}
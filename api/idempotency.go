@@ -0,0 +1,205 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/phlashdev/recipe-keeper-api/auth"
+	"github.com/phlashdev/recipe-keeper-api/core"
+)
+
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+	maxIdempotentRequestSize = 10 << 20
+)
+
+// idempotencyRecordTimeout limits each access of the idempotency records.
+var idempotencyRecordTimeout = 10 * time.Second
+
+// idempotentHeaders are the response headers stored to replay a response.
+var idempotentHeaders = []string{"Content-Type", "Location", "ETag"}
+
+// IdempotencyHandler makes POST requests safe to retry. The response to a request with an
+// Idempotency-Key header is stored and returned again for a retry with the same key, instead of
+// creating the resource twice. Failed requests are not stored, they can be retried with the same key.
+type IdempotencyHandler struct {
+	idempotencyRepository core.IdempotencyRepository
+	next                  http.Handler
+}
+
+func NewIdempotencyHandler(idempotencyRepository core.IdempotencyRepository, next http.Handler) *IdempotencyHandler {
+	return &IdempotencyHandler{
+		idempotencyRepository: idempotencyRepository,
+		next:                  next,
+	}
+}
+
+func (handler *IdempotencyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := r.Header.Get(idempotencyKeyHeader)
+	if len(key) == 0 {
+		handler.next.ServeHTTP(w, r)
+		return
+	}
+	if len(key) > maxIdempotencyKeyLength {
+		writeError(w, r, invalidParam(idempotencyKeyHeader, fmt.Errorf("must not be longer than %d characters", maxIdempotencyKeyLength)))
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentRequestSize))
+	if err != nil {
+		log.Print(err)
+		writeProblem(w, r, newProblem(http.StatusRequestEntityTooLarge, problemTypeTooLarge, err.Error()))
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	// keys are chosen by the clients, so each user has their own
+	user, _ := auth.UserFromContext(r.Context())
+	fingerprint := sha256.Sum256(body)
+	record := core.IdempotencyRecord{
+		Key:         user.ID.Hex() + " " + r.Method + " " + r.URL.Path + " " + key,
+		Fingerprint: hex.EncodeToString(fingerprint[:]),
+		CreatedAt:   time.Now(),
+	}
+	if !handler.addRecord(w, r, record) {
+		return
+	}
+
+	recorder := newResponseRecorder()
+	handler.next.ServeHTTP(recorder, r)
+
+	handler.completeRecord(record, recorder)
+	recorder.writeTo(w)
+}
+
+// addRecord marks the key as in progress. It answers the request itself and returns false if
+// the key was used before.
+func (handler *IdempotencyHandler) addRecord(w http.ResponseWriter, r *http.Request, record core.IdempotencyRecord) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), idempotencyRecordTimeout)
+	defer cancel()
+
+	err := handler.idempotencyRepository.AddIdempotencyRecord(ctx, record)
+	var existsErr *core.IdempotencyKeyExistsError
+	if errors.As(err, &existsErr) {
+		handler.replay(ctx, w, r, record)
+		return false
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return false
+	}
+
+	return true
+}
+
+// completeRecord stores a successful response for retries, the key of a failed request is
+// released. It gets a context of its own, as the wrapped handler may run longer than
+// idempotencyRecordTimeout.
+func (handler *IdempotencyHandler) completeRecord(record core.IdempotencyRecord, recorder *responseRecorder) {
+	ctx, cancel := context.WithTimeout(context.Background(), idempotencyRecordTimeout)
+	defer cancel()
+
+	var err error
+	if recorder.status >= 200 && recorder.status < 300 {
+		record.Status = recorder.status
+		record.Header = core.Header{}
+		for _, name := range idempotentHeaders {
+			if value := recorder.Header().Get(name); len(value) > 0 {
+				record.Header[name] = value
+			}
+		}
+		record.Body = recorder.body.Bytes()
+		err = handler.idempotencyRepository.CompleteIdempotencyRecord(ctx, record)
+	} else {
+		err = handler.idempotencyRepository.DeleteIdempotencyRecord(ctx, record.Key)
+	}
+	if err != nil {
+		// the request itself succeeded or failed as recorded, only a retry is affected
+		log.Print(err)
+	}
+}
+
+func (handler *IdempotencyHandler) replay(ctx context.Context, w http.ResponseWriter, r *http.Request, record core.IdempotencyRecord) {
+	stored, err := handler.idempotencyRepository.GetIdempotencyRecord(ctx, record.Key)
+	var notFoundErr *core.IdempotencyRecordNotFoundError
+	if errors.As(err, &notFoundErr) {
+		// the first request failed in the meantime
+		writeProblem(w, r, newProblem(http.StatusConflict, problemTypeConflict, "the request with this idempotency key was not completed, retry it"))
+		return
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if stored.Fingerprint != record.Fingerprint {
+		writeProblem(w, r, newProblem(http.StatusUnprocessableEntity, problemTypeUnprocessable, "the idempotency key was already used for another request"))
+		return
+	}
+	if stored.Status == 0 {
+		writeProblem(w, r, newProblem(http.StatusConflict, problemTypeConflict, "the request with this idempotency key is still being processed"))
+		return
+	}
+
+	for name, value := range stored.Header {
+		w.Header().Set(name, value)
+	}
+	w.Header().Set(idempotentReplayedHeader, "true")
+	w.WriteHeader(stored.Status)
+	_, err = w.Write(stored.Body)
+	if err != nil {
+		log.Print(err)
+	}
+}
+
+// responseRecorder buffers the response of the wrapped handler, so it can be stored before it is sent.
+type responseRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newResponseRecorder() *responseRecorder {
+	return &responseRecorder{
+		header: http.Header{},
+	}
+}
+
+func (recorder *responseRecorder) Header() http.Header {
+	return recorder.header
+}
+
+func (recorder *responseRecorder) WriteHeader(status int) {
+	if recorder.status == 0 {
+		recorder.status = status
+	}
+}
+
+func (recorder *responseRecorder) Write(data []byte) (int, error) {
+	recorder.WriteHeader(http.StatusOK)
+	return recorder.body.Write(data)
+}
+
+func (recorder *responseRecorder) writeTo(w http.ResponseWriter) {
+	for name, values := range recorder.header {
+		w.Header()[name] = values
+	}
+	if recorder.status == 0 {
+		recorder.status = http.StatusOK
+	}
+	w.WriteHeader(recorder.status)
+	_, err := w.Write(recorder.body.Bytes())
+	if err != nil {
+		log.Print(err)
+	}
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/phlashdev/recipe-keeper-api/core"
+)
+
+// memoryIdempotencyRepository fails on expired contexts like the mongo repository does.
+type memoryIdempotencyRepository struct {
+	mutex   sync.Mutex
+	records map[string]core.IdempotencyRecord
+}
+
+func (repo *memoryIdempotencyRepository) GetIdempotencyRecord(ctx context.Context, key string) (core.IdempotencyRecord, error) {
+	if err := ctx.Err(); err != nil {
+		return core.IdempotencyRecord{}, err
+	}
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	record, ok := repo.records[key]
+	if !ok {
+		return core.IdempotencyRecord{}, &core.IdempotencyRecordNotFoundError{Key: key}
+	}
+
+	return record, nil
+}
+
+func (repo *memoryIdempotencyRepository) AddIdempotencyRecord(ctx context.Context, record core.IdempotencyRecord) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	if _, ok := repo.records[record.Key]; ok {
+		return &core.IdempotencyKeyExistsError{Key: record.Key}
+	}
+	repo.records[record.Key] = record
+
+	return nil
+}
+
+func (repo *memoryIdempotencyRepository) CompleteIdempotencyRecord(ctx context.Context, record core.IdempotencyRecord) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	repo.records[record.Key] = record
+
+	return nil
+}
+
+func (repo *memoryIdempotencyRepository) DeleteIdempotencyRecord(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	delete(repo.records, key)
+
+	return nil
+}
+
+func TestIdempotencyHandlerOutlastsRecordTimeout(t *testing.T) {
+	timeout := idempotencyRecordTimeout
+	idempotencyRecordTimeout = 50 * time.Millisecond
+	defer func() { idempotencyRecordTimeout = timeout }()
+
+	tests := []struct {
+		name     string
+		status   int
+		replayed bool
+	}{
+		{"success is stored", http.StatusCreated, true},
+		{"failure releases the key", http.StatusInternalServerError, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repo := &memoryIdempotencyRepository{records: map[string]core.IdempotencyRecord{}}
+			calls := 0
+			// the wrapped handler runs longer than the records may take, like a large batch
+			slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				time.Sleep(2 * idempotencyRecordTimeout)
+				w.WriteHeader(test.status)
+			})
+			handler := NewIdempotencyHandler(repo, slow)
+
+			for attempt := 1; attempt <= 2; attempt++ {
+				r := httptest.NewRequest(http.MethodPost, "/api/recipes/batch", strings.NewReader(`[]`))
+				r.Header.Set(idempotencyKeyHeader, "key-1")
+				w := httptest.NewRecorder()
+
+				handler.ServeHTTP(w, r)
+
+				if w.Code != test.status {
+					t.Errorf("attempt %d: status = %d, want %d", attempt, w.Code, test.status)
+				}
+			}
+
+			wantCalls := 2
+			if test.replayed {
+				wantCalls = 1
+			}
+			if calls != wantCalls {
+				t.Errorf("wrapped handler called %d times, want %d", calls, wantCalls)
+			}
+		})
+	}
+}
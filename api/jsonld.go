@@ -0,0 +1,84 @@
+package api
+
+import (
+	"strings"
+
+	"github.com/phlashdev/recipe-keeper-api/core"
+)
+
+const schemaOrgContext = "https://schema.org"
+
+type jsonLDThing struct {
+	Type string `json:"@type"`
+	Name string `json:"name,omitempty"`
+	URL  string `json:"url,omitempty"`
+}
+
+type recipeJSONLDModel struct {
+	Context         string       `json:"@context"`
+	Type            string       `json:"@type"`
+	Name            string       `json:"name"`
+	RecipeCategory  string       `json:"recipeCategory,omitempty"`
+	SuitableForDiet []string     `json:"suitableForDiet,omitempty"`
+	Keywords        string       `json:"keywords,omitempty"`
+	Author          *jsonLDThing `json:"author,omitempty"`
+	IsBasedOn       *jsonLDThing `json:"isBasedOn,omitempty"`
+}
+
+// restrictedDietAllergens lists for each schema.org RestrictedDiet the allergens a recipe must not contain.
+var restrictedDietAllergens = map[string][]string{
+	"https://schema.org/GlutenFreeDiet": {"gluten", "wheat", "weizen"},
+	"https://schema.org/LowLactoseDiet": {"lactose", "laktose", "milk", "milch"},
+}
+
+func newRecipeJSONLDModel(recipe core.Recipe, source *core.Source) recipeJSONLDModel {
+	model := recipeJSONLDModel{
+		Context:         schemaOrgContext,
+		Type:            "Recipe",
+		Name:            recipe.Title,
+		RecipeCategory:  recipe.Category,
+		SuitableForDiet: suitableForDiet(recipe.Allergens),
+		Keywords:        strings.Join(recipe.Tags, ", "),
+	}
+
+	if source != nil {
+		switch source.Type {
+		case core.SourceTypeBook:
+			model.IsBasedOn = &jsonLDThing{Type: "Book", Name: source.Title}
+		case core.SourceTypeUrl:
+			model.IsBasedOn = &jsonLDThing{Type: "WebPage", URL: source.Title}
+		case core.SourceTypeCustom:
+			model.Author = &jsonLDThing{Type: "Person", Name: source.Title}
+		}
+	}
+
+	return model
+}
+
+func suitableForDiet(allergens []string) []string {
+	// without any declared allergens we cannot tell whether they were just not recorded
+	if len(allergens) == 0 {
+		return nil
+	}
+
+	var diets []string
+	for _, diet := range []string{"https://schema.org/GlutenFreeDiet", "https://schema.org/LowLactoseDiet"} {
+		if !containsAnyAllergen(allergens, restrictedDietAllergens[diet]) {
+			diets = append(diets, diet)
+		}
+	}
+
+	return diets
+}
+
+func containsAnyAllergen(allergens []string, candidates []string) bool {
+	for _, allergen := range allergens {
+		for _, candidate := range candidates {
+			if strings.EqualFold(strings.TrimSpace(allergen), candidate) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
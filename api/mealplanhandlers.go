@@ -0,0 +1,350 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/phlashdev/recipe-keeper-api/core"
+	"github.com/phlashdev/recipe-keeper-api/ical"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	dateFormat = "2006-01-02"
+
+	mediaTypeCalendar = "text/calendar"
+)
+
+type mealPlanEntryModel struct {
+	Date     string `json:"date"`
+	Slot     string `json:"slot"`
+	RecipeID string `json:"recipeId"`
+	Servings int    `json:"servings"`
+}
+
+type mealPlanModelBase struct {
+	Title   string               `json:"title"`
+	Entries []mealPlanEntryModel `json:"entries"`
+}
+
+type mealPlanModel struct {
+	ID string `json:"id"`
+	mealPlanModelBase
+}
+
+type mealPlanForCreationModel struct {
+	mealPlanModelBase
+}
+
+type mealPlanForUpdateModel struct {
+	mealPlanModelBase
+}
+
+func newMealPlanModel(mealPlan core.MealPlan) mealPlanModel {
+	entryModels := make([]mealPlanEntryModel, 0, len(mealPlan.Entries))
+	for _, entry := range mealPlan.Entries {
+		entryModels = append(entryModels, mealPlanEntryModel{
+			Date:     entry.Date.Format(dateFormat),
+			Slot:     entry.Slot,
+			RecipeID: entry.Recipe.Hex(),
+			Servings: entry.Servings,
+		})
+	}
+
+	return mealPlanModel{
+		ID: mealPlan.ID.Hex(),
+		mealPlanModelBase: mealPlanModelBase{
+			Title:   mealPlan.Title,
+			Entries: entryModels,
+		},
+	}
+}
+
+// toMealPlanEntries converts the entries and checks that every referenced recipe exists.
+func toMealPlanEntries(ctx context.Context, recipeRepository core.RecipeRepository, entryModels []mealPlanEntryModel) ([]core.MealPlanEntry, error) {
+	var entries []core.MealPlanEntry
+	for i, entryModel := range entryModels {
+		date, err := time.Parse(dateFormat, entryModel.Date)
+		if err != nil {
+			return nil, invalidParam(fmt.Sprintf("entries[%d].date", i), &core.DateNotValidError{Date: entryModel.Date})
+		}
+
+		recipe, err := recipeRepository.GetRecipeByID(ctx, entryModel.RecipeID)
+		if err != nil {
+			return nil, invalidParam(fmt.Sprintf("entries[%d].recipeId", i), err)
+		}
+
+		entries = append(entries, core.MealPlanEntry{
+			Date:     date,
+			Slot:     entryModel.Slot,
+			Recipe:   recipe.ID,
+			Servings: entryModel.Servings,
+		})
+	}
+
+	return entries, nil
+}
+
+type GetMealPlansHandler struct {
+	mealPlanRepository core.MealPlanRepository
+}
+
+func NewGetMealPlansHandler(mealPlanRepository core.MealPlanRepository) *GetMealPlansHandler {
+	return &GetMealPlansHandler{
+		mealPlanRepository: mealPlanRepository,
+	}
+}
+
+func (handler *GetMealPlansHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	mealPlans, err := handler.mealPlanRepository.GetMealPlans(ctx)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var mealPlanModels = make([]mealPlanModel, 0, len(mealPlans))
+	for _, mealPlan := range mealPlans {
+		mealPlanModels = append(mealPlanModels, newMealPlanModel(mealPlan))
+	}
+
+	jsonMealPlans, err := json.Marshal(mealPlanModels)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	_, err = w.Write(jsonMealPlans)
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+type GetMealPlanHandler struct {
+	mealPlanRepository core.MealPlanRepository
+}
+
+func NewGetMealPlanHandler(mealPlanRepository core.MealPlanRepository) *GetMealPlanHandler {
+	return &GetMealPlanHandler{
+		mealPlanRepository: mealPlanRepository,
+	}
+}
+
+func (handler *GetMealPlanHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	vars := mux.Vars(r)
+	id := vars["id"]
+	mealPlan, err := handler.mealPlanRepository.GetMealPlanByID(ctx, id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	jsonMealPlan, err := json.Marshal(newMealPlanModel(mealPlan))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	_, err = w.Write(jsonMealPlan)
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+type GetMealPlanCalendarHandler struct {
+	mealPlanRepository core.MealPlanRepository
+	recipeRepository   core.RecipeRepository
+}
+
+func NewGetMealPlanCalendarHandler(mealPlanRepository core.MealPlanRepository, recipeRepository core.RecipeRepository) *GetMealPlanCalendarHandler {
+	return &GetMealPlanCalendarHandler{
+		mealPlanRepository: mealPlanRepository,
+		recipeRepository:   recipeRepository,
+	}
+}
+
+func (handler *GetMealPlanCalendarHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	vars := mux.Vars(r)
+	id := vars["id"]
+	mealPlan, err := handler.mealPlanRepository.GetMealPlanByID(ctx, id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	recipes := map[primitive.ObjectID]core.Recipe{}
+	for _, entry := range mealPlan.Entries {
+		if _, ok := recipes[entry.Recipe]; ok {
+			continue
+		}
+
+		recipe, err := handler.recipeRepository.GetRecipeByID(ctx, entry.Recipe.Hex())
+		if err != nil {
+			// recipes deleted after planning are listed without title
+			var e *core.RecipeNotFoundError
+			if errors.As(err, &e) {
+				continue
+			}
+			writeError(w, r, err)
+			return
+		}
+		recipes[entry.Recipe] = recipe
+	}
+
+	var buffer bytes.Buffer
+	err = ical.WriteMealPlan(&buffer, mealPlan, recipes)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", mediaTypeCalendar+"; charset=utf-8")
+	w.Header().Set("Content-Disposition", attachmentDisposition(mealPlan.Title, ".ics"))
+	_, err = w.Write(buffer.Bytes())
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+type AddMealPlanHandler struct {
+	mealPlanRepository core.MealPlanRepository
+	recipeRepository   core.RecipeRepository
+}
+
+func NewAddMealPlanHandler(mealPlanRepository core.MealPlanRepository, recipeRepository core.RecipeRepository) *AddMealPlanHandler {
+	return &AddMealPlanHandler{
+		mealPlanRepository: mealPlanRepository,
+		recipeRepository:   recipeRepository,
+	}
+}
+
+func (handler *AddMealPlanHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var mealPlanForCreation mealPlanForCreationModel
+	err := json.NewDecoder(r.Body).Decode(&mealPlanForCreation)
+	if err != nil {
+		writeError(w, r, malformedRequest(err))
+		return
+	}
+
+	entries, err := toMealPlanEntries(ctx, handler.recipeRepository, mealPlanForCreation.Entries)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	mealPlan := core.MealPlan{
+		Title:   mealPlanForCreation.Title,
+		Entries: entries,
+	}
+	err = handler.mealPlanRepository.AddMealPlan(ctx, &mealPlan)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeCreated(w, r, mealPlan.ID.Hex(), newMealPlanModel(mealPlan))
+}
+
+type UpdateMealPlanHandler struct {
+	mealPlanRepository core.MealPlanRepository
+	recipeRepository   core.RecipeRepository
+}
+
+func NewUpdateMealPlanHandler(mealPlanRepository core.MealPlanRepository, recipeRepository core.RecipeRepository) *UpdateMealPlanHandler {
+	return &UpdateMealPlanHandler{
+		mealPlanRepository: mealPlanRepository,
+		recipeRepository:   recipeRepository,
+	}
+}
+
+func (handler *UpdateMealPlanHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	vars := mux.Vars(r)
+	id := vars["id"]
+	mealPlan, err := handler.mealPlanRepository.GetMealPlanByID(ctx, id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var mealPlanForUpdate mealPlanForUpdateModel
+	err = json.NewDecoder(r.Body).Decode(&mealPlanForUpdate)
+	if err != nil {
+		writeError(w, r, malformedRequest(err))
+		return
+	}
+
+	entries, err := toMealPlanEntries(ctx, handler.recipeRepository, mealPlanForUpdate.Entries)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	mealPlan.Title = mealPlanForUpdate.Title
+	mealPlan.Entries = entries
+
+	err = handler.mealPlanRepository.UpdateMealPlan(ctx, mealPlan)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type DeleteMealPlanHandler struct {
+	mealPlanRepository core.MealPlanRepository
+}
+
+func NewDeleteMealPlanHandler(mealPlanRepository core.MealPlanRepository) *DeleteMealPlanHandler {
+	return &DeleteMealPlanHandler{
+		mealPlanRepository: mealPlanRepository,
+	}
+}
+
+func (handler *DeleteMealPlanHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	vars := mux.Vars(r)
+	id := vars["id"]
+	mealPlan, err := handler.mealPlanRepository.GetMealPlanByID(ctx, id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	err = handler.mealPlanRepository.DeleteMealPlan(ctx, mealPlan)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
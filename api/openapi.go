@@ -0,0 +1,589 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/phlashdev/recipe-keeper-api/patch"
+)
+
+const openAPIVersion = "3.0.3"
+
+// apiOperation describes a route for the OpenAPI document. Request and response models are
+// given as values of the model types, their schemas are derived from the json tags so the
+// document cannot drift from the models.
+type apiOperation struct {
+	Method      string
+	Path        string
+	Tag         string
+	Summary     string
+	Parameters  []apiParameter
+	Request     []apiContent
+	Responses   []apiResponse
+	Idempotency bool
+	// Public operations need no session, all others need a bearer token returned by the login.
+	Public bool
+}
+
+type apiParameter struct {
+	Name        string
+	In          string
+	Description string
+	Type        string
+}
+
+type apiContent struct {
+	MediaType string
+	Model     interface{}
+}
+
+type apiResponse struct {
+	Status      int
+	Description string
+	Content     []apiContent
+}
+
+// jsonPatchOperationModel documents the operations of a JSON Patch, they are read by package patch.
+type jsonPatchOperationModel struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	From  string      `json:"from,omitempty"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+var (
+	recipeFilterParameters = []apiParameter{
+		{Name: "title", In: "query", Description: "part of the title, case insensitive", Type: "string"},
+		{Name: "category", In: "query", Description: "category", Type: "string"},
+		{Name: "sourceId", In: "query", Description: "id of the source", Type: "string"},
+		{Name: "tag", In: "query", Description: "tag", Type: "string"},
+		pageParameters[0],
+		pageParameters[1],
+	}
+	pageParameters = []apiParameter{
+		{Name: "offset", In: "query", Description: "number of items to skip", Type: "integer"},
+		{Name: "limit", In: "query", Description: "maximum number of items, all if not set", Type: "integer"},
+	}
+	ifMatchParameter = apiParameter{Name: "If-Match", In: "header", Description: "entity tag of the version the change is based on", Type: "string"}
+)
+
+// textContent is the model of plain text representations like Cooklang or Markdown.
+var textContent = ""
+
+// binaryContent is the model of binary representations like PDF.
+var binaryContent = []byte(nil)
+
+var apiOperations = []apiOperation{
+	{
+		Method: http.MethodPost, Path: "/api/login", Tag: "auth",
+		Summary: "Log in with username and password",
+		Request: []apiContent{{mediaTypeJSON, loginModel{}}},
+		Public:  true,
+		Responses: []apiResponse{
+			{Status: http.StatusOK, Description: "the session token to send as bearer token", Content: []apiContent{{mediaTypeJSON, sessionModel{}}}},
+		},
+	},
+	{
+		Method: http.MethodPost, Path: "/api/logout", Tag: "auth",
+		Summary:   "End the session of the bearer token",
+		Public:    true,
+		Responses: []apiResponse{{Status: http.StatusNoContent, Description: "the session ended"}},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/me", Tag: "auth",
+		Summary: "Get the signed in user",
+		Responses: []apiResponse{
+			{Status: http.StatusOK, Description: "the user of the session", Content: []apiContent{{mediaTypeJSON, userModel{}}}},
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/recipes", Tag: "recipes",
+		Summary:    "List recipes",
+		Parameters: recipeFilterParameters,
+		Responses: []apiResponse{
+			{Status: http.StatusOK, Description: "the matching recipes", Content: []apiContent{{mediaTypeJSON, []recipeModel{}}, {mediaTypeCSV, textContent}}},
+		},
+	},
+	{
+		Method: http.MethodPost, Path: "/api/recipes", Tag: "recipes",
+		Summary:     "Create a recipe",
+		Request:     []apiContent{{mediaTypeJSON, recipeForCreationModel{}}, {mediaTypeMarkdown, textContent}},
+		Idempotency: true,
+		Responses: []apiResponse{
+			{Status: http.StatusCreated, Description: "the created recipe", Content: []apiContent{{mediaTypeJSON, recipeModel{}}}},
+		},
+	},
+	{
+		Method: http.MethodPost, Path: "/api/recipes/batch", Tag: "recipes",
+		Summary:     "Create, update and delete several recipes",
+		Request:     []apiContent{{mediaTypeJSON, []recipeOperationModel{}}},
+		Idempotency: true,
+		Responses: []apiResponse{
+			{Status: http.StatusOK, Description: "a result per operation", Content: []apiContent{{mediaTypeJSON, batchResultModel{}}}},
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/recipes/{id}", Tag: "recipes",
+		Summary: "Get a recipe",
+		Responses: []apiResponse{
+			{Status: http.StatusOK, Description: "the recipe", Content: []apiContent{{mediaTypeJSON, recipeModel{}}, {mediaTypeJSONLD, recipeJSONLDModel{}}, {mediaTypeCooklang, textContent}, {mediaTypeMarkdown, textContent}}},
+			{Status: http.StatusNotModified, Description: "the recipe matches If-None-Match"},
+		},
+	},
+	{
+		Method: http.MethodPut, Path: "/api/recipes/{id}", Tag: "recipes",
+		Summary:    "Replace a recipe",
+		Parameters: []apiParameter{ifMatchParameter},
+		Request:    []apiContent{{mediaTypeJSON, recipeForUpdateModel{}}},
+		Responses:  []apiResponse{{Status: http.StatusNoContent, Description: "the recipe was updated, the ETag names the new version"}},
+	},
+	{
+		Method: http.MethodPatch, Path: "/api/recipes/{id}", Tag: "recipes",
+		Summary:    "Change parts of a recipe",
+		Parameters: []apiParameter{ifMatchParameter},
+		Request:    []apiContent{{patch.MediaTypeMergePatch, recipeModelBase{}}, {patch.MediaTypeJSONPatch, []jsonPatchOperationModel{}}},
+		Responses: []apiResponse{
+			{Status: http.StatusOK, Description: "the changed recipe", Content: []apiContent{{mediaTypeJSON, recipeModel{}}}},
+		},
+	},
+	{
+		Method: http.MethodDelete, Path: "/api/recipes/{id}", Tag: "recipes",
+		Summary:    "Move a recipe to the trash",
+		Parameters: []apiParameter{ifMatchParameter},
+		Responses:  []apiResponse{{Status: http.StatusNoContent, Description: "the recipe was moved to the trash"}},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/recipes/{id}.pdf", Tag: "recipes",
+		Summary: "Get a recipe as PDF",
+		Responses: []apiResponse{
+			{Status: http.StatusOK, Description: "the recipe", Content: []apiContent{{mediaTypePDF, binaryContent}}},
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/recipes/{id}/revisions", Tag: "revisions",
+		Summary: "List the revisions of a recipe",
+		Responses: []apiResponse{
+			{Status: http.StatusOK, Description: "the revisions, oldest first", Content: []apiContent{{mediaTypeJSON, []revisionSummaryModel{}}}},
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/recipes/{id}/revisions/diff", Tag: "revisions",
+		Summary: "Compare two revisions of a recipe",
+		Parameters: []apiParameter{
+			{Name: "from", In: "query", Description: "number of the older revision", Type: "integer"},
+			{Name: "to", In: "query", Description: "number of the newer revision", Type: "integer"},
+		},
+		Responses: []apiResponse{
+			{Status: http.StatusOK, Description: "the changed fields", Content: []apiContent{{mediaTypeJSON, revisionDiffModel{}}}},
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/recipes/{id}/revisions/{number}", Tag: "revisions",
+		Summary: "Get a revision of a recipe",
+		Responses: []apiResponse{
+			{Status: http.StatusOK, Description: "the revision", Content: []apiContent{{mediaTypeJSON, revisionModel{}}}},
+		},
+	},
+	{
+		Method: http.MethodPost, Path: "/api/recipes/{id}/revisions/{number}/restore", Tag: "revisions",
+		Summary:   "Restore a revision of a recipe",
+		Responses: []apiResponse{{Status: http.StatusNoContent, Description: "the revision was restored as new revision"}},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/recipes/{id}/cook", Tag: "cook",
+		Summary: "Get a recipe in cook mode",
+		Responses: []apiResponse{
+			{Status: http.StatusOK, Description: "the overview with the running timers", Content: []apiContent{{mediaTypeJSON, cookModel{}}}},
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/recipes/{id}/cook/steps/{number}", Tag: "cook",
+		Summary: "Get a step in cook mode, steps are numbered starting at 1",
+		Responses: []apiResponse{
+			{Status: http.StatusOK, Description: "the step with the ingredients it uses", Content: []apiContent{{mediaTypeJSON, cookStepModel{}}}},
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/recipes/{id}/cook/timers", Tag: "cook",
+		Summary: "List the timers of a recipe",
+		Responses: []apiResponse{
+			{Status: http.StatusOK, Description: "the running and recently finished timers", Content: []apiContent{{mediaTypeJSON, []cookTimerModel{}}}},
+		},
+	},
+	{
+		Method: http.MethodPost, Path: "/api/recipes/{id}/cook/timers", Tag: "cook",
+		Summary:     "Start a timer of a step",
+		Request:     []apiContent{{mediaTypeJSON, cookTimerForStartModel{}}},
+		Idempotency: true,
+		Responses: []apiResponse{
+			{Status: http.StatusCreated, Description: "the started timer", Content: []apiContent{{mediaTypeJSON, cookTimerModel{}}}},
+		},
+	},
+	{
+		Method: http.MethodDelete, Path: "/api/recipes/{id}/cook/timers/{timerId}", Tag: "cook",
+		Summary:   "Cancel or dismiss a timer",
+		Responses: []apiResponse{{Status: http.StatusNoContent, Description: "the timer was removed"}},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/recipes/{id}/cook/events", Tag: "cook",
+		Summary: "Follow the timers of a recipe",
+		Responses: []apiResponse{
+			{Status: http.StatusOK, Description: "server-sent \"timers\" events with all timers as JSON, sent on connect and after every change", Content: []apiContent{{mediaTypeEventStream, textContent}}},
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/sources", Tag: "sources",
+		Summary:    "List sources",
+		Parameters: pageParameters,
+		Responses: []apiResponse{
+			{Status: http.StatusOK, Description: "all sources", Content: []apiContent{{mediaTypeJSON, []sourceModel{}}}},
+		},
+	},
+	{
+		Method: http.MethodPost, Path: "/api/sources", Tag: "sources",
+		Summary:     "Create a source",
+		Request:     []apiContent{{mediaTypeJSON, sourceForCreationModel{}}},
+		Idempotency: true,
+		Responses: []apiResponse{
+			{Status: http.StatusCreated, Description: "the created source", Content: []apiContent{{mediaTypeJSON, sourceModel{}}}},
+		},
+	},
+	{
+		Method: http.MethodPost, Path: "/api/sources/batch", Tag: "sources",
+		Summary:     "Create, update and delete several sources",
+		Request:     []apiContent{{mediaTypeJSON, []sourceOperationModel{}}},
+		Idempotency: true,
+		Responses: []apiResponse{
+			{Status: http.StatusOK, Description: "a result per operation", Content: []apiContent{{mediaTypeJSON, batchResultModel{}}}},
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/api/sources/{id}", Tag: "sources",
+		Summary: "Get a source",
+		Responses: []apiResponse{
+			{Status: http.StatusOK, Description: "the source", Content: []apiContent{{mediaTypeJSON, sourceModel{}}}},
+			{Status: http.StatusNotModified, Description: "the source matches If-None-Match"},
+		},
+	},
+	{
+		Method: http.MethodPut, Path: "/api/sources/{id}", Tag: "sources",
+		Summary:    "Replace a source",
+		Parameters: []apiParameter{ifMatchParameter},
+		Request:    []apiContent{{mediaTypeJSON, sourceForUpdateModel{}}},
+		Responses:  []apiResponse{{Status: http.StatusNoContent, Description: "the source was updated, the ETag names the new version"}},
+	},
+	{
+		Method: http.MethodPatch, Path: "/api/sources/{id}", Tag: "sources",
+		Summary:    "Change parts of a source",
+		Parameters: []apiParameter{ifMatchParameter},
+		Request:    []apiContent{{patch.MediaTypeMergePatch, sourceModelBase{}}, {patch.MediaTypeJSONPatch, []jsonPatchOperationModel{}}},
+		Responses: []apiResponse{
+			{Status: http.StatusOK, Description: "the changed source", Content: []apiContent{{mediaTypeJSON, sourceModel{}}}},
+		},
+	},
+	{
+		Method: http.MethodDelete, Path: "/api/sources/{id}", Tag: "sources",
+		Summary:    "Move a source to the trash",
+		Parameters: []apiParameter{ifMatchParameter},
+		Responses:  []apiResponse{{Status: http.StatusNoContent, Description: "the source was moved to the trash"}},
+	},
+}
+
+var pathParameterPattern = regexp.MustCompile(`\{(\w+)(:[^}]*)?\}`)
+
+// newOpenAPIDocument builds the OpenAPI document of the operations.
+func newOpenAPIDocument(operations []apiOperation) map[string]interface{} {
+	schemas := schemaRegistry{}
+	paths := map[string]map[string]interface{}{}
+	for _, operation := range operations {
+		if paths[operation.Path] == nil {
+			paths[operation.Path] = map[string]interface{}{}
+		}
+		paths[operation.Path][strings.ToLower(operation.Method)] = schemas.operation(operation)
+	}
+
+	return map[string]interface{}{
+		"openapi": openAPIVersion,
+		"info": map[string]interface{}{
+			"title":   "Recipe Keeper API",
+			"version": "1",
+		},
+		"paths":    paths,
+		"security": []interface{}{map[string]interface{}{"session": []string{}}},
+		"components": map[string]interface{}{
+			"schemas": schemas,
+			"securitySchemes": map[string]interface{}{
+				"session": map[string]interface{}{"type": "http", "scheme": "bearer"},
+			},
+		},
+	}
+}
+
+// schemaRegistry collects the schemas of named structs under their type name, so they are
+// referenced instead of repeated.
+type schemaRegistry map[string]interface{}
+
+func (schemas schemaRegistry) operation(operation apiOperation) map[string]interface{} {
+	var parameters []interface{}
+	for _, match := range pathParameterPattern.FindAllStringSubmatch(operation.Path, -1) {
+		parameters = append(parameters, map[string]interface{}{
+			"name":     match[1],
+			"in":       "path",
+			"required": true,
+			"schema":   map[string]interface{}{"type": "string"},
+		})
+	}
+	for _, parameter := range operation.Parameters {
+		parameters = append(parameters, map[string]interface{}{
+			"name":        parameter.Name,
+			"in":          parameter.In,
+			"description": parameter.Description,
+			"schema":      map[string]interface{}{"type": parameter.Type},
+		})
+	}
+	if operation.Idempotency {
+		parameters = append(parameters, map[string]interface{}{
+			"name":        idempotencyKeyHeader,
+			"in":          "header",
+			"description": "key making retries of the request safe",
+			"schema":      map[string]interface{}{"type": "string", "maxLength": maxIdempotencyKeyLength},
+		})
+	}
+
+	responses := map[string]interface{}{
+		"default": map[string]interface{}{
+			"description": "error",
+			"content":     schemas.content([]apiContent{{mediaTypeProblem, problemModel{}}}),
+		},
+	}
+	for _, response := range operation.Responses {
+		responseObject := map[string]interface{}{"description": response.Description}
+		if len(response.Content) > 0 {
+			responseObject["content"] = schemas.content(response.Content)
+		}
+		responses[strconv.Itoa(response.Status)] = responseObject
+	}
+
+	operationObject := map[string]interface{}{
+		"operationId": operationID(operation),
+		"summary":     operation.Summary,
+		"tags":        []string{operation.Tag},
+		"responses":   responses,
+	}
+	if len(parameters) > 0 {
+		operationObject["parameters"] = parameters
+	}
+	if operation.Public {
+		operationObject["security"] = []interface{}{}
+	}
+	if len(operation.Request) > 0 {
+		operationObject["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content":  schemas.content(operation.Request),
+		}
+	}
+
+	return operationObject
+}
+
+func (schemas schemaRegistry) content(contents []apiContent) map[string]interface{} {
+	content := map[string]interface{}{}
+	for _, c := range contents {
+		content[c.MediaType] = map[string]interface{}{
+			"schema": schemas.schema(reflect.TypeOf(c.Model)),
+		}
+	}
+
+	return content
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func (schemas schemaRegistry) schema(t reflect.Type) map[string]interface{} {
+	if t == nil {
+		return map[string]interface{}{}
+	}
+	if t == timeType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return schemas.schema(t.Elem())
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "binary"}
+		}
+		return map[string]interface{}{"type": "array", "items": schemas.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemas.schema(t.Elem())}
+	case reflect.Struct:
+		name := schemaName(t)
+		if _, ok := schemas[name]; !ok {
+			// registered before the fields, so recursive models end in a reference
+			schemas[name] = nil
+			schemas[name] = schemas.object(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	default:
+		// interface{} fields can hold any value
+		return map[string]interface{}{}
+	}
+}
+
+func (schemas schemaRegistry) object(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	schemas.addProperties(t, properties)
+
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+}
+
+// addProperties adds the fields of t the way encoding/json writes them, embedded structs are flattened.
+func (schemas schemaRegistry) addProperties(t reflect.Type, properties map[string]interface{}) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		if field.Anonymous && len(tag) == 0 {
+			schemas.addProperties(field.Type, properties)
+			continue
+		}
+		if len(field.PkgPath) > 0 {
+			continue
+		}
+
+		name := strings.Split(tag, ",")[0]
+		if len(name) == 0 {
+			name = field.Name
+		}
+		properties[name] = schemas.schema(field.Type)
+	}
+}
+
+// schemaName turns model type names like recipeForCreationModel into RecipeForCreation.
+func schemaName(t reflect.Type) string {
+	name := strings.TrimSuffix(t.Name(), "Model")
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// operationID derives a unique id like getRecipesIdRevisions from method and path.
+func operationID(operation apiOperation) string {
+	id := strings.ToLower(operation.Method)
+	for _, segment := range strings.FieldsFunc(operation.Path, func(r rune) bool { return r == '/' || r == '.' }) {
+		if segment == "api" {
+			continue
+		}
+		segment = strings.Trim(segment, "{}")
+		id += strings.ToUpper(segment[:1]) + segment[1:]
+	}
+
+	return id
+}
+
+// CheckRoutesDocumented returns an error naming every route below the given path prefixes that
+// is missing in the OpenAPI document. The tests run it on the routes of RegisterRoutes.
+func CheckRoutesDocumented(router *mux.Router, pathPrefixes ...string) error {
+	documented := map[string]bool{}
+	for _, operation := range apiOperations {
+		documented[operation.Method+" "+operation.Path] = true
+	}
+
+	var undocumented []string
+	err := router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			// path prefixes of subrouters
+			return nil
+		}
+
+		path := pathParameterPattern.ReplaceAllString(template, "{$1}")
+		if len(path) > 1 {
+			path = strings.TrimSuffix(path, "/")
+		}
+		if !hasAnyPrefix(path, pathPrefixes) {
+			return nil
+		}
+		for _, method := range methods {
+			if !documented[method+" "+path] {
+				undocumented = append(undocumented, method+" "+path)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(undocumented) > 0 {
+		sort.Strings(undocumented)
+		return fmt.Errorf("routes missing in the OpenAPI document: %s", strings.Join(undocumented, ", "))
+	}
+
+	return nil
+}
+
+// hasAnyPrefix compares whole path segments, "/api/me" does not include "/api/mealplans".
+func hasAnyPrefix(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		prefix = strings.TrimSuffix(prefix, "/")
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+type OpenAPIHandler struct {
+	document []byte
+	err      error
+}
+
+func NewOpenAPIHandler() *OpenAPIHandler {
+	document, err := json.Marshal(newOpenAPIDocument(apiOperations))
+	return &OpenAPIHandler{
+		document: document,
+		err:      err,
+	}
+}
+
+func (handler *OpenAPIHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if handler.err != nil {
+		writeError(w, r, handler.err)
+		return
+	}
+
+	w.Header().Set("Content-Type", mediaTypeJSON)
+	_, err := w.Write(handler.document)
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
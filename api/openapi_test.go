@@ -0,0 +1,60 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/phlashdev/recipe-keeper-api/cooking"
+)
+
+func TestRoutesDocumented(t *testing.T) {
+	router := mux.NewRouter()
+	RegisterRoutes(router, Repositories{}, nil, cooking.NewTimers(time.Minute))
+
+	err := CheckRoutesDocumented(router, "/api/recipes", "/api/sources", "/api/login", "/api/logout", "/api/me")
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCheckRoutesDocumentedReportsMissingRoutes(t *testing.T) {
+	router := mux.NewRouter()
+	router.Handle("/api/recipes/{id}/undocumented", http.NotFoundHandler()).Methods(http.MethodGet)
+
+	err := CheckRoutesDocumented(router, "/api/recipes")
+	if err == nil || !strings.Contains(err.Error(), "GET /api/recipes/{id}/undocumented") {
+		t.Errorf("err = %v, want the undocumented route", err)
+	}
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	router := mux.NewRouter()
+	RegisterRoutes(router, Repositories{}, nil, cooking.NewTimers(time.Minute))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d", w.Code)
+	}
+
+	var document struct {
+		OpenAPI string                     `json:"openapi"`
+		Paths   map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &document); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(document.OpenAPI, "3.") {
+		t.Errorf("openapi = %q, want 3.x", document.OpenAPI)
+	}
+	for _, operation := range apiOperations {
+		if _, ok := document.Paths[operation.Path]; !ok {
+			t.Errorf("path %s missing in the document", operation.Path)
+		}
+	}
+}
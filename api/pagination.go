@@ -0,0 +1,51 @@
+package api
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+)
+
+// parsePage reads the offset and limit query parameters of list requests. A limit of 0 means
+// no limit.
+func parsePage(query url.Values) (int, int, error) {
+	offset, err := parsePageParam(query, "offset")
+	if err != nil {
+		return 0, 0, err
+	}
+	limit, err := parsePageParam(query, "limit")
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return offset, limit, nil
+}
+
+func parsePageParam(query url.Values, name string) (int, error) {
+	if len(query.Get(name)) == 0 {
+		return 0, nil
+	}
+
+	value, err := strconv.Atoi(query.Get(name))
+	if err != nil {
+		return 0, invalidParam(name, err)
+	}
+	if value < 0 {
+		return 0, invalidParam(name, errors.New("must not be negative"))
+	}
+
+	return value, nil
+}
+
+// page returns the bounds of the page within a list of the given length.
+func page(length int, offset int, limit int) (int, int) {
+	if offset > length {
+		offset = length
+	}
+	end := length
+	if limit > 0 && offset+limit < length {
+		end = offset + limit
+	}
+
+	return offset, end
+}
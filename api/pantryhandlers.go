@@ -0,0 +1,362 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/phlashdev/recipe-keeper-api/core"
+)
+
+type pantryItemModelBase struct {
+	Name       string  `json:"name"`
+	Quantity   float64 `json:"quantity"`
+	Unit       string  `json:"unit"`
+	BestBefore string  `json:"bestBefore"`
+}
+
+type pantryItemModel struct {
+	ID string `json:"id"`
+	pantryItemModelBase
+	Expired bool `json:"expired"`
+}
+
+type pantryItemForCreationModel struct {
+	pantryItemModelBase
+}
+
+type pantryItemForUpdateModel struct {
+	pantryItemModelBase
+}
+
+type recipeCoverageModel struct {
+	RecipeID  string            `json:"recipeId"`
+	Title     string            `json:"title"`
+	Coverage  float64           `json:"coverage"`
+	Available []ingredientModel `json:"available"`
+	Missing   []ingredientModel `json:"missing"`
+}
+
+func newPantryItemModel(pantryItem core.PantryItem, now time.Time) pantryItemModel {
+	bestBefore := ""
+	if !pantryItem.BestBefore.IsZero() {
+		bestBefore = pantryItem.BestBefore.Format(dateFormat)
+	}
+
+	return pantryItemModel{
+		ID: pantryItem.ID.Hex(),
+		pantryItemModelBase: pantryItemModelBase{
+			Name:       pantryItem.Name,
+			Quantity:   pantryItem.Quantity,
+			Unit:       pantryItem.Unit,
+			BestBefore: bestBefore,
+		},
+		Expired: pantryItem.IsExpired(now),
+	}
+}
+
+func newRecipeCoverageModel(coverage core.RecipeCoverage) recipeCoverageModel {
+	return recipeCoverageModel{
+		RecipeID:  coverage.Recipe.ID.Hex(),
+		Title:     coverage.Recipe.Title,
+		Coverage:  coverage.Coverage(),
+		Available: newIngredientModels(coverage.Available),
+		Missing:   newIngredientModels(coverage.Missing),
+	}
+}
+
+func newIngredientModels(ingredients []core.Ingredient) []ingredientModel {
+	ingredientModels := make([]ingredientModel, 0, len(ingredients))
+	for _, ingredient := range ingredients {
+		ingredientModels = append(ingredientModels, ingredientModel{
+			Name:     ingredient.Name,
+			Quantity: ingredient.Quantity,
+			Unit:     ingredient.Unit,
+		})
+	}
+
+	return ingredientModels
+}
+
+// applyPantryItemModel copies the model into the pantry item. An empty best-before date clears it.
+func applyPantryItemModel(pantryItem *core.PantryItem, model pantryItemModelBase) error {
+	var bestBefore time.Time
+	if len(model.BestBefore) > 0 {
+		date, err := time.Parse(dateFormat, model.BestBefore)
+		if err != nil {
+			return &core.DateNotValidError{Date: model.BestBefore}
+		}
+		bestBefore = date
+	}
+
+	pantryItem.Name = model.Name
+	pantryItem.Quantity = model.Quantity
+	pantryItem.Unit = model.Unit
+	pantryItem.BestBefore = bestBefore
+	return nil
+}
+
+type GetPantryItemsHandler struct {
+	pantryRepository core.PantryRepository
+}
+
+func NewGetPantryItemsHandler(pantryRepository core.PantryRepository) *GetPantryItemsHandler {
+	return &GetPantryItemsHandler{
+		pantryRepository: pantryRepository,
+	}
+}
+
+func (handler *GetPantryItemsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	pantryItems, err := handler.pantryRepository.GetPantryItems(ctx)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	now := time.Now()
+	var pantryItemModels = make([]pantryItemModel, 0, len(pantryItems))
+	for _, pantryItem := range pantryItems {
+		pantryItemModels = append(pantryItemModels, newPantryItemModel(pantryItem, now))
+	}
+
+	jsonPantryItems, err := json.Marshal(pantryItemModels)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	_, err = w.Write(jsonPantryItems)
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+type GetPantryItemHandler struct {
+	pantryRepository core.PantryRepository
+}
+
+func NewGetPantryItemHandler(pantryRepository core.PantryRepository) *GetPantryItemHandler {
+	return &GetPantryItemHandler{
+		pantryRepository: pantryRepository,
+	}
+}
+
+func (handler *GetPantryItemHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	vars := mux.Vars(r)
+	id := vars["id"]
+	pantryItem, err := handler.pantryRepository.GetPantryItemByID(ctx, id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	jsonPantryItem, err := json.Marshal(newPantryItemModel(pantryItem, time.Now()))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	_, err = w.Write(jsonPantryItem)
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+type AddPantryItemHandler struct {
+	pantryRepository core.PantryRepository
+}
+
+func NewAddPantryItemHandler(pantryRepository core.PantryRepository) *AddPantryItemHandler {
+	return &AddPantryItemHandler{
+		pantryRepository: pantryRepository,
+	}
+}
+
+func (handler *AddPantryItemHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var pantryItemForCreation pantryItemForCreationModel
+	err := json.NewDecoder(r.Body).Decode(&pantryItemForCreation)
+	if err != nil {
+		writeError(w, r, malformedRequest(err))
+		return
+	}
+
+	var pantryItem core.PantryItem
+	err = applyPantryItemModel(&pantryItem, pantryItemForCreation.pantryItemModelBase)
+	if err != nil {
+		writeError(w, r, invalidParam("bestBefore", err))
+		return
+	}
+
+	err = handler.pantryRepository.AddPantryItem(ctx, &pantryItem)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeCreated(w, r, pantryItem.ID.Hex(), newPantryItemModel(pantryItem, time.Now()))
+}
+
+type UpdatePantryItemHandler struct {
+	pantryRepository core.PantryRepository
+}
+
+func NewUpdatePantryItemHandler(pantryRepository core.PantryRepository) *UpdatePantryItemHandler {
+	return &UpdatePantryItemHandler{
+		pantryRepository: pantryRepository,
+	}
+}
+
+func (handler *UpdatePantryItemHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	vars := mux.Vars(r)
+	id := vars["id"]
+	pantryItem, err := handler.pantryRepository.GetPantryItemByID(ctx, id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var pantryItemForUpdate pantryItemForUpdateModel
+	err = json.NewDecoder(r.Body).Decode(&pantryItemForUpdate)
+	if err != nil {
+		writeError(w, r, malformedRequest(err))
+		return
+	}
+
+	err = applyPantryItemModel(&pantryItem, pantryItemForUpdate.pantryItemModelBase)
+	if err != nil {
+		writeError(w, r, invalidParam("bestBefore", err))
+		return
+	}
+
+	err = handler.pantryRepository.UpdatePantryItem(ctx, pantryItem)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type DeletePantryItemHandler struct {
+	pantryRepository core.PantryRepository
+}
+
+func NewDeletePantryItemHandler(pantryRepository core.PantryRepository) *DeletePantryItemHandler {
+	return &DeletePantryItemHandler{
+		pantryRepository: pantryRepository,
+	}
+}
+
+func (handler *DeletePantryItemHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	vars := mux.Vars(r)
+	id := vars["id"]
+	pantryItem, err := handler.pantryRepository.GetPantryItemByID(ctx, id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	err = handler.pantryRepository.DeletePantryItem(ctx, pantryItem)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetCookableRecipesHandler ranks the recipes by how many of their ingredients are in the pantry.
+// The recipes can be narrowed down with the filters of the recipe list and the result limited.
+type GetCookableRecipesHandler struct {
+	pantryRepository core.PantryRepository
+	recipeRepository core.RecipeRepository
+}
+
+func NewGetCookableRecipesHandler(pantryRepository core.PantryRepository, recipeRepository core.RecipeRepository) *GetCookableRecipesHandler {
+	return &GetCookableRecipesHandler{
+		pantryRepository: pantryRepository,
+		recipeRepository: recipeRepository,
+	}
+}
+
+func (handler *GetCookableRecipesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := r.URL.Query()
+	filter := core.RecipeFilter{
+		Title:    query.Get("title"),
+		Category: query.Get("category"),
+		SourceID: query.Get("sourceId"),
+		Tag:      query.Get("tag"),
+	}
+
+	limit := 0
+	if len(query.Get("limit")) > 0 {
+		value, err := strconv.Atoi(query.Get("limit"))
+		if err != nil || value < 0 {
+			writeError(w, r, invalidParam("limit", fmt.Errorf("limit '%s' not valid", query.Get("limit"))))
+			return
+		}
+		limit = value
+	}
+
+	recipes, err := handler.recipeRepository.GetRecipes(ctx, filter)
+	if err != nil {
+		writeError(w, r, recipeFilterError(err))
+		return
+	}
+
+	pantryItems, err := handler.pantryRepository.GetPantryItems(ctx)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	coverages := core.RankRecipesByPantry(recipes, pantryItems, time.Now())
+	if limit > 0 && len(coverages) > limit {
+		coverages = coverages[:limit]
+	}
+
+	coverageModels := make([]recipeCoverageModel, 0, len(coverages))
+	for _, coverage := range coverages {
+		coverageModels = append(coverageModels, newRecipeCoverageModel(coverage))
+	}
+
+	jsonCoverages, err := json.Marshal(coverageModels)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	_, err = w.Write(jsonCoverages)
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,220 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/phlashdev/recipe-keeper-api/core"
+	"github.com/phlashdev/recipe-keeper-api/patch"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const maxPatchSize = 1 << 20
+
+// patchedDocumentError reports a patch that applied cleanly but left a document that is not a
+// valid model, e.g. because it added an unknown member or changed the type of a field.
+type patchedDocumentError struct {
+	err error
+}
+
+func (err *patchedDocumentError) Error() string {
+	return fmt.Sprintf("patched document not valid: %v", err.err)
+}
+
+// readPatch checks the media type of a PATCH request and reads the patch document.
+func readPatch(w http.ResponseWriter, r *http.Request) (string, []byte, bool) {
+	mediaType := requestContentType(r)
+	if mediaType != patch.MediaTypeMergePatch && mediaType != patch.MediaTypeJSONPatch {
+		w.Header().Set("Accept-Patch", patch.MediaTypeMergePatch+", "+patch.MediaTypeJSONPatch)
+		writeProblem(w, r, newProblem(http.StatusUnsupportedMediaType, problemTypeUnsupportedMedia,
+			fmt.Sprintf("media type '%s' is not supported", mediaType)))
+		return "", nil, false
+	}
+
+	document, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPatchSize))
+	if err != nil {
+		log.Print(err)
+		writeProblem(w, r, newProblem(http.StatusRequestEntityTooLarge, problemTypeTooLarge, err.Error()))
+		return "", nil, false
+	}
+
+	return mediaType, document, true
+}
+
+// applyPatch patches the JSON representation of a model and decodes the result into patched.
+func applyPatch(mediaType string, model interface{}, patchDocument []byte, patched interface{}) error {
+	document, err := json.Marshal(model)
+	if err != nil {
+		return err
+	}
+
+	document, err = patch.Apply(mediaType, document, patchDocument)
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(document))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(patched); err != nil {
+		return &patchedDocumentError{err: err}
+	}
+
+	return nil
+}
+
+// checkPatchVersion compares the version read inside the repository with If-Match, the check
+// has to happen there to cover the version the patch is actually applied to.
+func checkPatchVersion(r *http.Request, id primitive.ObjectID, version int) error {
+	header := r.Header.Get("If-Match")
+	if len(header) > 0 && !etagListMatches(header, version) {
+		return &core.VersionConflictError{ID: id.Hex(), Version: version}
+	}
+
+	return nil
+}
+
+type PatchRecipeHandler struct {
+	recipeRepository   core.RecipeRepository
+	revisionRepository core.RevisionRepository
+}
+
+func NewPatchRecipeHandler(recipeRepository core.RecipeRepository, revisionRepository core.RevisionRepository) *PatchRecipeHandler {
+	return &PatchRecipeHandler{
+		recipeRepository:   recipeRepository,
+		revisionRepository: revisionRepository,
+	}
+}
+
+func (handler *PatchRecipeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	mediaType, patchDocument, ok := readPatch(w, r)
+	if !ok {
+		return
+	}
+
+	vars := mux.Vars(r)
+	id := vars["id"]
+	var previous core.Recipe
+	recipe, err := handler.recipeRepository.PatchRecipe(ctx, id, func(recipe *core.Recipe) error {
+		if err := checkPatchVersion(r, recipe.ID, recipe.Version); err != nil {
+			return err
+		}
+		previous = *recipe
+
+		var patched recipeModelBase
+		if err := applyPatch(mediaType, newRecipeModel(*recipe).recipeModelBase, patchDocument, &patched); err != nil {
+			return err
+		}
+
+		// unlike PUT an empty source id removes the source
+		var sourceID primitive.ObjectID
+		if len(patched.SourceID) > 0 {
+			var err error
+			sourceID, err = primitive.ObjectIDFromHex(patched.SourceID)
+			if err != nil {
+				return invalidParam("sourceId", &core.SourceIDNotValidError{ID: patched.SourceID})
+			}
+		}
+
+		applyRecipeModel(recipe, patched, sourceID)
+		return nil
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	err = core.RecordRevision(ctx, handler.revisionRepository, &previous, recipe, requestAuthor(r))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	jsonRecipe, err := json.Marshal(newRecipeModel(recipe))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", formatETag(recipe.Version, ""))
+	_, err = w.Write(jsonRecipe)
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+type PatchSourceHandler struct {
+	sourceRepository core.SourceRepository
+}
+
+func NewPatchSourceHandler(sourceRepository core.SourceRepository) *PatchSourceHandler {
+	return &PatchSourceHandler{
+		sourceRepository: sourceRepository,
+	}
+}
+
+func (handler *PatchSourceHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	mediaType, patchDocument, ok := readPatch(w, r)
+	if !ok {
+		return
+	}
+
+	vars := mux.Vars(r)
+	id := vars["id"]
+	source, err := handler.sourceRepository.PatchSource(ctx, id, func(source *core.Source) error {
+		if err := checkPatchVersion(r, source.ID, source.Version); err != nil {
+			return err
+		}
+
+		model := sourceModelBase{
+			Title:      source.Title,
+			SourceType: source.Type,
+		}
+		var patched sourceModelBase
+		if err := applyPatch(mediaType, model, patchDocument, &patched); err != nil {
+			return err
+		}
+
+		source.Title = patched.Title
+		source.Type = patched.SourceType
+		return nil
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	jsonSource, err := json.Marshal(sourceModel{
+		ID: source.ID.Hex(),
+		sourceModelBase: sourceModelBase{
+			Title:      source.Title,
+			SourceType: source.Type,
+		},
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", formatETag(source.Version, ""))
+	_, err = w.Write(jsonSource)
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
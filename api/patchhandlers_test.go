@@ -0,0 +1,63 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/phlashdev/recipe-keeper-api/patch"
+)
+
+func TestReadPatch(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPatch, "/api/recipes/1", strings.NewReader(`{"title":"Bread"}`))
+	r.Header.Set("Content-Type", patch.MediaTypeMergePatch)
+	w := httptest.NewRecorder()
+
+	mediaType, document, ok := readPatch(w, r)
+	if !ok || mediaType != patch.MediaTypeMergePatch || string(document) != `{"title":"Bread"}` {
+		t.Errorf("readPatch = %q, %q, %v", mediaType, document, ok)
+	}
+}
+
+func TestReadPatchTooLarge(t *testing.T) {
+	body := `{"title":"` + strings.Repeat("a", maxPatchSize) + `"}`
+	r := httptest.NewRequest(http.MethodPatch, "/api/recipes/1", strings.NewReader(body))
+	r.Header.Set("Content-Type", patch.MediaTypeMergePatch)
+	w := httptest.NewRecorder()
+
+	if _, _, ok := readPatch(w, r); ok {
+		t.Fatal("readPatch accepted a patch over the limit")
+	}
+	assertProblem(t, w, http.StatusRequestEntityTooLarge, problemTypeTooLarge)
+}
+
+func TestReadPatchUnsupportedMediaType(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPatch, "/api/recipes/1", strings.NewReader(`{}`))
+	r.Header.Set("Content-Type", mediaTypeJSON)
+	w := httptest.NewRecorder()
+
+	if _, _, ok := readPatch(w, r); ok {
+		t.Fatal("readPatch accepted application/json")
+	}
+	assertProblem(t, w, http.StatusUnsupportedMediaType, problemTypeUnsupportedMedia)
+	if accept := w.Header().Get("Accept-Patch"); !strings.Contains(accept, patch.MediaTypeJSONPatch) {
+		t.Errorf("Accept-Patch = %q", accept)
+	}
+}
+
+func assertProblem(t *testing.T, w *httptest.ResponseRecorder, status int, problemType string) {
+	t.Helper()
+
+	if w.Code != status {
+		t.Errorf("status = %d, want %d", w.Code, status)
+	}
+	var body problemModel
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("body %q is no problem: %v", w.Body.String(), err)
+	}
+	if body.Type != problemType {
+		t.Errorf("problem type = %q, want %q", body.Type, problemType)
+	}
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/phlashdev/recipe-keeper-api/core"
+	"github.com/phlashdev/recipe-keeper-api/pdf"
+)
+
+const mediaTypePDF = "application/pdf"
+
+type GetRecipePDFHandler struct {
+	recipeRepository core.RecipeRepository
+	sourceRepository core.SourceRepository
+}
+
+func NewGetRecipePDFHandler(recipeRepository core.RecipeRepository, sourceRepository core.SourceRepository) *GetRecipePDFHandler {
+	return &GetRecipePDFHandler{
+		recipeRepository: recipeRepository,
+		sourceRepository: sourceRepository,
+	}
+}
+
+func (handler *GetRecipePDFHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	vars := mux.Vars(r)
+	id := vars["id"]
+	recipe, err := handler.recipeRepository.GetRecipeByID(ctx, id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var source *core.Source
+	if !recipe.Source.IsZero() {
+		recipeSource, err := handler.sourceRepository.GetSourceByID(ctx, recipe.Source.Hex())
+		var e *core.SourceNotFoundError
+		if err != nil && !errors.As(err, &e) {
+			writeError(w, r, err)
+			return
+		}
+		if err == nil {
+			source = &recipeSource
+		}
+	}
+
+	var buffer bytes.Buffer
+	err = pdf.WriteRecipe(&buffer, recipe, source)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writePDF(w, recipe.Title, buffer.Bytes())
+}
+
+type GetCookbookPDFHandler struct {
+	recipeRepository core.RecipeRepository
+	sourceRepository core.SourceRepository
+}
+
+func NewGetCookbookPDFHandler(recipeRepository core.RecipeRepository, sourceRepository core.SourceRepository) *GetCookbookPDFHandler {
+	return &GetCookbookPDFHandler{
+		recipeRepository: recipeRepository,
+		sourceRepository: sourceRepository,
+	}
+}
+
+func (handler *GetCookbookPDFHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	query := r.URL.Query()
+	filter := core.RecipeFilter{
+		Title:    query.Get("title"),
+		Category: query.Get("category"),
+		SourceID: query.Get("sourceId"),
+		Tag:      query.Get("tag"),
+	}
+
+	recipes, err := handler.recipeRepository.GetRecipes(ctx, filter)
+	if err != nil {
+		writeError(w, r, recipeFilterError(err))
+		return
+	}
+
+	sources, err := handler.sourceRepository.GetSources(ctx)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	title := "Recipes"
+	switch {
+	case len(filter.Category) > 0:
+		title = filter.Category
+	case len(filter.Tag) > 0:
+		title = filter.Tag
+	}
+
+	var buffer bytes.Buffer
+	err = pdf.WriteCookbook(&buffer, title, recipes, sources)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writePDF(w, title, buffer.Bytes())
+}
+
+func writePDF(w http.ResponseWriter, title string, data []byte) {
+	w.Header().Set("Content-Type", mediaTypePDF)
+	w.Header().Set("Content-Disposition", attachmentDisposition(title, ".pdf"))
+	_, err := w.Write(data)
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,220 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/phlashdev/recipe-keeper-api/auth"
+	"github.com/phlashdev/recipe-keeper-api/backup"
+	"github.com/phlashdev/recipe-keeper-api/cooking"
+	"github.com/phlashdev/recipe-keeper-api/core"
+	"github.com/phlashdev/recipe-keeper-api/patch"
+)
+
+const mediaTypeProblem = "application/problem+json"
+
+// Problem types, relative URIs identifying the kind of error independent of the status code.
+const (
+	problemTypeInvalidRequest   = "/problems/invalid-request"
+	problemTypeNotFound         = "/problems/not-found"
+	problemTypeConflict         = "/problems/conflict"
+	problemTypeVersionConflict  = "/problems/version-conflict"
+	problemTypeUnprocessable    = "/problems/unprocessable-entity"
+	problemTypeUnsupportedMedia = "/problems/unsupported-media-type"
+	problemTypeTooLarge         = "/problems/request-too-large"
+	problemTypeInternal         = "/problems/internal-error"
+	problemTypeBatchAborted     = "/problems/batch-aborted"
+	problemTypeUnauthorized     = "/problems/unauthorized"
+	problemTypeForbidden        = "/problems/forbidden"
+)
+
+type invalidParamModel struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// problemModel is a problem details object as defined in RFC 7807.
+type problemModel struct {
+	Type          string              `json:"type"`
+	Title         string              `json:"title"`
+	Status        int                 `json:"status"`
+	Detail        string              `json:"detail,omitempty"`
+	Instance      string              `json:"instance,omitempty"`
+	InvalidParams []invalidParamModel `json:"invalid-params,omitempty"`
+}
+
+// requestError is an error the client has to fix in its request. Param names the body member or
+// query parameter at fault, it is empty if the request as a whole is malformed.
+type requestError struct {
+	Param string
+	Err   error
+}
+
+func (err *requestError) Error() string {
+	if len(err.Param) == 0 {
+		return err.Err.Error()
+	}
+
+	return err.Param + ": " + err.Err.Error()
+}
+
+func (err *requestError) Unwrap() error {
+	return err.Err
+}
+
+// malformedRequest wraps errors of requests that cannot be read at all, like invalid JSON.
+func malformedRequest(err error) error {
+	return &requestError{Err: err}
+}
+
+// invalidParam wraps errors caused by a single member of the body or query parameter. This also
+// turns not found and id errors into a bad request, the referenced item is not the resource
+// of the request.
+func invalidParam(param string, err error) error {
+	return &requestError{Param: param, Err: err}
+}
+
+// writeError maps errors to problem details. Errors of package core describe the resource of the
+// request, errors concerning the request content are wrapped with invalidParam or malformedRequest.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	log.Print(err)
+	writeProblem(w, r, newErrorProblem(r, err))
+}
+
+func newErrorProblem(r *http.Request, err error) problemModel {
+	var requestErr *requestError
+	if errors.As(err, &requestErr) {
+		problem := newProblem(http.StatusBadRequest, problemTypeInvalidRequest, err.Error())
+		if len(requestErr.Param) > 0 {
+			problem.InvalidParams = []invalidParamModel{{Name: requestErr.Param, Reason: requestErr.Err.Error()}}
+		}
+		return problem
+	}
+
+	var validationErr *core.ValidationError
+	if errors.As(err, &validationErr) {
+		problem := newProblem(http.StatusBadRequest, problemTypeInvalidRequest, "the request contains invalid fields")
+		for _, fieldErr := range validationErr.Errors {
+			problem.InvalidParams = append(problem.InvalidParams, invalidParamModel{Name: fieldErr.Field, Reason: fieldErr.Message})
+		}
+		return problem
+	}
+
+	if status, ok := versionConflictStatus(r, err); ok {
+		return newProblem(status, problemTypeVersionConflict, err.Error())
+	}
+
+	var sourceTypeErr *core.SourceTypeNotValidError
+	var mealSlotErr *core.MealSlotNotValidError
+	var dateErr *core.DateNotValidError
+	var shoppingListCompletedErr *core.ShoppingListCompletedError
+	var patchSyntaxErr *patch.SyntaxError
+	var patchTestErr *patch.TestFailedError
+	var patchErr *patch.Error
+	var patchedDocumentErr *patchedDocumentError
+	var archiveErr *backup.ArchiveNotValidError
+	var archiveVersionErr *backup.VersionNotSupportedError
+	var batchOperationErr *core.BatchOperationNotValidError
+	var batchAbortedErr *core.BatchAbortedError
+	var invalidCredentialsErr *auth.InvalidCredentialsError
+	var notAuthenticatedErr *auth.NotAuthenticatedError
+	var forbiddenErr *auth.ForbiddenError
+	var usernameExistsErr *core.UsernameExistsError
+	switch {
+	case errors.As(err, &invalidCredentialsErr), errors.As(err, &notAuthenticatedErr):
+		return newProblem(http.StatusUnauthorized, problemTypeUnauthorized, err.Error())
+	case errors.As(err, &forbiddenErr):
+		return newProblem(http.StatusForbidden, problemTypeForbidden, err.Error())
+	case errors.As(err, &usernameExistsErr):
+		return newErrorProblem(r, invalidParam("username", err))
+	case errors.As(err, &sourceTypeErr):
+		return newErrorProblem(r, invalidParam("type", err))
+	case errors.As(err, &mealSlotErr):
+		return newErrorProblem(r, invalidParam("slot", err))
+	case errors.As(err, &dateErr):
+		return newErrorProblem(r, invalidParam("date", err))
+	case errors.As(err, &batchOperationErr):
+		return newErrorProblem(r, invalidParam("op", err))
+	case errors.As(err, &batchAbortedErr):
+		return newProblem(http.StatusFailedDependency, problemTypeBatchAborted, err.Error())
+	case errors.As(err, &patchSyntaxErr), errors.As(err, &archiveErr), errors.As(err, &archiveVersionErr):
+		return newErrorProblem(r, malformedRequest(err))
+	case isNotFoundError(err):
+		return newProblem(http.StatusNotFound, problemTypeNotFound, err.Error())
+	case errors.As(err, &shoppingListCompletedErr), errors.As(err, &patchTestErr):
+		return newProblem(http.StatusConflict, problemTypeConflict, err.Error())
+	case errors.As(err, &patchErr), errors.As(err, &patchedDocumentErr):
+		return newProblem(http.StatusUnprocessableEntity, problemTypeUnprocessable, err.Error())
+	default:
+		// internal errors are only logged, they might reveal details of the database
+		return newProblem(http.StatusInternalServerError, problemTypeInternal, "")
+	}
+}
+
+func isNotFoundError(err error) bool {
+	var recipeNotFoundErr *core.RecipeNotFoundError
+	var recipeIDErr *core.RecipeIDNotValidError
+	var sourceNotFoundErr *core.SourceNotFoundError
+	var sourceIDErr *core.SourceIDNotValidError
+	var mealPlanNotFoundErr *core.MealPlanNotFoundError
+	var mealPlanIDErr *core.MealPlanIDNotValidError
+	var shoppingListNotFoundErr *core.ShoppingListNotFoundError
+	var shoppingListIDErr *core.ShoppingListIDNotValidError
+	var shoppingListItemNotFoundErr *core.ShoppingListItemNotFoundError
+	var pantryItemNotFoundErr *core.PantryItemNotFoundError
+	var pantryItemIDErr *core.PantryItemIDNotValidError
+	var revisionNotFoundErr *core.RevisionNotFoundError
+	var stepNotFoundErr *cooking.StepNotFoundError
+	var timerNotFoundErr *cooking.TimerNotFoundError
+
+	return errors.As(err, &recipeNotFoundErr) || errors.As(err, &recipeIDErr) ||
+		errors.As(err, &sourceNotFoundErr) || errors.As(err, &sourceIDErr) ||
+		errors.As(err, &mealPlanNotFoundErr) || errors.As(err, &mealPlanIDErr) ||
+		errors.As(err, &shoppingListNotFoundErr) || errors.As(err, &shoppingListIDErr) || errors.As(err, &shoppingListItemNotFoundErr) ||
+		errors.As(err, &pantryItemNotFoundErr) || errors.As(err, &pantryItemIDErr) ||
+		errors.As(err, &revisionNotFoundErr) ||
+		errors.As(err, &stepNotFoundErr) || errors.As(err, &timerNotFoundErr)
+}
+
+// recipeFilterError reports an invalid source id of the recipe filter as bad query parameter
+// instead of a missing source.
+func recipeFilterError(err error) error {
+	var sourceIDErr *core.SourceIDNotValidError
+	if errors.As(err, &sourceIDErr) {
+		return invalidParam("sourceId", err)
+	}
+
+	return err
+}
+
+func newProblem(status int, problemType string, detail string) problemModel {
+	return problemModel{
+		Type:   problemType,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+func writeProblem(w http.ResponseWriter, r *http.Request, problem problemModel) {
+	problem.Instance = r.URL.Path
+
+	jsonProblem, err := json.Marshal(problem)
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(problem.Status)
+		return
+	}
+
+	w.Header().Set("Content-Type", mediaTypeProblem)
+	if problem.Status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+	}
+	w.WriteHeader(problem.Status)
+	_, err = w.Write(jsonProblem)
+	if err != nil {
+		log.Print(err)
+	}
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -10,16 +11,40 @@ import (
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/phlashdev/recipe-keeper-api/cooklang"
 	"github.com/phlashdev/recipe-keeper-api/core"
+	"github.com/phlashdev/recipe-keeper-api/markdown"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type recipeModelBase struct {
-	Title            string   `json:"title"`
-	SourceID         string   `json:"sourceId"`
-	SourceAnnotation string   `json:"sourceAnnotation"`
-	Category         string   `json:"category"`
-	Allergens        []string `json:"allergens"`
+	Title            string            `json:"title"`
+	SourceID         string            `json:"sourceId"`
+	SourceAnnotation string            `json:"sourceAnnotation"`
+	Category         string            `json:"category"`
+	Allergens        []string          `json:"allergens"`
+	Tags             []string          `json:"tags"`
+	Servings         int               `json:"servings"`
+	Ingredients      []ingredientModel `json:"ingredients"`
+	Cookware         []string          `json:"cookware"`
+	Steps            []stepModel       `json:"steps"`
+}
+
+type ingredientModel struct {
+	Name     string  `json:"name"`
+	Quantity float64 `json:"quantity"`
+	Unit     string  `json:"unit"`
+}
+
+type stepModel struct {
+	Text        string       `json:"text"`
+	Ingredients []string     `json:"ingredients"`
+	Timers      []timerModel `json:"timers"`
+}
+
+type timerModel struct {
+	Name            string `json:"name"`
+	DurationSeconds int64  `json:"durationSeconds"`
 }
 
 type recipeModel struct {
@@ -35,13 +60,116 @@ type recipeForUpdateModel struct {
 	recipeModelBase
 }
 
+func newRecipeModel(recipe core.Recipe) recipeModel {
+	sourceID := ""
+	if !recipe.Source.IsZero() {
+		sourceID = recipe.Source.Hex()
+	}
+
+	var ingredientModels []ingredientModel
+	for _, ingredient := range recipe.Ingredients {
+		ingredientModels = append(ingredientModels, ingredientModel{
+			Name:     ingredient.Name,
+			Quantity: ingredient.Quantity,
+			Unit:     ingredient.Unit,
+		})
+	}
+
+	return recipeModel{
+		ID: recipe.ID.Hex(),
+		recipeModelBase: recipeModelBase{
+			Title:            recipe.Title,
+			SourceID:         sourceID,
+			SourceAnnotation: recipe.SourceAnnotation,
+			Category:         recipe.Category,
+			Allergens:        recipe.Allergens,
+			Tags:             recipe.Tags,
+			Servings:         recipe.Servings,
+			Ingredients:      ingredientModels,
+			Cookware:         recipe.Cookware,
+			Steps:            newStepModels(recipe.Steps),
+		},
+	}
+}
+
+func newStepModels(steps []core.Step) []stepModel {
+	var stepModels []stepModel
+	for _, step := range steps {
+		var timerModels []timerModel
+		for _, timer := range step.Timers {
+			timerModels = append(timerModels, timerModel{
+				Name:            timer.Name,
+				DurationSeconds: int64(timer.Duration / time.Second),
+			})
+		}
+
+		stepModels = append(stepModels, stepModel{
+			Text:        step.Text,
+			Ingredients: step.Ingredients,
+			Timers:      timerModels,
+		})
+	}
+
+	return stepModels
+}
+
+// applyRecipeModel copies all fields of the model into the recipe, the source id is parsed by the caller.
+func applyRecipeModel(recipe *core.Recipe, model recipeModelBase, sourceID primitive.ObjectID) {
+	recipe.Title = model.Title
+	recipe.Source = sourceID
+	recipe.SourceAnnotation = model.SourceAnnotation
+	recipe.Category = model.Category
+	recipe.Allergens = model.Allergens
+	recipe.Tags = model.Tags
+	recipe.Servings = model.Servings
+	recipe.Ingredients = toIngredients(model.Ingredients)
+	recipe.Cookware = model.Cookware
+	recipe.Steps = toSteps(model.Steps)
+}
+
+func toIngredients(ingredientModels []ingredientModel) []core.Ingredient {
+	var ingredients []core.Ingredient
+	for _, ingredientModel := range ingredientModels {
+		ingredients = append(ingredients, core.Ingredient{
+			Name:     ingredientModel.Name,
+			Quantity: ingredientModel.Quantity,
+			Unit:     ingredientModel.Unit,
+		})
+	}
+
+	return ingredients
+}
+
+func toSteps(stepModels []stepModel) []core.Step {
+	var steps []core.Step
+	for _, stepModel := range stepModels {
+		var timers []core.Timer
+		for _, timerModel := range stepModel.Timers {
+			timers = append(timers, core.Timer{
+				Name:     timerModel.Name,
+				Duration: time.Duration(timerModel.DurationSeconds) * time.Second,
+			})
+		}
+
+		steps = append(steps, core.Step{
+			Text:        stepModel.Text,
+			Ingredients: stepModel.Ingredients,
+			Timers:      timers,
+		})
+	}
+
+	return steps
+}
+
 type GetRecipesHandler struct {
 	recipeRepository core.RecipeRepository
+	sourceRepository core.SourceRepository
 }
 
-func NewGetRecipesHandler(recipeRepository core.RecipeRepository) *GetRecipesHandler {
+func NewGetRecipesHandler(recipeRepository core.RecipeRepository, sourceRepository core.SourceRepository) *GetRecipesHandler {
 	return &GetRecipesHandler{
 		recipeRepository: recipeRepository,
+		sourceRepository: sourceRepository,
 	}
 }
 
@@ -49,36 +177,42 @@ func (handler *GetRecipesHandler) ServeHTTP(w http.ResponseWriter, r *http.Reque
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	recipes, err := handler.recipeRepository.GetRecipes(ctx)
+	query := r.URL.Query()
+	offset, limit, err := parsePage(query)
 	if err != nil {
-		fmt.Println(err)
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, err)
+		return
+	}
+
+	filter := core.RecipeFilter{
+		Title:    query.Get("title"),
+		Category: query.Get("category"),
+		SourceID: query.Get("sourceId"),
+		Tag:      query.Get("tag"),
+		Offset:   offset,
+		Limit:    limit,
+	}
+
+	recipes, err := handler.recipeRepository.GetRecipes(ctx, filter)
+	if err != nil {
+		writeError(w, r, recipeFilterError(err))
+		return
+	}
+
+	w.Header().Add("Vary", "Accept")
+	if negotiateContentType(r, mediaTypeJSON, mediaTypeCSV) == mediaTypeCSV {
+		handler.serveCSV(ctx, w, r, recipes)
 		return
 	}
 
 	var recipeModels = make([]recipeModel, 0, len(recipes))
 	for _, recipe := range recipes {
-		sourceID := ""
-		if !recipe.Source.IsZero() {
-			sourceID = recipe.Source.Hex()
-		}
-
-		recipeModels = append(recipeModels, recipeModel{
-			ID: recipe.ID.Hex(),
-			recipeModelBase: recipeModelBase{
-				Title:            recipe.Title,
-				SourceID:         sourceID,
-				SourceAnnotation: recipe.SourceAnnotation,
-				Category:         recipe.Category,
-				Allergens:        recipe.Allergens,
-			},
-		})
+		recipeModels = append(recipeModels, newRecipeModel(recipe))
 	}
 
 	jsonRecipes, err := json.Marshal(recipeModels)
 	if err != nil {
-		fmt.Println(err)
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
@@ -90,13 +224,39 @@ func (handler *GetRecipesHandler) ServeHTTP(w http.ResponseWriter, r *http.Reque
 	}
 }
 
+func (handler *GetRecipesHandler) serveCSV(ctx context.Context, w http.ResponseWriter, r *http.Request, recipes []core.Recipe) {
+	sources, err := handler.sourceRepository.GetSources(ctx)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var buffer bytes.Buffer
+	err = writeRecipesCSV(&buffer, recipes, sources)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", mediaTypeCSV+"; charset=utf-8")
+	w.Header().Set("Content-Disposition", attachmentDisposition("recipes", ".csv"))
+	_, err = w.Write(buffer.Bytes())
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
 type GetRecipeHandler struct {
 	recipeRepository core.RecipeRepository
+	sourceRepository core.SourceRepository
 }
 
-func NewGetRecipeHandler(recipeRepository core.RecipeRepository) *GetRecipeHandler {
+func NewGetRecipeHandler(recipeRepository core.RecipeRepository, sourceRepository core.SourceRepository) *GetRecipeHandler {
 	return &GetRecipeHandler{
 		recipeRepository: recipeRepository,
+		sourceRepository: sourceRepository,
 	}
 }
 
@@ -107,63 +267,133 @@ func (handler *GetRecipeHandler) ServeHTTP(w http.ResponseWriter, r *http.Reques
 	vars := mux.Vars(r)
 	id := vars["id"]
 	recipe, err := handler.recipeRepository.GetRecipeByID(ctx, id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Add("Vary", "Accept")
+	mediaType := negotiateContentType(r, mediaTypeJSON, mediaTypeJSONLD, mediaTypeCooklang, mediaTypeMarkdown)
+	if !checkIfNoneMatch(w, r, recipe.Version, etagVariants[mediaType]) {
+		return
+	}
+
+	switch mediaType {
+	case mediaTypeJSONLD:
+		handler.serveJSONLD(ctx, w, r, recipe)
+		return
+	case mediaTypeCooklang:
+		handler.serveCooklang(ctx, w, r, recipe)
+		return
+	case mediaTypeMarkdown:
+		handler.serveMarkdown(w, r, recipe)
+		return
+	}
+
+	jsonRecipe, err := json.Marshal(newRecipeModel(recipe))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	_, err = w.Write(jsonRecipe)
 	if err != nil {
 		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
 
-		var recipeNotFoundErr *core.RecipeNotFoundError
-		if errors.As(err, &recipeNotFoundErr) {
-			w.WriteHeader(http.StatusNotFound)
-			return
-		}
+func (handler *GetRecipeHandler) serveJSONLD(ctx context.Context, w http.ResponseWriter, r *http.Request, recipe core.Recipe) {
+	source, err := handler.getSource(ctx, recipe)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
 
-		var idNotValidErr *core.RecipeIDNotValidError
-		if errors.As(err, &idNotValidErr) {
-			w.WriteHeader(http.StatusNotFound)
-			return
-		}
+	jsonRecipe, err := json.Marshal(newRecipeJSONLDModel(recipe, source))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
 
+	w.Header().Set("Content-Type", mediaTypeJSONLD)
+	_, err = w.Write(jsonRecipe)
+	if err != nil {
+		log.Print(err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
+}
 
-	sourceID := ""
-	if !recipe.Source.IsZero() {
-		sourceID = recipe.Source.Hex()
+func (handler *GetRecipeHandler) serveCooklang(ctx context.Context, w http.ResponseWriter, r *http.Request, recipe core.Recipe) {
+	source, err := handler.getSource(ctx, recipe)
+	if err != nil {
+		writeError(w, r, err)
+		return
 	}
 
-	recipeModel := recipeModel{
-		ID: recipe.ID.Hex(),
-		recipeModelBase: recipeModelBase{
-			Title:            recipe.Title,
-			SourceID:         sourceID,
-			SourceAnnotation: recipe.SourceAnnotation,
-			Category:         recipe.Category,
-			Allergens:        recipe.Allergens,
-		},
+	var buffer bytes.Buffer
+	err = cooklang.Format(&buffer, recipe, source)
+	if err != nil {
+		writeError(w, r, err)
+		return
 	}
 
-	jsonRecipe, err := json.Marshal(recipeModel)
+	w.Header().Set("Content-Type", mediaTypeCooklang+"; charset=utf-8")
+	w.Header().Set("Content-Disposition", attachmentDisposition(recipe.Title, ".cook"))
+	_, err = w.Write(buffer.Bytes())
 	if err != nil {
-		fmt.Println(err)
+		log.Print(err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
+}
 
-	_, err = w.Write(jsonRecipe)
+func (handler *GetRecipeHandler) serveMarkdown(w http.ResponseWriter, r *http.Request, recipe core.Recipe) {
+	var buffer bytes.Buffer
+	err := markdown.Format(&buffer, recipe)
 	if err != nil {
-		fmt.Println(err)
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", mediaTypeMarkdown+"; charset=utf-8")
+	_, err = w.Write(buffer.Bytes())
+	if err != nil {
+		log.Print(err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 }
 
+// getSource returns the source the recipe refers to or nil if it has none or it does not exist anymore.
+func (handler *GetRecipeHandler) getSource(ctx context.Context, recipe core.Recipe) (*core.Source, error) {
+	if recipe.Source.IsZero() {
+		return nil, nil
+	}
+
+	source, err := handler.sourceRepository.GetSourceByID(ctx, recipe.Source.Hex())
+	if err != nil {
+		var e *core.SourceNotFoundError
+		if errors.As(err, &e) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &source, nil
+}
+
 type AddRecipeHandler struct {
-	recipeRepository core.RecipeRepository
+	recipeRepository   core.RecipeRepository
+	revisionRepository core.RevisionRepository
 }
 
-func NewAddRecipeHandler(recipeRepository core.RecipeRepository) *AddRecipeHandler {
+func NewAddRecipeHandler(recipeRepository core.RecipeRepository, revisionRepository core.RevisionRepository) *AddRecipeHandler {
 	return &AddRecipeHandler{
-		recipeRepository: recipeRepository,
+		recipeRepository:   recipeRepository,
+		revisionRepository: revisionRepository,
 	}
 }
 
@@ -172,17 +402,20 @@ func (handler *AddRecipeHandler) ServeHTTP(w http.ResponseWriter, r *http.Reques
 	defer cancel()
 
 	var recipeForCreation recipeForCreationModel
-	err := json.NewDecoder(r.Body).Decode(&recipeForCreation)
+	var err error
+	if requestContentType(r) == mediaTypeMarkdown {
+		recipeForCreation, err = decodeMarkdownRecipe(r)
+	} else {
+		err = json.NewDecoder(r.Body).Decode(&recipeForCreation)
+	}
 	if err != nil {
-		fmt.Println(err)
-		w.WriteHeader(http.StatusBadRequest)
+		writeError(w, r, malformedRequest(err))
 		return
 	}
 
 	sourceID, err := primitive.ObjectIDFromHex(recipeForCreation.SourceID)
 	if err != nil {
-		log.Print(err)
-		w.WriteHeader(http.StatusBadRequest)
+		writeError(w, r, invalidParam("sourceId", err))
 		return
 	}
 
@@ -192,25 +425,51 @@ func (handler *AddRecipeHandler) ServeHTTP(w http.ResponseWriter, r *http.Reques
 		SourceAnnotation: recipeForCreation.SourceAnnotation,
 		Category:         recipeForCreation.Category,
 		Allergens:        recipeForCreation.Allergens,
+		Tags:             recipeForCreation.Tags,
+		Servings:         recipeForCreation.Servings,
+		Ingredients:      toIngredients(recipeForCreation.Ingredients),
+		Cookware:         recipeForCreation.Cookware,
+		Steps:            toSteps(recipeForCreation.Steps),
 	}
 
 	err = handler.recipeRepository.AddRecipe(ctx, &recipe)
 	if err != nil {
-		log.Print(err)
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, err)
+		return
+	}
+
+	err = core.RecordRevision(ctx, handler.revisionRepository, nil, recipe, requestAuthor(r))
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	w.WriteHeader(http.StatusCreated)
+	w.Header().Set("ETag", formatETag(recipe.Version, ""))
+	writeCreated(w, r, recipe.ID.Hex(), newRecipeModel(recipe))
+}
+
+// decodeMarkdownRecipe converts a Markdown recipe to the model used for JSON, so both are validated the same way.
+func decodeMarkdownRecipe(r *http.Request) (recipeForCreationModel, error) {
+	document, err := markdown.Parse(r.Body)
+	if err != nil {
+		return recipeForCreationModel{}, err
+	}
+
+	recipeModelBase := newRecipeModel(document.Recipe).recipeModelBase
+	recipeModelBase.SourceID = document.SourceID
+
+	return recipeForCreationModel{recipeModelBase: recipeModelBase}, nil
 }
 
 type UpdateRecipeHandler struct {
-	recipeRepository core.RecipeRepository
+	recipeRepository   core.RecipeRepository
+	revisionRepository core.RevisionRepository
 }
 
-func NewUpdateRecipeHandler(recipeRepository core.RecipeRepository) *UpdateRecipeHandler {
+func NewUpdateRecipeHandler(recipeRepository core.RecipeRepository, revisionRepository core.RevisionRepository) *UpdateRecipeHandler {
 	return &UpdateRecipeHandler{
-		recipeRepository: recipeRepository,
+		recipeRepository:   recipeRepository,
+		revisionRepository: revisionRepository,
 	}
 }
 
@@ -222,45 +481,44 @@ func (handler *UpdateRecipeHandler) ServeHTTP(w http.ResponseWriter, r *http.Req
 	id := vars["id"]
 	recipe, err := handler.recipeRepository.GetRecipeByID(ctx, id)
 	if err != nil {
-		fmt.Println(err)
+		writeError(w, r, err)
+		return
+	}
 
-		var e *core.RecipeNotFoundError
-		if errors.As(err, &e) {
-			w.WriteHeader(http.StatusNotFound)
-		} else {
-			w.WriteHeader(http.StatusInternalServerError)
-		}
+	if !checkIfMatch(w, r, recipe.Version) {
 		return
 	}
 
 	var recipeForUpdate recipeForUpdateModel
 	err = json.NewDecoder(r.Body).Decode(&recipeForUpdate)
 	if err != nil {
-		fmt.Println(err)
-		w.WriteHeader(http.StatusBadRequest)
+		writeError(w, r, malformedRequest(err))
 		return
 	}
 
 	sourceID, err := primitive.ObjectIDFromHex(recipeForUpdate.SourceID)
 	if err != nil {
-		log.Print(err)
-		w.WriteHeader(http.StatusBadRequest)
+		writeError(w, r, invalidParam("sourceId", err))
 		return
 	}
 
-	recipe.Title = recipeForUpdate.Title
-	recipe.Source = sourceID
-	recipe.SourceAnnotation = recipeForUpdate.SourceAnnotation
-	recipe.Category = recipeForUpdate.Category
-	recipe.Allergens = recipeForUpdate.Allergens
+	previous := recipe
+	applyRecipeModel(&recipe, recipeForUpdate.recipeModelBase, sourceID)
 
 	err = handler.recipeRepository.UpdateRecipe(ctx, recipe)
 	if err != nil {
-		log.Print(err)
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
+	err = core.RecordRevision(ctx, handler.revisionRepository, &previous, recipe, requestAuthor(r))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// the repository increased the stored version, the client sends it with its next update
+	w.Header().Set("ETag", formatETag(recipe.Version+1, ""))
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -282,21 +540,17 @@ func (handler *DeleteRecipeHandler) ServeHTTP(w http.ResponseWriter, r *http.Req
 	id := vars["id"]
 	recipe, err := handler.recipeRepository.GetRecipeByID(ctx, id)
 	if err != nil {
-		fmt.Println(err)
+		writeError(w, r, err)
+		return
+	}
 
-		var e *core.RecipeNotFoundError
-		if errors.As(err, &e) {
-			w.WriteHeader(http.StatusNotFound)
-		} else {
-			w.WriteHeader(http.StatusInternalServerError)
-		}
+	if !checkIfMatch(w, r, recipe.Version) {
 		return
 	}
 
 	err = handler.recipeRepository.DeleteRecipe(ctx, recipe)
 	if err != nil {
-		log.Print(err)
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
@@ -0,0 +1,303 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/phlashdev/recipe-keeper-api/auth"
+	"github.com/phlashdev/recipe-keeper-api/core"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type fieldChangeModel struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+type revisionSummaryModel struct {
+	Number    int                `json:"number"`
+	Author    string             `json:"author"`
+	CreatedAt time.Time          `json:"createdAt"`
+	Changes   []fieldChangeModel `json:"changes"`
+}
+
+type revisionModel struct {
+	Number    int         `json:"number"`
+	Author    string      `json:"author"`
+	CreatedAt time.Time   `json:"createdAt"`
+	Recipe    recipeModel `json:"recipe"`
+}
+
+type revisionDiffModel struct {
+	From    int                `json:"from"`
+	To      int                `json:"to"`
+	Changes []fieldChangeModel `json:"changes"`
+}
+
+func newFieldChangeModels(changes []core.FieldChange) []fieldChangeModel {
+	changeModels := make([]fieldChangeModel, 0, len(changes))
+	for _, change := range changes {
+		changeModels = append(changeModels, fieldChangeModel{
+			Field: change.Field,
+			Old:   newFieldValueModel(change.Old),
+			New:   newFieldValueModel(change.New),
+		})
+	}
+
+	return changeModels
+}
+
+// newFieldValueModel converts field values to the representation used in recipe models.
+func newFieldValueModel(value interface{}) interface{} {
+	switch v := value.(type) {
+	case primitive.ObjectID:
+		if v.IsZero() {
+			return ""
+		}
+		return v.Hex()
+	case []core.Ingredient:
+		return newIngredientModels(v)
+	case []core.Step:
+		return newStepModels(v)
+	default:
+		return v
+	}
+}
+
+// requestAuthor names the signed in user as author of a change.
+func requestAuthor(r *http.Request) string {
+	user, _ := auth.UserFromContext(r.Context())
+	return user.Name()
+}
+
+type GetRevisionsHandler struct {
+	recipeRepository   core.RecipeRepository
+	revisionRepository core.RevisionRepository
+}
+
+func NewGetRevisionsHandler(recipeRepository core.RecipeRepository, revisionRepository core.RevisionRepository) *GetRevisionsHandler {
+	return &GetRevisionsHandler{
+		recipeRepository:   recipeRepository,
+		revisionRepository: revisionRepository,
+	}
+}
+
+func (handler *GetRevisionsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	vars := mux.Vars(r)
+	id := vars["id"]
+	_, err := handler.recipeRepository.GetRecipeByID(ctx, id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	revisions, err := handler.revisionRepository.GetRevisions(ctx, id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// every revision lists its changes against the one before
+	revisionModels := make([]revisionSummaryModel, 0, len(revisions))
+	previous := core.Recipe{}
+	for _, revision := range revisions {
+		revisionModels = append(revisionModels, revisionSummaryModel{
+			Number:    revision.Number,
+			Author:    revision.Author,
+			CreatedAt: revision.CreatedAt,
+			Changes:   newFieldChangeModels(core.DiffRecipes(previous, revision.Snapshot)),
+		})
+		previous = revision.Snapshot
+	}
+
+	jsonRevisions, err := json.Marshal(revisionModels)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	_, err = w.Write(jsonRevisions)
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+type GetRevisionHandler struct {
+	revisionRepository core.RevisionRepository
+}
+
+func NewGetRevisionHandler(revisionRepository core.RevisionRepository) *GetRevisionHandler {
+	return &GetRevisionHandler{
+		revisionRepository: revisionRepository,
+	}
+}
+
+func (handler *GetRevisionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	vars := mux.Vars(r)
+	number, err := strconv.Atoi(vars["number"])
+	if err != nil {
+		log.Print(err)
+		writeProblem(w, r, newProblem(http.StatusNotFound, problemTypeNotFound, err.Error()))
+		return
+	}
+
+	revision, err := handler.revisionRepository.GetRevision(ctx, vars["id"], number)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	jsonRevision, err := json.Marshal(revisionModel{
+		Number:    revision.Number,
+		Author:    revision.Author,
+		CreatedAt: revision.CreatedAt,
+		Recipe:    newRecipeModel(revision.Snapshot),
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	_, err = w.Write(jsonRevision)
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetRevisionDiffHandler compares the revisions given by the query parameters from and to.
+type GetRevisionDiffHandler struct {
+	revisionRepository core.RevisionRepository
+}
+
+func NewGetRevisionDiffHandler(revisionRepository core.RevisionRepository) *GetRevisionDiffHandler {
+	return &GetRevisionDiffHandler{
+		revisionRepository: revisionRepository,
+	}
+}
+
+func (handler *GetRevisionDiffHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := r.URL.Query()
+	from, err := strconv.Atoi(query.Get("from"))
+	if err != nil {
+		writeError(w, r, invalidParam("from", err))
+		return
+	}
+	to, err := strconv.Atoi(query.Get("to"))
+	if err != nil {
+		writeError(w, r, invalidParam("to", err))
+		return
+	}
+
+	vars := mux.Vars(r)
+	id := vars["id"]
+	fromRevision, err := handler.revisionRepository.GetRevision(ctx, id, from)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	toRevision, err := handler.revisionRepository.GetRevision(ctx, id, to)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	jsonDiff, err := json.Marshal(revisionDiffModel{
+		From:    from,
+		To:      to,
+		Changes: newFieldChangeModels(core.DiffRecipes(fromRevision.Snapshot, toRevision.Snapshot)),
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	_, err = w.Write(jsonDiff)
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// RestoreRevisionHandler sets the recipe back to an earlier revision. The restore is recorded as
+// a new revision, so it can be undone as well.
+type RestoreRevisionHandler struct {
+	recipeRepository   core.RecipeRepository
+	revisionRepository core.RevisionRepository
+}
+
+func NewRestoreRevisionHandler(recipeRepository core.RecipeRepository, revisionRepository core.RevisionRepository) *RestoreRevisionHandler {
+	return &RestoreRevisionHandler{
+		recipeRepository:   recipeRepository,
+		revisionRepository: revisionRepository,
+	}
+}
+
+func (handler *RestoreRevisionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	vars := mux.Vars(r)
+	id := vars["id"]
+	recipe, err := handler.recipeRepository.GetRecipeByID(ctx, id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	number, err := strconv.Atoi(vars["number"])
+	if err != nil {
+		log.Print(err)
+		writeProblem(w, r, newProblem(http.StatusNotFound, problemTypeNotFound, err.Error()))
+		return
+	}
+
+	revision, err := handler.revisionRepository.GetRevision(ctx, id, number)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if !checkIfMatch(w, r, recipe.Version) {
+		return
+	}
+
+	restored := revision.Snapshot
+	restored.ID = recipe.ID
+	restored.Version = recipe.Version
+
+	err = handler.recipeRepository.UpdateRecipe(ctx, restored)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	err = core.RecordRevision(ctx, handler.revisionRepository, &recipe, restored, requestAuthor(r))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// the repository increased the stored version, the client sends it with its next update
+	w.Header().Set("ETag", formatETag(restored.Version+1, ""))
+	w.WriteHeader(http.StatusNoContent)
+}
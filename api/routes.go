@@ -0,0 +1,105 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/phlashdev/recipe-keeper-api/auth"
+	"github.com/phlashdev/recipe-keeper-api/cooking"
+	"github.com/phlashdev/recipe-keeper-api/core"
+)
+
+// Repositories are the stores read and written by the API.
+type Repositories struct {
+	Recipes       core.RecipeRepository
+	Sources       core.SourceRepository
+	MealPlans     core.MealPlanRepository
+	ShoppingLists core.ShoppingListRepository
+	Pantry        core.PantryRepository
+	Revisions     core.RevisionRepository
+	Idempotency   core.IdempotencyRepository
+}
+
+// RegisterRoutes adds the routes below /api to the router. Authentication is left to the
+// middleware of the router.
+func RegisterRoutes(router *mux.Router, repositories Repositories, authenticator *auth.Authenticator, cookingTimers *cooking.Timers) {
+	router.Handle("/api/login", NewLoginHandler(authenticator)).Methods(http.MethodPost)
+	router.Handle("/api/logout", NewLogoutHandler(authenticator)).Methods(http.MethodPost)
+	router.Handle("/api/me", NewGetCurrentUserHandler()).Methods(http.MethodGet)
+
+	recipesSubrouter := router.PathPrefix("/api/recipes").Subrouter()
+	recipesSubrouter.Handle("/batch", NewIdempotencyHandler(repositories.Idempotency, NewBatchRecipesHandler(repositories.Recipes, repositories.Revisions))).Methods(http.MethodPost)
+	recipesSubrouter.Handle("/{id}.pdf", NewGetRecipePDFHandler(repositories.Recipes, repositories.Sources)).Methods(http.MethodGet)
+	recipesSubrouter.Handle("/{id}/cook", NewGetCookHandler(repositories.Recipes, cookingTimers)).Methods(http.MethodGet)
+	recipesSubrouter.Handle("/{id}/cook/steps/{number:[0-9]+}", NewGetCookStepHandler(repositories.Recipes)).Methods(http.MethodGet)
+	recipesSubrouter.Handle("/{id}/cook/timers", NewGetCookTimersHandler(cookingTimers)).Methods(http.MethodGet)
+	recipesSubrouter.Handle("/{id}/cook/timers", NewIdempotencyHandler(repositories.Idempotency, NewStartCookTimerHandler(repositories.Recipes, cookingTimers))).Methods(http.MethodPost)
+	recipesSubrouter.Handle("/{id}/cook/timers/{timerId}", NewDeleteCookTimerHandler(cookingTimers)).Methods(http.MethodDelete)
+	recipesSubrouter.Handle("/{id}/cook/events", NewCookEventsHandler(cookingTimers)).Methods(http.MethodGet)
+	recipesSubrouter.Handle("/{id}/revisions", NewGetRevisionsHandler(repositories.Recipes, repositories.Revisions)).Methods(http.MethodGet)
+	recipesSubrouter.Handle("/{id}/revisions/diff", NewGetRevisionDiffHandler(repositories.Revisions)).Methods(http.MethodGet)
+	recipesSubrouter.Handle("/{id}/revisions/{number:[0-9]+}", NewGetRevisionHandler(repositories.Revisions)).Methods(http.MethodGet)
+	recipesSubrouter.Handle("/{id}/revisions/{number:[0-9]+}/restore", NewRestoreRevisionHandler(repositories.Recipes, repositories.Revisions)).Methods(http.MethodPost)
+	recipesSubrouter.Handle("/{id}", NewGetRecipeHandler(repositories.Recipes, repositories.Sources)).Methods(http.MethodGet)
+	recipesSubrouter.Handle("/{id}", NewUpdateRecipeHandler(repositories.Recipes, repositories.Revisions)).Methods(http.MethodPut)
+	recipesSubrouter.Handle("/{id}", NewPatchRecipeHandler(repositories.Recipes, repositories.Revisions)).Methods(http.MethodPatch)
+	recipesSubrouter.Handle("/{id}", NewDeleteRecipeHandler(repositories.Recipes)).Methods(http.MethodDelete)
+	recipesSubrouter.Handle("/", NewGetRecipesHandler(repositories.Recipes, repositories.Sources)).Methods(http.MethodGet)
+	recipesSubrouter.Handle("", NewGetRecipesHandler(repositories.Recipes, repositories.Sources)).Methods(http.MethodGet)
+	recipesSubrouter.Handle("", NewIdempotencyHandler(repositories.Idempotency, NewAddRecipeHandler(repositories.Recipes, repositories.Revisions))).Methods(http.MethodPost)
+
+	sourcesSubrouter := router.PathPrefix("/api/sources").Subrouter()
+	sourcesSubrouter.Handle("/batch", NewIdempotencyHandler(repositories.Idempotency, NewBatchSourcesHandler(repositories.Sources))).Methods(http.MethodPost)
+	sourcesSubrouter.Handle("/{id}", NewGetSourceHandler(repositories.Sources)).Methods(http.MethodGet)
+	sourcesSubrouter.Handle("/{id}", NewUpdateSourceHandler(repositories.Sources)).Methods(http.MethodPut)
+	sourcesSubrouter.Handle("/{id}", NewPatchSourceHandler(repositories.Sources)).Methods(http.MethodPatch)
+	sourcesSubrouter.Handle("/{id}", NewDeleteSourceHandler(repositories.Sources)).Methods(http.MethodDelete)
+	sourcesSubrouter.Handle("/", NewGetSourcesHandler(repositories.Sources)).Methods(http.MethodGet)
+	sourcesSubrouter.Handle("", NewGetSourcesHandler(repositories.Sources)).Methods(http.MethodGet)
+	sourcesSubrouter.Handle("", NewIdempotencyHandler(repositories.Idempotency, NewAddSourceHandler(repositories.Sources))).Methods(http.MethodPost)
+
+	mealPlansSubrouter := router.PathPrefix("/api/mealplans").Subrouter()
+	mealPlansSubrouter.Handle("/{id}.ics", NewGetMealPlanCalendarHandler(repositories.MealPlans, repositories.Recipes)).Methods(http.MethodGet)
+	mealPlansSubrouter.Handle("/{id}", NewGetMealPlanHandler(repositories.MealPlans)).Methods(http.MethodGet)
+	mealPlansSubrouter.Handle("/{id}", NewUpdateMealPlanHandler(repositories.MealPlans, repositories.Recipes)).Methods(http.MethodPut)
+	mealPlansSubrouter.Handle("/{id}", NewDeleteMealPlanHandler(repositories.MealPlans)).Methods(http.MethodDelete)
+	mealPlansSubrouter.Handle("/", NewGetMealPlansHandler(repositories.MealPlans)).Methods(http.MethodGet)
+	mealPlansSubrouter.Handle("", NewGetMealPlansHandler(repositories.MealPlans)).Methods(http.MethodGet)
+	mealPlansSubrouter.Handle("", NewIdempotencyHandler(repositories.Idempotency, NewAddMealPlanHandler(repositories.MealPlans, repositories.Recipes))).Methods(http.MethodPost)
+
+	shoppingListsSubrouter := router.PathPrefix("/api/shoppinglists").Subrouter()
+	shoppingListsSubrouter.Handle("/{id}/items/{itemId}", NewCheckShoppingListItemHandler(repositories.ShoppingLists)).Methods(http.MethodPut)
+	shoppingListsSubrouter.Handle("/{id}/complete", NewCompleteShoppingListHandler(repositories.ShoppingLists, repositories.Pantry)).Methods(http.MethodPost)
+	shoppingListsSubrouter.Handle("/{id}", NewGetShoppingListHandler(repositories.ShoppingLists)).Methods(http.MethodGet)
+	shoppingListsSubrouter.Handle("/{id}", NewUpdateShoppingListHandler(repositories.ShoppingLists)).Methods(http.MethodPut)
+	shoppingListsSubrouter.Handle("/{id}", NewDeleteShoppingListHandler(repositories.ShoppingLists)).Methods(http.MethodDelete)
+	shoppingListsSubrouter.Handle("/", NewGetShoppingListsHandler(repositories.ShoppingLists)).Methods(http.MethodGet)
+	shoppingListsSubrouter.Handle("", NewGetShoppingListsHandler(repositories.ShoppingLists)).Methods(http.MethodGet)
+	shoppingListsSubrouter.Handle("", NewIdempotencyHandler(repositories.Idempotency, NewAddShoppingListHandler(repositories.ShoppingLists, repositories.Recipes, repositories.MealPlans))).Methods(http.MethodPost)
+
+	pantrySubrouter := router.PathPrefix("/api/pantry").Subrouter()
+	pantrySubrouter.Handle("/recipes", NewGetCookableRecipesHandler(repositories.Pantry, repositories.Recipes)).Methods(http.MethodGet)
+	pantrySubrouter.Handle("/{id}", NewGetPantryItemHandler(repositories.Pantry)).Methods(http.MethodGet)
+	pantrySubrouter.Handle("/{id}", NewUpdatePantryItemHandler(repositories.Pantry)).Methods(http.MethodPut)
+	pantrySubrouter.Handle("/{id}", NewDeletePantryItemHandler(repositories.Pantry)).Methods(http.MethodDelete)
+	pantrySubrouter.Handle("/", NewGetPantryItemsHandler(repositories.Pantry)).Methods(http.MethodGet)
+	pantrySubrouter.Handle("", NewGetPantryItemsHandler(repositories.Pantry)).Methods(http.MethodGet)
+	pantrySubrouter.Handle("", NewIdempotencyHandler(repositories.Idempotency, NewAddPantryItemHandler(repositories.Pantry))).Methods(http.MethodPost)
+
+	trashSubrouter := router.PathPrefix("/api/trash").Subrouter()
+	trashSubrouter.Handle("/recipes/{id}/restore", NewRestoreRecipeHandler(repositories.Recipes)).Methods(http.MethodPost)
+	trashSubrouter.Handle("/recipes/{id}", NewPurgeRecipeHandler(repositories.Recipes)).Methods(http.MethodDelete)
+	trashSubrouter.Handle("/sources/{id}/restore", NewRestoreSourceHandler(repositories.Sources)).Methods(http.MethodPost)
+	trashSubrouter.Handle("/sources/{id}", NewPurgeSourceHandler(repositories.Sources)).Methods(http.MethodDelete)
+	trashSubrouter.Handle("/", NewGetTrashHandler(repositories.Recipes, repositories.Sources)).Methods(http.MethodGet)
+	trashSubrouter.Handle("", NewGetTrashHandler(repositories.Recipes, repositories.Sources)).Methods(http.MethodGet)
+
+	router.Handle("/api/cookbook.pdf", NewGetCookbookPDFHandler(repositories.Recipes, repositories.Sources)).Methods(http.MethodGet)
+
+	adminSubrouter := router.PathPrefix("/api/admin").Subrouter()
+	adminSubrouter.Handle("/backup", NewBackupHandler(repositories.Recipes, repositories.Sources)).Methods(http.MethodGet)
+	adminSubrouter.Handle("/restore", NewRestoreHandler(repositories.Recipes, repositories.Sources)).Methods(http.MethodPost)
+
+	router.Handle("/api/openapi.json", NewOpenAPIHandler()).Methods(http.MethodGet)
+	router.Handle("/api/docs", NewAPIDocsHandler("/api/openapi.json")).Methods(http.MethodGet)
+}
@@ -0,0 +1,490 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/phlashdev/recipe-keeper-api/core"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type shoppingListItemModel struct {
+	ID       string  `json:"id"`
+	Name     string  `json:"name"`
+	Quantity float64 `json:"quantity"`
+	Unit     string  `json:"unit"`
+	Aisle    string  `json:"aisle"`
+	Checked  bool    `json:"checked"`
+}
+
+type shoppingListAisleModel struct {
+	Name  string                  `json:"name"`
+	Items []shoppingListItemModel `json:"items"`
+}
+
+type shoppingListModel struct {
+	ID          string                   `json:"id"`
+	Title       string                   `json:"title"`
+	Aisles      []shoppingListAisleModel `json:"aisles"`
+	CompletedAt *time.Time               `json:"completedAt,omitempty"`
+}
+
+type plannedRecipeModel struct {
+	RecipeID string `json:"recipeId"`
+	Servings int    `json:"servings"`
+}
+
+// shoppingListForCreationModel either lists the recipes to shop for or selects the entries of
+// a meal plan between two dates, both inclusive.
+type shoppingListForCreationModel struct {
+	Title      string               `json:"title"`
+	Recipes    []plannedRecipeModel `json:"recipes"`
+	MealPlanID string               `json:"mealPlanId"`
+	From       string               `json:"from"`
+	To         string               `json:"to"`
+}
+
+type shoppingListForUpdateModel struct {
+	Title string                  `json:"title"`
+	Items []shoppingListItemModel `json:"items"`
+}
+
+type shoppingListItemForUpdateModel struct {
+	Checked bool `json:"checked"`
+}
+
+func newShoppingListModel(shoppingList core.ShoppingList) shoppingListModel {
+	model := shoppingListModel{
+		ID:     shoppingList.ID.Hex(),
+		Title:  shoppingList.Title,
+		Aisles: []shoppingListAisleModel{},
+	}
+	if !shoppingList.CompletedAt.IsZero() {
+		model.CompletedAt = &shoppingList.CompletedAt
+	}
+
+	// the items are stored sorted by aisle
+	for _, item := range shoppingList.Items {
+		if len(model.Aisles) == 0 || model.Aisles[len(model.Aisles)-1].Name != item.Aisle {
+			model.Aisles = append(model.Aisles, shoppingListAisleModel{Name: item.Aisle})
+		}
+
+		aisle := &model.Aisles[len(model.Aisles)-1]
+		aisle.Items = append(aisle.Items, shoppingListItemModel{
+			ID:       item.ID.Hex(),
+			Name:     item.Name,
+			Quantity: item.Quantity,
+			Unit:     item.Unit,
+			Aisle:    item.Aisle,
+			Checked:  item.Checked,
+		})
+	}
+
+	return model
+}
+
+type GetShoppingListsHandler struct {
+	shoppingListRepository core.ShoppingListRepository
+}
+
+func NewGetShoppingListsHandler(shoppingListRepository core.ShoppingListRepository) *GetShoppingListsHandler {
+	return &GetShoppingListsHandler{
+		shoppingListRepository: shoppingListRepository,
+	}
+}
+
+func (handler *GetShoppingListsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	shoppingLists, err := handler.shoppingListRepository.GetShoppingLists(ctx)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var shoppingListModels = make([]shoppingListModel, 0, len(shoppingLists))
+	for _, shoppingList := range shoppingLists {
+		shoppingListModels = append(shoppingListModels, newShoppingListModel(shoppingList))
+	}
+
+	jsonShoppingLists, err := json.Marshal(shoppingListModels)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	_, err = w.Write(jsonShoppingLists)
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+type GetShoppingListHandler struct {
+	shoppingListRepository core.ShoppingListRepository
+}
+
+func NewGetShoppingListHandler(shoppingListRepository core.ShoppingListRepository) *GetShoppingListHandler {
+	return &GetShoppingListHandler{
+		shoppingListRepository: shoppingListRepository,
+	}
+}
+
+func (handler *GetShoppingListHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	vars := mux.Vars(r)
+	id := vars["id"]
+	shoppingList, err := handler.shoppingListRepository.GetShoppingListByID(ctx, id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	jsonShoppingList, err := json.Marshal(newShoppingListModel(shoppingList))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	_, err = w.Write(jsonShoppingList)
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+type AddShoppingListHandler struct {
+	shoppingListRepository core.ShoppingListRepository
+	recipeRepository       core.RecipeRepository
+	mealPlanRepository     core.MealPlanRepository
+}
+
+func NewAddShoppingListHandler(shoppingListRepository core.ShoppingListRepository, recipeRepository core.RecipeRepository, mealPlanRepository core.MealPlanRepository) *AddShoppingListHandler {
+	return &AddShoppingListHandler{
+		shoppingListRepository: shoppingListRepository,
+		recipeRepository:       recipeRepository,
+		mealPlanRepository:     mealPlanRepository,
+	}
+}
+
+func (handler *AddShoppingListHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var shoppingListForCreation shoppingListForCreationModel
+	err := json.NewDecoder(r.Body).Decode(&shoppingListForCreation)
+	if err != nil {
+		writeError(w, r, malformedRequest(err))
+		return
+	}
+
+	var plannedRecipes []core.PlannedRecipe
+	title := shoppingListForCreation.Title
+	if len(shoppingListForCreation.MealPlanID) > 0 {
+		var mealPlan core.MealPlan
+		mealPlan, plannedRecipes, err = handler.plannedRecipesFromMealPlan(ctx, shoppingListForCreation)
+		if len(title) == 0 && err == nil {
+			title = mealPlan.Title
+		}
+	} else {
+		plannedRecipes, err = handler.plannedRecipes(ctx, shoppingListForCreation.Recipes)
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if len(title) == 0 {
+		title = "Shopping list"
+	}
+	shoppingList := core.ShoppingList{
+		Title: title,
+		Items: core.NewShoppingListItems(plannedRecipes),
+	}
+	err = handler.shoppingListRepository.AddShoppingList(ctx, &shoppingList)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeCreated(w, r, shoppingList.ID.Hex(), newShoppingListModel(shoppingList))
+}
+
+func (handler *AddShoppingListHandler) plannedRecipes(ctx context.Context, plannedRecipeModels []plannedRecipeModel) ([]core.PlannedRecipe, error) {
+	var plannedRecipes []core.PlannedRecipe
+	for i, plannedRecipeModel := range plannedRecipeModels {
+		recipe, err := handler.recipeRepository.GetRecipeByID(ctx, plannedRecipeModel.RecipeID)
+		if err != nil {
+			return nil, invalidParam(fmt.Sprintf("recipes[%d].recipeId", i), err)
+		}
+
+		plannedRecipes = append(plannedRecipes, core.PlannedRecipe{
+			Recipe:   recipe,
+			Servings: plannedRecipeModel.Servings,
+		})
+	}
+
+	return plannedRecipes, nil
+}
+
+func (handler *AddShoppingListHandler) plannedRecipesFromMealPlan(ctx context.Context, model shoppingListForCreationModel) (core.MealPlan, []core.PlannedRecipe, error) {
+	from, err := time.Parse(dateFormat, model.From)
+	if err != nil {
+		return core.MealPlan{}, nil, invalidParam("from", &core.DateNotValidError{Date: model.From})
+	}
+	to, err := time.Parse(dateFormat, model.To)
+	if err != nil || to.Before(from) {
+		return core.MealPlan{}, nil, invalidParam("to", &core.DateNotValidError{Date: model.To})
+	}
+
+	mealPlan, err := handler.mealPlanRepository.GetMealPlanByID(ctx, model.MealPlanID)
+	if err != nil {
+		return core.MealPlan{}, nil, invalidParam("mealPlanId", err)
+	}
+
+	var plannedRecipes []core.PlannedRecipe
+	for _, entry := range mealPlan.Entries {
+		date := entry.Date.UTC()
+		if date.Before(from) || date.After(to) {
+			continue
+		}
+
+		recipe, err := handler.recipeRepository.GetRecipeByID(ctx, entry.Recipe.Hex())
+		if err != nil {
+			// recipes deleted after planning are left out
+			var e *core.RecipeNotFoundError
+			if errors.As(err, &e) {
+				continue
+			}
+			return core.MealPlan{}, nil, err
+		}
+
+		plannedRecipes = append(plannedRecipes, core.PlannedRecipe{
+			Recipe:   recipe,
+			Servings: entry.Servings,
+		})
+	}
+
+	return mealPlan, plannedRecipes, nil
+}
+
+type UpdateShoppingListHandler struct {
+	shoppingListRepository core.ShoppingListRepository
+}
+
+func NewUpdateShoppingListHandler(shoppingListRepository core.ShoppingListRepository) *UpdateShoppingListHandler {
+	return &UpdateShoppingListHandler{
+		shoppingListRepository: shoppingListRepository,
+	}
+}
+
+func (handler *UpdateShoppingListHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	vars := mux.Vars(r)
+	id := vars["id"]
+	shoppingList, err := handler.shoppingListRepository.GetShoppingListByID(ctx, id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var shoppingListForUpdate shoppingListForUpdateModel
+	err = json.NewDecoder(r.Body).Decode(&shoppingListForUpdate)
+	if err != nil {
+		writeError(w, r, malformedRequest(err))
+		return
+	}
+
+	// items keep their id, so ticks sent for the list before the update still find them
+	itemIDs := map[string]primitive.ObjectID{}
+	for _, item := range shoppingList.Items {
+		if !item.ID.IsZero() {
+			itemIDs[item.ID.Hex()] = item.ID
+		}
+	}
+
+	var items []core.ShoppingListItem
+	for _, itemModel := range shoppingListForUpdate.Items {
+		itemID, ok := itemIDs[itemModel.ID]
+		if ok {
+			delete(itemIDs, itemModel.ID)
+		} else {
+			itemID = primitive.NewObjectID()
+		}
+
+		aisle := itemModel.Aisle
+		if len(aisle) == 0 {
+			aisle = core.AisleOf(itemModel.Name)
+		}
+
+		items = append(items, core.ShoppingListItem{
+			ID:       itemID,
+			Name:     itemModel.Name,
+			Quantity: itemModel.Quantity,
+			Unit:     itemModel.Unit,
+			Aisle:    aisle,
+			Checked:  itemModel.Checked,
+		})
+	}
+	core.SortShoppingListItems(items)
+
+	shoppingList.Title = shoppingListForUpdate.Title
+	shoppingList.Items = items
+
+	err = handler.shoppingListRepository.UpdateShoppingList(ctx, shoppingList)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type CheckShoppingListItemHandler struct {
+	shoppingListRepository core.ShoppingListRepository
+}
+
+func NewCheckShoppingListItemHandler(shoppingListRepository core.ShoppingListRepository) *CheckShoppingListItemHandler {
+	return &CheckShoppingListItemHandler{
+		shoppingListRepository: shoppingListRepository,
+	}
+}
+
+func (handler *CheckShoppingListItemHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	vars := mux.Vars(r)
+	id := vars["id"]
+	shoppingList, err := handler.shoppingListRepository.GetShoppingListByID(ctx, id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var itemForUpdate shoppingListItemForUpdateModel
+	err = json.NewDecoder(r.Body).Decode(&itemForUpdate)
+	if err != nil {
+		writeError(w, r, malformedRequest(err))
+		return
+	}
+
+	err = handler.shoppingListRepository.CheckShoppingListItem(ctx, shoppingList, vars["itemId"], itemForUpdate.Checked)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CompleteShoppingListHandler marks the shopping as done. With the query parameter restock=true
+// the checked items are added to the pantry.
+type CompleteShoppingListHandler struct {
+	shoppingListRepository core.ShoppingListRepository
+	pantryRepository       core.PantryRepository
+}
+
+func NewCompleteShoppingListHandler(shoppingListRepository core.ShoppingListRepository, pantryRepository core.PantryRepository) *CompleteShoppingListHandler {
+	return &CompleteShoppingListHandler{
+		shoppingListRepository: shoppingListRepository,
+		pantryRepository:       pantryRepository,
+	}
+}
+
+func (handler *CompleteShoppingListHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	restock := false
+	if value := r.URL.Query().Get("restock"); len(value) > 0 {
+		var err error
+		restock, err = strconv.ParseBool(value)
+		if err != nil {
+			writeError(w, r, invalidParam("restock", err))
+			return
+		}
+	}
+
+	vars := mux.Vars(r)
+	id := vars["id"]
+	shoppingList, err := handler.shoppingListRepository.GetShoppingListByID(ctx, id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	err = handler.shoppingListRepository.CompleteShoppingList(ctx, &shoppingList)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// completing first keeps concurrent requests from restocking twice
+	if restock {
+		err = handler.pantryRepository.RestockPantry(ctx, shoppingList.Items)
+		if err != nil {
+			handler.reopen(shoppingList)
+			writeError(w, r, err)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// reopen undoes the completion after the restock failed, so the request can be retried. It has
+// a context of its own as the failed restock may have used up the time of the request.
+func (handler *CompleteShoppingListHandler) reopen(shoppingList core.ShoppingList) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := handler.shoppingListRepository.ReopenShoppingList(ctx, shoppingList); err != nil {
+		log.Print(err)
+	}
+}
+
+type DeleteShoppingListHandler struct {
+	shoppingListRepository core.ShoppingListRepository
+}
+
+func NewDeleteShoppingListHandler(shoppingListRepository core.ShoppingListRepository) *DeleteShoppingListHandler {
+	return &DeleteShoppingListHandler{
+		shoppingListRepository: shoppingListRepository,
+	}
+}
+
+func (handler *DeleteShoppingListHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	vars := mux.Vars(r)
+	id := vars["id"]
+	shoppingList, err := handler.shoppingListRepository.GetShoppingListByID(ctx, id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	err = handler.shoppingListRepository.DeleteShoppingList(ctx, shoppingList)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
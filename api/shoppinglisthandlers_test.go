@@ -0,0 +1,120 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/phlashdev/recipe-keeper-api/core"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// completingShoppingListRepository stores a single list, the other methods are not used.
+type completingShoppingListRepository struct {
+	core.ShoppingListRepository
+	shoppingList core.ShoppingList
+}
+
+func (repo *completingShoppingListRepository) GetShoppingListByID(ctx context.Context, id string) (core.ShoppingList, error) {
+	return repo.shoppingList, nil
+}
+
+func (repo *completingShoppingListRepository) UpdateShoppingList(ctx context.Context, shoppingList core.ShoppingList) error {
+	repo.shoppingList = shoppingList
+	return nil
+}
+
+func (repo *completingShoppingListRepository) CompleteShoppingList(ctx context.Context, shoppingList *core.ShoppingList) error {
+	if !repo.shoppingList.CompletedAt.IsZero() {
+		return &core.ShoppingListCompletedError{ID: shoppingList.ID.Hex()}
+	}
+	shoppingList.CompletedAt = time.Now().UTC().Truncate(time.Millisecond)
+	repo.shoppingList.CompletedAt = shoppingList.CompletedAt
+	return nil
+}
+
+func (repo *completingShoppingListRepository) ReopenShoppingList(ctx context.Context, shoppingList core.ShoppingList) error {
+	if repo.shoppingList.CompletedAt.Equal(shoppingList.CompletedAt) {
+		repo.shoppingList.CompletedAt = time.Time{}
+	}
+	return nil
+}
+
+// failingPantryRepository fails the first restocks.
+type failingPantryRepository struct {
+	core.PantryRepository
+	failures int
+	restocks int
+}
+
+func (repo *failingPantryRepository) RestockPantry(ctx context.Context, items []core.ShoppingListItem) error {
+	if repo.failures > 0 {
+		repo.failures--
+		return errors.New("pantry not available")
+	}
+	repo.restocks++
+	return nil
+}
+
+func TestCompleteShoppingListReopensOnFailedRestock(t *testing.T) {
+	shoppingLists := &completingShoppingListRepository{shoppingList: core.ShoppingList{
+		ID:    primitive.NewObjectID(),
+		Items: []core.ShoppingListItem{{Name: "Flour", Quantity: 1, Unit: core.UnitKilogram, Checked: true}},
+	}}
+	pantry := &failingPantryRepository{failures: 1}
+	router := mux.NewRouter()
+	router.Handle("/api/shoppinglists/{id}/complete", NewCompleteShoppingListHandler(shoppingLists, pantry))
+	path := "/api/shoppinglists/" + shoppingLists.shoppingList.ID.Hex() + "/complete?restock=true"
+
+	for _, want := range []int{http.StatusInternalServerError, http.StatusNoContent, http.StatusConflict} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, nil))
+
+		if w.Code != want {
+			t.Fatalf("status = %d, want %d", w.Code, want)
+		}
+	}
+	if pantry.restocks != 1 || shoppingLists.shoppingList.CompletedAt.IsZero() {
+		t.Errorf("restocked %d times, completed at %v, want one restock of the completed list", pantry.restocks, shoppingLists.shoppingList.CompletedAt)
+	}
+}
+
+func TestUpdateShoppingListKeepsItemIDs(t *testing.T) {
+	flour := core.ShoppingListItem{ID: primitive.NewObjectID(), Name: "Flour", Aisle: core.AisleBakery}
+	milk := core.ShoppingListItem{ID: primitive.NewObjectID(), Name: "Milk", Aisle: core.AisleDairy}
+	shoppingLists := &completingShoppingListRepository{shoppingList: core.ShoppingList{
+		ID:    primitive.NewObjectID(),
+		Items: []core.ShoppingListItem{flour, milk},
+	}}
+	router := mux.NewRouter()
+	router.Handle("/api/shoppinglists/{id}", NewUpdateShoppingListHandler(shoppingLists))
+
+	// milk is sent twice and eggs are new, only the first milk may keep the id
+	body := `{"title":"Weekend","items":[
+		{"id":"` + milk.ID.Hex() + `","name":"Milk","aisle":"dairy"},
+		{"id":"` + milk.ID.Hex() + `","name":"Milk","aisle":"dairy"},
+		{"id":"unknown","name":"Eggs","aisle":"dairy"},
+		{"id":"` + flour.ID.Hex() + `","name":"Flour","aisle":"bakery"}
+	]}`
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/api/shoppinglists/"+shoppingLists.shoppingList.ID.Hex(), strings.NewReader(body)))
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusNoContent)
+	}
+	ids := map[primitive.ObjectID]string{}
+	for _, item := range shoppingLists.shoppingList.Items {
+		if item.ID.IsZero() || ids[item.ID] != "" {
+			t.Errorf("item %s has id %s, want a unique id", item.Name, item.ID.Hex())
+		}
+		ids[item.ID] = item.Name
+	}
+	if ids[flour.ID] != "Flour" || ids[milk.ID] != "Milk" {
+		t.Errorf("ids = %v, want flour and milk to keep theirs", ids)
+	}
+}
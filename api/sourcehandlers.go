@@ -3,9 +3,7 @@ package api
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
-	"log"
 	"net/http"
 	"time"
 
@@ -45,13 +43,22 @@ func (handler *GetSourcesHandler) ServeHTTP(w http.ResponseWriter, r *http.Reque
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	offset, limit, err := parsePage(r.URL.Query())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
 	sources, err := handler.sourceRepository.GetSources(ctx)
 	if err != nil {
-		fmt.Println(err)
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
+	// there are few sources, they are paged here instead of in the repository
+	start, end := page(len(sources), offset, limit)
+	sources = sources[start:end]
+
 	var sourceModels = make([]sourceModel, 0, len(sources))
 	for _, source := range sources {
 		sourceModels = append(sourceModels, sourceModel{
@@ -65,8 +72,7 @@ func (handler *GetSourcesHandler) ServeHTTP(w http.ResponseWriter, r *http.Reque
 
 	jsonRecipes, err := json.Marshal(sourceModels)
 	if err != nil {
-		fmt.Println(err)
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
@@ -96,14 +102,11 @@ func (handler *GetSourceHandler) ServeHTTP(w http.ResponseWriter, r *http.Reques
 	id := vars["id"]
 	source, err := handler.sourceRepository.GetSourceByID(ctx, id)
 	if err != nil {
-		fmt.Println(err)
+		writeError(w, r, err)
+		return
+	}
 
-		var e *core.SourceNotFoundError
-		if errors.As(err, &e) {
-			w.WriteHeader(http.StatusNotFound)
-		} else {
-			w.WriteHeader(http.StatusInternalServerError)
-		}
+	if !checkIfNoneMatch(w, r, source.Version, "") {
 		return
 	}
 
@@ -117,8 +120,7 @@ func (handler *GetSourceHandler) ServeHTTP(w http.ResponseWriter, r *http.Reques
 
 	jsonRecipe, err := json.Marshal(sourceModel)
 	if err != nil {
-		fmt.Println(err)
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
@@ -147,8 +149,7 @@ func (handler *AddSourceHandler) ServeHTTP(w http.ResponseWriter, r *http.Reques
 	var sourceForCreation sourceForCreationModel
 	err := json.NewDecoder(r.Body).Decode(&sourceForCreation)
 	if err != nil {
-		fmt.Println(err)
-		w.WriteHeader(http.StatusBadRequest)
+		writeError(w, r, malformedRequest(err))
 		return
 	}
 
@@ -158,18 +159,18 @@ func (handler *AddSourceHandler) ServeHTTP(w http.ResponseWriter, r *http.Reques
 	}
 	err = handler.sourceRepository.AddSource(ctx, &source)
 	if err != nil {
-		log.Print(err)
-
-		var e *core.SourceTypeNotValidError
-		if errors.As(err, &e) {
-			w.WriteHeader(http.StatusBadRequest)
-		} else {
-			w.WriteHeader(http.StatusInternalServerError)
-		}
+		writeError(w, r, err)
 		return
 	}
 
-	w.WriteHeader(http.StatusCreated)
+	w.Header().Set("ETag", formatETag(source.Version, ""))
+	writeCreated(w, r, source.ID.Hex(), sourceModel{
+		ID: source.ID.Hex(),
+		sourceModelBase: sourceModelBase{
+			Title:      source.Title,
+			SourceType: source.Type,
+		},
+	})
 }
 
 type UpdateSourceHandler struct {
@@ -190,22 +191,18 @@ func (handler *UpdateSourceHandler) ServeHTTP(w http.ResponseWriter, r *http.Req
 	id := vars["id"]
 	source, err := handler.sourceRepository.GetSourceByID(ctx, id)
 	if err != nil {
-		fmt.Println(err)
+		writeError(w, r, err)
+		return
+	}
 
-		var e *core.SourceNotFoundError
-		if errors.As(err, &e) {
-			w.WriteHeader(http.StatusNotFound)
-		} else {
-			w.WriteHeader(http.StatusInternalServerError)
-		}
+	if !checkIfMatch(w, r, source.Version) {
 		return
 	}
 
 	var sourceForUpdate sourceForUpdateModel
 	err = json.NewDecoder(r.Body).Decode(&sourceForUpdate)
 	if err != nil {
-		fmt.Println(err)
-		w.WriteHeader(http.StatusBadRequest)
+		writeError(w, r, malformedRequest(err))
 		return
 	}
 
@@ -214,11 +211,12 @@ func (handler *UpdateSourceHandler) ServeHTTP(w http.ResponseWriter, r *http.Req
 
 	err = handler.sourceRepository.UpdateSource(ctx, source)
 	if err != nil {
-		log.Print(err)
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
+	// the repository increased the stored version, the client sends it with its next update
+	w.Header().Set("ETag", formatETag(source.Version+1, ""))
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -240,21 +238,17 @@ func (handler *DeleteSourceHandler) ServeHTTP(w http.ResponseWriter, r *http.Req
 	id := vars["id"]
 	source, err := handler.sourceRepository.GetSourceByID(ctx, id)
 	if err != nil {
-		fmt.Println(err)
+		writeError(w, r, err)
+		return
+	}
 
-		var e *core.SourceNotFoundError
-		if errors.As(err, &e) {
-			w.WriteHeader(http.StatusNotFound)
-		} else {
-			w.WriteHeader(http.StatusInternalServerError)
-		}
+	if !checkIfMatch(w, r, source.Version) {
 		return
 	}
 
 	err = handler.sourceRepository.DeleteSource(ctx, source)
 	if err != nil {
-		log.Print(err)
-		w.WriteHeader(http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
@@ -0,0 +1,188 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/phlashdev/recipe-keeper-api/core"
+)
+
+type trashedRecipeModel struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	DeletedAt time.Time `json:"deletedAt"`
+}
+
+type trashedSourceModel struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	Title     string    `json:"title"`
+	DeletedAt time.Time `json:"deletedAt"`
+}
+
+type trashModel struct {
+	Recipes []trashedRecipeModel `json:"recipes"`
+	Sources []trashedSourceModel `json:"sources"`
+}
+
+type GetTrashHandler struct {
+	recipeRepository core.RecipeRepository
+	sourceRepository core.SourceRepository
+}
+
+func NewGetTrashHandler(recipeRepository core.RecipeRepository, sourceRepository core.SourceRepository) *GetTrashHandler {
+	return &GetTrashHandler{
+		recipeRepository: recipeRepository,
+		sourceRepository: sourceRepository,
+	}
+}
+
+func (handler *GetTrashHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	recipes, err := handler.recipeRepository.GetDeletedRecipes(ctx)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	sources, err := handler.sourceRepository.GetDeletedSources(ctx)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	trash := trashModel{
+		Recipes: make([]trashedRecipeModel, 0, len(recipes)),
+		Sources: make([]trashedSourceModel, 0, len(sources)),
+	}
+	for _, recipe := range recipes {
+		trash.Recipes = append(trash.Recipes, trashedRecipeModel{
+			ID:        recipe.ID.Hex(),
+			Title:     recipe.Title,
+			DeletedAt: recipe.DeletedAt,
+		})
+	}
+	for _, source := range sources {
+		trash.Sources = append(trash.Sources, trashedSourceModel{
+			ID:        source.ID.Hex(),
+			Type:      source.Type,
+			Title:     source.Title,
+			DeletedAt: source.DeletedAt,
+		})
+	}
+
+	jsonTrash, err := json.Marshal(trash)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	_, err = w.Write(jsonTrash)
+	if err != nil {
+		log.Print(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+type RestoreRecipeHandler struct {
+	recipeRepository core.RecipeRepository
+}
+
+func NewRestoreRecipeHandler(recipeRepository core.RecipeRepository) *RestoreRecipeHandler {
+	return &RestoreRecipeHandler{
+		recipeRepository: recipeRepository,
+	}
+}
+
+func (handler *RestoreRecipeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	vars := mux.Vars(r)
+	err := handler.recipeRepository.RestoreRecipe(ctx, vars["id"])
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type PurgeRecipeHandler struct {
+	recipeRepository core.RecipeRepository
+}
+
+func NewPurgeRecipeHandler(recipeRepository core.RecipeRepository) *PurgeRecipeHandler {
+	return &PurgeRecipeHandler{
+		recipeRepository: recipeRepository,
+	}
+}
+
+func (handler *PurgeRecipeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	vars := mux.Vars(r)
+	err := handler.recipeRepository.PurgeRecipe(ctx, vars["id"])
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type RestoreSourceHandler struct {
+	sourceRepository core.SourceRepository
+}
+
+func NewRestoreSourceHandler(sourceRepository core.SourceRepository) *RestoreSourceHandler {
+	return &RestoreSourceHandler{
+		sourceRepository: sourceRepository,
+	}
+}
+
+func (handler *RestoreSourceHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	vars := mux.Vars(r)
+	err := handler.sourceRepository.RestoreSource(ctx, vars["id"])
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type PurgeSourceHandler struct {
+	sourceRepository core.SourceRepository
+}
+
+func NewPurgeSourceHandler(sourceRepository core.SourceRepository) *PurgeSourceHandler {
+	return &PurgeSourceHandler{
+		sourceRepository: sourceRepository,
+	}
+}
+
+func (handler *PurgeSourceHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	vars := mux.Vars(r)
+	err := handler.sourceRepository.PurgeSource(ctx, vars["id"])
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,145 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/phlashdev/recipe-keeper-api/core"
+)
+
+// SessionCookieName is the cookie holding the session token of the web pages.
+const SessionCookieName = "recipekeeper_session"
+
+type contextKey int
+
+const userContextKey contextKey = iota
+
+// WithUser returns a context carrying the signed in user.
+func WithUser(ctx context.Context, user core.User) context.Context {
+	return context.WithValue(ctx, userContextKey, user)
+}
+
+// UserFromContext returns the signed in user, false if the request is not authenticated.
+func UserFromContext(ctx context.Context) (core.User, bool) {
+	user, ok := ctx.Value(userContextKey).(core.User)
+	return user, ok
+}
+
+// RequestToken returns the session token of a request, from the Authorization header for API
+// clients or from the session cookie for the web pages.
+func RequestToken(r *http.Request) string {
+	authorization := r.Header.Get("Authorization")
+	if len(authorization) > 7 && strings.EqualFold(authorization[:7], "Bearer ") {
+		return strings.TrimSpace(authorization[7:])
+	}
+
+	cookie, err := r.Cookie(SessionCookieName)
+	if err != nil {
+		return ""
+	}
+
+	return cookie.Value
+}
+
+// SetSessionCookie stores the token in a cookie scripts cannot read. SameSite keeps other sites
+// from posting forms with the cookie.
+func SetSessionCookie(w http.ResponseWriter, r *http.Request, token string, expires time.Time) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookieName,
+		Value:    token,
+		Path:     "/",
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   isHTTPS(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func ClearSessionCookie(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   isHTTPS(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// isHTTPS also honors the header set by a reverse proxy terminating TLS.
+func isHTTPS(r *http.Request) bool {
+	return r.TLS != nil || strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https")
+}
+
+// RequiredRole returns the role needed for a request. Reading and cooking needs any role,
+// changes need editors and the admin routes admins.
+func RequiredRole(r *http.Request) string {
+	switch {
+	case strings.HasPrefix(r.URL.Path, "/api/admin/"):
+		return core.RoleAdmin
+	case r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions:
+		return core.RoleViewer
+	case strings.Contains(r.URL.Path, "/cook/"):
+		// starting timers changes no recipe
+		return core.RoleViewer
+	default:
+		return core.RoleEditor
+	}
+}
+
+// oidcStateCookieName holds the OIDCState while the user signs in at the provider. The cookie is
+// only sent to the login paths and expires with the login.
+const oidcStateCookieName = "recipekeeper_oidc"
+
+const oidcStateLifetime = 10 * time.Minute
+
+func SetOIDCStateCookie(w http.ResponseWriter, r *http.Request, state OIDCState) error {
+	value, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	// SameSite Lax still sends the cookie when the provider redirects back
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookieName,
+		Value:    base64.RawURLEncoding.EncodeToString(value),
+		Path:     "/login/",
+		MaxAge:   int(oidcStateLifetime / time.Second),
+		HttpOnly: true,
+		Secure:   isHTTPS(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	return nil
+}
+
+// OIDCStateFromCookie returns the state of the login, false if there is none.
+func OIDCStateFromCookie(r *http.Request) (OIDCState, bool) {
+	cookie, err := r.Cookie(oidcStateCookieName)
+	if err != nil {
+		return OIDCState{}, false
+	}
+
+	var state OIDCState
+	if err := decodeSegment(cookie.Value, &state); err != nil || len(state.State) == 0 {
+		return OIDCState{}, false
+	}
+
+	return state, true
+}
+
+func ClearOIDCStateCookie(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookieName,
+		Value:    "",
+		Path:     "/login/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   isHTTPS(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+}
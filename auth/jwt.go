@@ -0,0 +1,257 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// clockSkew is the difference tolerated between the clocks of the provider and the server.
+const clockSkew = time.Minute
+
+// IDTokenClaims are the claims of a verified ID token.
+type IDTokenClaims struct {
+	Issuer            string   `json:"iss"`
+	Subject           string   `json:"sub"`
+	Audience          audience `json:"aud"`
+	AuthorizedParty   string   `json:"azp"`
+	ExpiresAt         float64  `json:"exp"`
+	IssuedAt          float64  `json:"iat"`
+	Nonce             string   `json:"nonce"`
+	PreferredUsername string   `json:"preferred_username"`
+	Email             string   `json:"email"`
+	Name              string   `json:"name"`
+
+	raw map[string]interface{}
+}
+
+// Strings returns the values of a claim holding a string or a list of strings, a dot in the name
+// separates nested claims.
+func (claims IDTokenClaims) Strings(name string) []string {
+	if len(name) == 0 {
+		return nil
+	}
+
+	var value interface{} = claims.raw
+	for _, key := range strings.Split(name, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = object[key]
+	}
+
+	switch value := value.(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		var values []string
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
+
+// audience is a single audience or a list of them.
+type audience []string
+
+func (aud *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*aud = audience{single}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*aud = list
+	return nil
+}
+
+func (aud audience) contains(value string) bool {
+	for _, item := range aud {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+type jwtHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+}
+
+type jsonWebKey struct {
+	KeyType string `json:"kty"`
+	KeyID   string `json:"kid"`
+	Use     string `json:"use"`
+	N       string `json:"n"`
+	E       string `json:"e"`
+}
+
+// verifyIDToken checks the RS256 signature of the token with the keys of the provider and that the
+// token was issued by the provider for this client and has not expired.
+func (provider *OIDCProvider) verifyIDToken(ctx context.Context, discovery oidcDiscovery, token string, now time.Time) (IDTokenClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return IDTokenClaims{}, tokenError("malformed")
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return IDTokenClaims{}, tokenError("header not valid: %v", err)
+	}
+	// the algorithm is fixed, so a token cannot choose a weaker one or none
+	if header.Algorithm != "RS256" {
+		return IDTokenClaims{}, tokenError("algorithm %q not supported", header.Algorithm)
+	}
+
+	key, err := provider.key(ctx, discovery, header.KeyID)
+	if err != nil {
+		return IDTokenClaims{}, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return IDTokenClaims{}, tokenError("signature not valid: %v", err)
+	}
+	hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, hash[:], signature); err != nil {
+		return IDTokenClaims{}, tokenError("signature not valid")
+	}
+
+	var claims IDTokenClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return IDTokenClaims{}, tokenError("claims not valid: %v", err)
+	}
+	if err := decodeSegment(parts[1], &claims.raw); err != nil {
+		return IDTokenClaims{}, tokenError("claims not valid: %v", err)
+	}
+
+	switch {
+	case claims.Issuer != discovery.Issuer:
+		return IDTokenClaims{}, tokenError("issued by %q", claims.Issuer)
+	case !claims.Audience.contains(provider.config.ClientID):
+		return IDTokenClaims{}, tokenError("not issued for this client")
+	case len(claims.Audience) > 1 && len(claims.AuthorizedParty) > 0 && claims.AuthorizedParty != provider.config.ClientID:
+		return IDTokenClaims{}, tokenError("authorized party %q not this client", claims.AuthorizedParty)
+	case len(claims.Subject) == 0:
+		return IDTokenClaims{}, tokenError("subject missing")
+	case !now.Before(unixTime(claims.ExpiresAt).Add(clockSkew)):
+		return IDTokenClaims{}, tokenError("expired")
+	case unixTime(claims.IssuedAt).After(now.Add(clockSkew)):
+		return IDTokenClaims{}, tokenError("issued in the future")
+	}
+
+	return claims, nil
+}
+
+// key returns the public key with the id. The keys are fetched again for an unknown id, as the
+// provider rotates them.
+func (provider *OIDCProvider) key(ctx context.Context, discovery oidcDiscovery, keyID string) (*rsa.PublicKey, error) {
+	provider.mutex.Lock()
+	defer provider.mutex.Unlock()
+
+	jwk, ok := provider.findKey(keyID)
+	if !ok && time.Since(provider.keysFetchedAt) > keysRefreshInterval {
+		if err := provider.fetchKeys(ctx, discovery); err != nil {
+			return nil, err
+		}
+		jwk, ok = provider.findKey(keyID)
+	}
+	if !ok {
+		return nil, tokenError("signing key %q unknown", keyID)
+	}
+
+	return rsaPublicKey(jwk)
+}
+
+// findKey expects the mutex to be locked. Tokens without key id are accepted if there is only one key.
+func (provider *OIDCProvider) findKey(keyID string) (jsonWebKey, bool) {
+	if len(keyID) == 0 && len(provider.keys) == 1 {
+		for _, jwk := range provider.keys {
+			return jwk, true
+		}
+	}
+
+	jwk, ok := provider.keys[keyID]
+	return jwk, ok
+}
+
+func (provider *OIDCProvider) fetchKeys(ctx context.Context, discovery oidcDiscovery) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, discovery.JWKSURI, nil)
+	if err != nil {
+		return err
+	}
+
+	var keySet struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	status, err := provider.do(req, &keySet)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return &IdentityProviderError{Message: fmt.Sprintf("fetching keys failed with status %d", status)}
+	}
+
+	keys := map[string]jsonWebKey{}
+	for _, jwk := range keySet.Keys {
+		if jwk.KeyType == "RSA" && (len(jwk.Use) == 0 || jwk.Use == "sig") {
+			keys[jwk.KeyID] = jwk
+		}
+	}
+	provider.keys = keys
+	provider.keysFetchedAt = time.Now()
+
+	return nil
+}
+
+func rsaPublicKey(jwk jsonWebKey) (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+	if err != nil {
+		return nil, &IdentityProviderError{Message: fmt.Sprintf("key %q not valid: %v", jwk.KeyID, err)}
+	}
+	e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+	if err != nil || len(e) == 0 || len(e) > 4 {
+		return nil, &IdentityProviderError{Message: fmt.Sprintf("key %q has no valid exponent", jwk.KeyID)}
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}, nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+func unixTime(seconds float64) time.Time {
+	return time.Unix(0, int64(seconds*float64(time.Second)))
+}
+
+func tokenError(format string, args ...interface{}) error {
+	return &IdentityProviderError{Message: "ID token " + fmt.Sprintf(format, args...)}
+}
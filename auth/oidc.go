@@ -0,0 +1,350 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/phlashdev/recipe-keeper-api/core"
+)
+
+// OIDCConfig configures the login with an OpenID Connect provider. Users are signed in with the
+// authorization code flow and PKCE, they are added on their first login.
+type OIDCConfig struct {
+	// Name is shown on the login button, e.g. "Company account".
+	Name string
+	// Issuer is the URL of the provider, its endpoints are discovered from
+	// Issuer + "/.well-known/openid-configuration".
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is the callback URL registered at the provider.
+	RedirectURL string
+	Scopes      []string
+	// RoleClaim names the claim holding the groups or roles of a user, a dot separates nested
+	// claims like "realm_access.roles".
+	RoleClaim string
+	// Roles maps values of the role claim to roles, the highest matching role is given.
+	Roles map[string]string
+	// DefaultRole is given to users without a matching value, they are refused if it is empty.
+	DefaultRole string
+}
+
+// DefaultOIDCScopes request the claims used to name the users.
+var DefaultOIDCScopes = []string{"openid", "profile", "email"}
+
+// keysRefreshInterval limits how often the keys are fetched for an unknown key id.
+const keysRefreshInterval = time.Minute
+
+// OIDCProvider signs in users at an OpenID Connect provider. The endpoints are discovered on the
+// first login, so the server starts while the provider is down.
+type OIDCProvider struct {
+	config     OIDCConfig
+	httpClient *http.Client
+
+	mutex         sync.Mutex
+	discovery     *oidcDiscovery
+	keys          map[string]jsonWebKey
+	keysFetchedAt time.Time
+}
+
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type tokenResponse struct {
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+func NewOIDCProvider(config OIDCConfig, httpClient *http.Client) *OIDCProvider {
+	if len(config.Scopes) == 0 {
+		config.Scopes = DefaultOIDCScopes
+	}
+	config.Issuer = strings.TrimSuffix(config.Issuer, "/")
+
+	return &OIDCProvider{
+		config:     config,
+		httpClient: httpClient,
+	}
+}
+
+func (provider *OIDCProvider) Name() string {
+	return provider.config.Name
+}
+
+// OIDCState is kept by the browser while the user signs in at the provider. State protects the
+// callback from forged requests, Nonce binds the ID token to the login and Verifier is the PKCE
+// secret the code is redeemed with. Next is the page to return to.
+type OIDCState struct {
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+	Next     string `json:"next"`
+}
+
+func NewOIDCState(next string) (OIDCState, error) {
+	var values [3]string
+	for i := range values {
+		value, err := newToken()
+		if err != nil {
+			return OIDCState{}, err
+		}
+		values[i] = value
+	}
+
+	return OIDCState{State: values[0], Nonce: values[1], Verifier: values[2], Next: next}, nil
+}
+
+// AuthCodeURL returns the URL of the provider the user signs in at.
+func (provider *OIDCProvider) AuthCodeURL(ctx context.Context, state OIDCState) (string, error) {
+	discovery, err := provider.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	authURL, err := url.Parse(discovery.AuthorizationEndpoint)
+	if err != nil {
+		return "", &IdentityProviderError{Message: fmt.Sprintf("authorization endpoint not valid: %v", err)}
+	}
+
+	challenge := sha256.Sum256([]byte(state.Verifier))
+	query := authURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", provider.config.ClientID)
+	query.Set("redirect_uri", provider.config.RedirectURL)
+	query.Set("scope", strings.Join(provider.config.Scopes, " "))
+	query.Set("state", state.State)
+	query.Set("nonce", state.Nonce)
+	query.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	query.Set("code_challenge_method", "S256")
+	authURL.RawQuery = query.Encode()
+
+	return authURL.String(), nil
+}
+
+// Exchange redeems the code the provider returned with and returns the claims of the verified ID token.
+func (provider *OIDCProvider) Exchange(ctx context.Context, code string, state OIDCState) (IDTokenClaims, error) {
+	discovery, err := provider.discover(ctx)
+	if err != nil {
+		return IDTokenClaims{}, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {provider.config.RedirectURL},
+		"client_id":     {provider.config.ClientID},
+		"code_verifier": {state.Verifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return IDTokenClaims{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if len(provider.config.ClientSecret) > 0 {
+		// client_secret_basic, the credentials are form encoded first
+		req.SetBasicAuth(url.QueryEscape(provider.config.ClientID), url.QueryEscape(provider.config.ClientSecret))
+	}
+
+	var response tokenResponse
+	status, err := provider.do(req, &response)
+	if err != nil {
+		return IDTokenClaims{}, err
+	}
+	if status != http.StatusOK || len(response.Error) > 0 {
+		return IDTokenClaims{}, &IdentityProviderError{
+			Message: fmt.Sprintf("token request failed with status %d: %s %s", status, response.Error, response.ErrorDescription),
+		}
+	}
+	if len(response.IDToken) == 0 {
+		return IDTokenClaims{}, &IdentityProviderError{Message: "token response has no id_token"}
+	}
+
+	claims, err := provider.verifyIDToken(ctx, discovery, response.IDToken, time.Now())
+	if err != nil {
+		return IDTokenClaims{}, err
+	}
+	if claims.Nonce != state.Nonce {
+		return IDTokenClaims{}, &IdentityProviderError{Message: "ID token nonce does not match the login"}
+	}
+
+	return claims, nil
+}
+
+// Identity is the account of a user at an OpenID Connect provider.
+type Identity struct {
+	Issuer      string
+	Subject     string
+	Username    string
+	DisplayName string
+	Role        string
+}
+
+// Identity maps the claims to the user. The username is taken from preferred_username, email or
+// sub, the first one set. It returns a NoRoleError if no role is given to the user.
+func (provider *OIDCProvider) Identity(claims IDTokenClaims) (Identity, error) {
+	username := claims.PreferredUsername
+	if len(username) == 0 {
+		username = claims.Email
+	}
+	if len(username) == 0 {
+		username = claims.Subject
+	}
+	username = strings.Join(strings.Fields(username), "-")
+
+	role := provider.config.DefaultRole
+	for _, value := range claims.Strings(provider.config.RoleClaim) {
+		mapped, ok := provider.config.Roles[value]
+		if ok && (len(role) == 0 || core.User{Role: mapped}.HasRole(role)) {
+			role = mapped
+		}
+	}
+	if len(role) == 0 {
+		return Identity{}, &NoRoleError{Username: username}
+	}
+
+	return Identity{
+		Issuer:      claims.Issuer,
+		Subject:     claims.Subject,
+		Username:    username,
+		DisplayName: claims.Name,
+		Role:        role,
+	}, nil
+}
+
+// LoginWithIdentity starts a session of the user with the account at an OpenID Connect provider.
+// The user is added on the first login, the name and role are updated from the provider on every
+// login so changes there take effect.
+func (authenticator *Authenticator) LoginWithIdentity(ctx context.Context, identity Identity) (string, core.Session, core.User, error) {
+	user, err := authenticator.userRepository.GetUserBySubject(ctx, identity.Issuer, identity.Subject)
+	var notFoundErr *core.UserNotFoundError
+	switch {
+	case errors.As(err, &notFoundErr):
+		user, err = authenticator.addIdentityUser(ctx, identity)
+	case err == nil && (user.Role != identity.Role || user.DisplayName != identity.DisplayName):
+		user.Role = identity.Role
+		user.DisplayName = identity.DisplayName
+		err = authenticator.userRepository.UpdateUser(ctx, user)
+	}
+	if err != nil {
+		return "", core.Session{}, core.User{}, err
+	}
+
+	token, session, err := authenticator.StartSession(ctx, user)
+	if err != nil {
+		return "", core.Session{}, core.User{}, err
+	}
+
+	return token, session, user, nil
+}
+
+func (authenticator *Authenticator) addIdentityUser(ctx context.Context, identity Identity) (core.User, error) {
+	user := core.User{
+		Username:    identity.Username,
+		DisplayName: identity.DisplayName,
+		Issuer:      identity.Issuer,
+		Subject:     identity.Subject,
+		Role:        identity.Role,
+		CreatedAt:   time.Now().UTC().Truncate(time.Millisecond),
+	}
+	err := authenticator.userRepository.AddUser(ctx, &user)
+
+	// an existing user with the name is not taken over, the account gets a name of its own
+	var existsErr *core.UsernameExistsError
+	if errors.As(err, &existsErr) {
+		hash := sha256.Sum256([]byte(identity.Issuer + " " + identity.Subject))
+		user.Username = identity.Username + "-" + hex.EncodeToString(hash[:4])
+		err = authenticator.userRepository.AddUser(ctx, &user)
+	}
+
+	return user, err
+}
+
+// discover returns the endpoints of the provider, they are fetched once.
+func (provider *OIDCProvider) discover(ctx context.Context) (oidcDiscovery, error) {
+	provider.mutex.Lock()
+	defer provider.mutex.Unlock()
+
+	if provider.discovery != nil {
+		return *provider.discovery, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, provider.config.Issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return oidcDiscovery{}, err
+	}
+
+	var discovery oidcDiscovery
+	status, err := provider.do(req, &discovery)
+	if err != nil {
+		return oidcDiscovery{}, err
+	}
+	if status != http.StatusOK {
+		return oidcDiscovery{}, &IdentityProviderError{Message: fmt.Sprintf("discovery failed with status %d", status)}
+	}
+	// the issuer must match exactly, otherwise tokens of another issuer would be accepted
+	if strings.TrimSuffix(discovery.Issuer, "/") != provider.config.Issuer {
+		return oidcDiscovery{}, &IdentityProviderError{
+			Message: fmt.Sprintf("discovered issuer %q does not match %q", discovery.Issuer, provider.config.Issuer),
+		}
+	}
+	if len(discovery.AuthorizationEndpoint) == 0 || len(discovery.TokenEndpoint) == 0 || len(discovery.JWKSURI) == 0 {
+		return oidcDiscovery{}, &IdentityProviderError{Message: "discovery document misses endpoints"}
+	}
+
+	provider.discovery = &discovery
+	return discovery, nil
+}
+
+// do sends the request and decodes the JSON response into v, whatever the status.
+func (provider *OIDCProvider) do(req *http.Request, v interface{}) (int, error) {
+	res, err := provider.httpClient.Do(req)
+	if err != nil {
+		return 0, &IdentityProviderError{Message: err.Error()}
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return 0, &IdentityProviderError{Message: err.Error()}
+	}
+	if err := json.Unmarshal(body, v); err != nil && res.StatusCode == http.StatusOK {
+		return 0, &IdentityProviderError{Message: fmt.Sprintf("response of %s not valid: %v", req.URL, err)}
+	}
+
+	return res.StatusCode, nil
+}
+
+// IdentityProviderError is returned if the login at the OpenID Connect provider failed.
+type IdentityProviderError struct {
+	Message string
+}
+
+func (err *IdentityProviderError) Error() string {
+	return fmt.Sprintf("login with identity provider failed: %s", err.Message)
+}
+
+// NoRoleError is returned if the claims of a user map to no role and there is no default role.
+type NoRoleError struct {
+	Username string
+}
+
+func (err *NoRoleError) Error() string {
+	return fmt.Sprintf("user '%s' has no role in recipe keeper", err.Username)
+}
@@ -0,0 +1,440 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/phlashdev/recipe-keeper-api/core"
+)
+
+const (
+	testClientID     = "recipe-keeper"
+	testClientSecret = "secret with spaces & symbols"
+	testRedirectURL  = "http://recipes.example/login/oidc/callback"
+	testKeyID        = "key-1"
+)
+
+var (
+	testKeysOnce sync.Once
+	testKey      *rsa.PrivateKey
+	otherKey     *rsa.PrivateKey
+)
+
+// testKeys generates the keys once, it takes a while.
+func testKeys(t *testing.T) (*rsa.PrivateKey, *rsa.PrivateKey) {
+	testKeysOnce.Do(func() {
+		var err error
+		if testKey, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+			t.Fatal(err)
+		}
+		if otherKey, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	return testKey, otherKey
+}
+
+// mockProvider is an OpenID Connect provider serving discovery, keys, an authorization endpoint
+// redirecting back with a code and a token endpoint issuing an ID token for it. The token endpoint
+// checks the client credentials and the PKCE verifier like a real provider.
+type mockProvider struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+
+	// token changes the header and claims of the ID token before it is signed with sign
+	token func(header map[string]interface{}, claims map[string]interface{})
+	sign  func(signingInput string) []byte
+
+	mutex        sync.Mutex
+	authRequest  url.Values
+	tokenRequest url.Values
+}
+
+func newMockProvider(t *testing.T) *mockProvider {
+	key, _ := testKeys(t)
+	provider := &mockProvider{key: key}
+	provider.sign = provider.signRS256(key)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", provider.serveDiscovery)
+	mux.HandleFunc("/keys", provider.serveKeys)
+	mux.HandleFunc("/authorize", provider.serveAuthorize)
+	mux.HandleFunc("/token", provider.serveToken)
+	provider.Server = httptest.NewServer(mux)
+	t.Cleanup(provider.Close)
+
+	return provider
+}
+
+func (provider *mockProvider) serveDiscovery(w http.ResponseWriter, r *http.Request) {
+	writeTestJSON(w, http.StatusOK, map[string]string{
+		"issuer":                 provider.URL,
+		"authorization_endpoint": provider.URL + "/authorize",
+		"token_endpoint":         provider.URL + "/token",
+		"jwks_uri":               provider.URL + "/keys",
+	})
+}
+
+func (provider *mockProvider) serveKeys(w http.ResponseWriter, r *http.Request) {
+	writeTestJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": testKeyID,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(provider.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(provider.key.E)).Bytes()),
+		}},
+	})
+}
+
+func (provider *mockProvider) serveAuthorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	provider.mutex.Lock()
+	provider.authRequest = query
+	provider.mutex.Unlock()
+
+	redirect := query.Get("redirect_uri") + "?" + url.Values{"code": {"code-1"}, "state": {query.Get("state")}}.Encode()
+	http.Redirect(w, r, redirect, http.StatusFound)
+}
+
+func (provider *mockProvider) serveToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeTestJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+	provider.mutex.Lock()
+	provider.tokenRequest = r.PostForm
+	authRequest := provider.authRequest
+	provider.mutex.Unlock()
+
+	id, secret, ok := r.BasicAuth()
+	id, _ = url.QueryUnescape(id)
+	secret, _ = url.QueryUnescape(secret)
+	if !ok || id != testClientID || secret != testClientSecret {
+		writeTestJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+	challenge := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if r.PostForm.Get("code") != "code-1" ||
+		r.PostForm.Get("redirect_uri") != authRequest.Get("redirect_uri") ||
+		authRequest.Get("code_challenge_method") != "S256" ||
+		base64.RawURLEncoding.EncodeToString(challenge[:]) != authRequest.Get("code_challenge") {
+		writeTestJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	header := map[string]interface{}{"alg": "RS256", "typ": "JWT", "kid": testKeyID}
+	claims := map[string]interface{}{
+		"iss":                provider.URL,
+		"sub":                "248289761001",
+		"aud":                testClientID,
+		"exp":                now.Add(5 * time.Minute).Unix(),
+		"iat":                now.Unix(),
+		"nonce":              authRequest.Get("nonce"),
+		"preferred_username": "jane",
+		"name":               "Jane Doe",
+		"realm_access":       map[string]interface{}{"roles": []string{"offline_access", "cooks"}},
+	}
+	if provider.token != nil {
+		provider.token(header, claims)
+	}
+
+	signingInput := encodeTestSegment(header) + "." + encodeTestSegment(claims)
+	idToken := signingInput + "." + base64.RawURLEncoding.EncodeToString(provider.sign(signingInput))
+	writeTestJSON(w, http.StatusOK, map[string]string{"access_token": "access", "token_type": "Bearer", "id_token": idToken})
+}
+
+func (provider *mockProvider) signRS256(key *rsa.PrivateKey) func(signingInput string) []byte {
+	return func(signingInput string) []byte {
+		hash := sha256.Sum256([]byte(signingInput))
+		signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hash[:])
+		if err != nil {
+			panic(err)
+		}
+		return signature
+	}
+}
+
+// login signs in at the provider like a browser does and exchanges the code.
+func (provider *mockProvider) login(t *testing.T, oidcProvider *OIDCProvider, state OIDCState) (IDTokenClaims, error) {
+	t.Helper()
+
+	authURL, err := oidcProvider.AuthCodeURL(context.Background(), state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	browser := &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	res, err := browser.Get(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	callback, err := url.Parse(res.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if callback.Query().Get("state") != state.State {
+		t.Fatalf("provider returned state %q, want %q", callback.Query().Get("state"), state.State)
+	}
+
+	return oidcProvider.Exchange(context.Background(), callback.Query().Get("code"), state)
+}
+
+func newTestOIDCProvider(provider *mockProvider) *OIDCProvider {
+	return NewOIDCProvider(OIDCConfig{
+		Name:         "Company account",
+		Issuer:       provider.URL + "/",
+		ClientID:     testClientID,
+		ClientSecret: testClientSecret,
+		RedirectURL:  testRedirectURL,
+		RoleClaim:    "realm_access.roles",
+		Roles:        map[string]string{"cooks": core.RoleEditor, "chefs": core.RoleAdmin},
+	}, provider.Client())
+}
+
+func TestOIDCLogin(t *testing.T) {
+	provider := newMockProvider(t)
+	oidcProvider := newTestOIDCProvider(provider)
+	state, err := NewOIDCState("/recipes")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := provider.login(t, oidcProvider, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authRequest, tokenRequest := provider.authRequest, provider.tokenRequest
+	if authRequest.Get("nonce") != state.Nonce || authRequest.Get("client_id") != testClientID || authRequest.Get("scope") != "openid profile email" {
+		t.Errorf("authorization request = %v", authRequest)
+	}
+	if authRequest.Get("code_challenge") == state.Verifier || tokenRequest.Get("code_verifier") != state.Verifier {
+		t.Errorf("the verifier must only be sent to the token endpoint, got %v and %v", authRequest, tokenRequest)
+	}
+	if claims.Nonce != state.Nonce || claims.Issuer != provider.URL {
+		t.Errorf("claims = %+v", claims)
+	}
+
+	identity, err := oidcProvider.Identity(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Identity{Issuer: provider.URL, Subject: "248289761001", Username: "jane", DisplayName: "Jane Doe", Role: core.RoleEditor}
+	if identity != want {
+		t.Errorf("identity = %+v, want %+v", identity, want)
+	}
+}
+
+func TestOIDCExchangeRejectsTokens(t *testing.T) {
+	_, otherKey := testKeys(t)
+	tests := []struct {
+		name  string
+		want  string
+		setup func(provider *mockProvider)
+	}{
+		{"wrong issuer", "issued by", func(provider *mockProvider) {
+			provider.token = func(header, claims map[string]interface{}) { claims["iss"] = "https://attacker.example" }
+		}},
+		{"wrong audience", "not issued for this client", func(provider *mockProvider) {
+			provider.token = func(header, claims map[string]interface{}) { claims["aud"] = []string{"another-client"} }
+		}},
+		{"foreign authorized party", "authorized party", func(provider *mockProvider) {
+			provider.token = func(header, claims map[string]interface{}) {
+				claims["aud"] = []string{testClientID, "another-client"}
+				claims["azp"] = "another-client"
+			}
+		}},
+		{"expired", "expired", func(provider *mockProvider) {
+			provider.token = func(header, claims map[string]interface{}) {
+				claims["exp"] = time.Now().Add(-clockSkew - time.Second).Unix()
+			}
+		}},
+		{"issued in the future", "issued in the future", func(provider *mockProvider) {
+			provider.token = func(header, claims map[string]interface{}) {
+				claims["iat"] = time.Now().Add(clockSkew + time.Minute).Unix()
+			}
+		}},
+		{"nonce mismatch", "nonce does not match", func(provider *mockProvider) {
+			provider.token = func(header, claims map[string]interface{}) { claims["nonce"] = "another-nonce" }
+		}},
+		{"alg none", "algorithm \"none\"", func(provider *mockProvider) {
+			provider.token = func(header, claims map[string]interface{}) { header["alg"] = "none" }
+			provider.sign = func(signingInput string) []byte { return nil }
+		}},
+		{"alg HS256 with the public key as secret", "algorithm \"HS256\"", func(provider *mockProvider) {
+			provider.token = func(header, claims map[string]interface{}) { header["alg"] = "HS256" }
+			provider.sign = func(signingInput string) []byte {
+				mac := hmac.New(sha256.New, x509.MarshalPKCS1PublicKey(&provider.key.PublicKey))
+				mac.Write([]byte(signingInput))
+				return mac.Sum(nil)
+			}
+		}},
+		{"unknown key id", "signing key \"key-2\" unknown", func(provider *mockProvider) {
+			provider.token = func(header, claims map[string]interface{}) { header["kid"] = "key-2" }
+			provider.sign = provider.signRS256(otherKey)
+		}},
+		{"bad signature", "signature not valid", func(provider *mockProvider) {
+			provider.sign = provider.signRS256(otherKey)
+		}},
+		{"changed claims", "signature not valid", func(provider *mockProvider) {
+			sign := provider.sign
+			provider.sign = func(signingInput string) []byte {
+				return sign(strings.Replace(signingInput, ".", ".x", 1))
+			}
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			provider := newMockProvider(t)
+			test.setup(provider)
+			state, err := NewOIDCState("/")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = provider.login(t, newTestOIDCProvider(provider), state)
+
+			var providerErr *IdentityProviderError
+			if !errors.As(err, &providerErr) || !strings.Contains(providerErr.Message, test.want) {
+				t.Errorf("err = %v, want an IdentityProviderError with %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestOIDCExchangeRejectsWrongVerifier(t *testing.T) {
+	provider := newMockProvider(t)
+	oidcProvider := newTestOIDCProvider(provider)
+	state, err := NewOIDCState("/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := oidcProvider.AuthCodeURL(context.Background(), state); err != nil {
+		t.Fatal(err)
+	}
+	provider.authRequest = url.Values{"redirect_uri": {testRedirectURL}, "code_challenge_method": {"S256"}, "code_challenge": {"another-challenge"}}
+
+	_, err = oidcProvider.Exchange(context.Background(), "code-1", state)
+
+	var providerErr *IdentityProviderError
+	if !errors.As(err, &providerErr) || !strings.Contains(err.Error(), "invalid_grant") {
+		t.Errorf("err = %v, want the invalid_grant of the provider", err)
+	}
+}
+
+func TestOIDCIdentityRoles(t *testing.T) {
+	tests := []struct {
+		name        string
+		roles       interface{}
+		defaultRole string
+		want        string
+	}{
+		{"mapped", []interface{}{"offline_access", "cooks"}, "", core.RoleEditor},
+		{"highest of several", []interface{}{"chefs", "cooks"}, "", core.RoleAdmin},
+		{"single value", "chefs", "", core.RoleAdmin},
+		{"default", []interface{}{"offline_access"}, core.RoleViewer, core.RoleViewer},
+		{"above default", []interface{}{"cooks"}, core.RoleViewer, core.RoleEditor},
+		{"none", []interface{}{"offline_access"}, "", ""},
+		{"claim missing", nil, "", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			provider := NewOIDCProvider(OIDCConfig{
+				RoleClaim:   "realm_access.roles",
+				Roles:       map[string]string{"cooks": core.RoleEditor, "chefs": core.RoleAdmin},
+				DefaultRole: test.defaultRole,
+			}, http.DefaultClient)
+			claims := IDTokenClaims{
+				Subject: "248289761001",
+				Email:   "jane@example.com",
+				raw:     map[string]interface{}{"realm_access": map[string]interface{}{"roles": test.roles}},
+			}
+
+			identity, err := provider.Identity(claims)
+
+			var noRoleErr *NoRoleError
+			switch {
+			case len(test.want) == 0 && !errors.As(err, &noRoleErr):
+				t.Errorf("err = %v, want a NoRoleError", err)
+			case len(test.want) > 0 && (err != nil || identity.Role != test.want):
+				t.Errorf("Identity = %+v, %v, want role %q", identity, err, test.want)
+			case err == nil && identity.Username != "jane@example.com":
+				t.Errorf("username = %q, want the email as there is no preferred_username", identity.Username)
+			}
+		})
+	}
+}
+
+func TestLoginWithIdentity(t *testing.T) {
+	users := &memoryUserRepository{}
+	authenticator := NewAuthenticator(users, &memorySessionRepository{sessions: map[string]core.Session{}}, time.Hour)
+	local := core.User{Username: "jane", Role: core.RoleAdmin}
+	if err := users.AddUser(context.Background(), &local); err != nil {
+		t.Fatal(err)
+	}
+	identity := Identity{Issuer: "https://id.example", Subject: "248289761001", Username: "jane", DisplayName: "Jane Doe", Role: core.RoleViewer}
+
+	token, _, user, err := authenticator.LoginWithIdentity(context.Background(), identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the local user with the same name is not taken over
+	if user.ID == local.ID || !strings.HasPrefix(user.Username, "jane-") || user.Role != core.RoleViewer {
+		t.Errorf("user = %+v", user)
+	}
+	if authenticated, err := authenticator.Authenticate(context.Background(), token); err != nil || authenticated.ID != user.ID {
+		t.Errorf("Authenticate = %+v, %v", authenticated, err)
+	}
+
+	identity.Role = core.RoleEditor
+	_, _, again, err := authenticator.LoginWithIdentity(context.Background(), identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.ID != user.ID || again.Username != user.Username || again.Role != core.RoleEditor {
+		t.Errorf("second login = %+v, want %+v with the new role", again, user)
+	}
+	if stored, _ := users.GetUsers(context.Background()); len(stored) != 2 {
+		t.Errorf("stored %d users, want 2", len(stored))
+	}
+}
+
+func encodeTestSegment(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func writeTestJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
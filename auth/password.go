@@ -0,0 +1,48 @@
+// Package auth signs in users and keeps their sessions. Users sign in with a password hashed with
+// bcrypt or at an OpenID Connect provider, a login issues a random session token that is sent as
+// bearer token or cookie with every request.
+package auth
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/phlashdev/recipe-keeper-api/core"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// MinPasswordLength is the minimum number of characters of a password.
+const MinPasswordLength = 8
+
+// maxPasswordBytes is the limit of bcrypt, longer passwords would be truncated silently.
+const maxPasswordBytes = 72
+
+// HashPassword returns the bcrypt hash of the password, or a *core.ValidationError if the password
+// is too short or too long.
+func HashPassword(password string) (string, error) {
+	if utf8.RuneCountInString(password) < MinPasswordLength {
+		return "", passwordError("must be at least %d characters", MinPasswordLength)
+	}
+	if len(password) > maxPasswordBytes {
+		return "", passwordError("must not be longer than %d bytes", maxPasswordBytes)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(hash), nil
+}
+
+// CheckPassword tells whether the password matches the hash.
+func CheckPassword(hash string, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+func passwordError(format string, args ...interface{}) error {
+	return &core.ValidationError{Errors: []core.FieldError{{
+		Field:   "password",
+		Message: fmt.Sprintf(format, args...),
+	}}}
+}
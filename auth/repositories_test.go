@@ -0,0 +1,132 @@
+package auth
+
+import (
+	"context"
+	"sync"
+
+	"github.com/phlashdev/recipe-keeper-api/core"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryUserRepository keeps the users in memory like the mongo repository stores them.
+type memoryUserRepository struct {
+	mutex sync.Mutex
+	users []core.User
+}
+
+func (repo *memoryUserRepository) GetUsers(ctx context.Context) ([]core.User, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	return append([]core.User{}, repo.users...), nil
+}
+
+func (repo *memoryUserRepository) GetUserByID(ctx context.Context, id string) (core.User, error) {
+	return repo.findUser(id, func(user core.User) bool { return user.ID.Hex() == id })
+}
+
+func (repo *memoryUserRepository) GetUserByUsername(ctx context.Context, username string) (core.User, error) {
+	return repo.findUser(username, func(user core.User) bool { return user.Username == username })
+}
+
+func (repo *memoryUserRepository) GetUserBySubject(ctx context.Context, issuer string, subject string) (core.User, error) {
+	return repo.findUser(subject, func(user core.User) bool { return user.Issuer == issuer && user.Subject == subject })
+}
+
+func (repo *memoryUserRepository) findUser(id string, match func(user core.User) bool) (core.User, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	for _, user := range repo.users {
+		if match(user) {
+			return user, nil
+		}
+	}
+
+	return core.User{}, &core.UserNotFoundError{ID: id}
+}
+
+func (repo *memoryUserRepository) AddUser(ctx context.Context, user *core.User) error {
+	if err := core.ValidateUser(*user); err != nil {
+		return err
+	}
+
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	for _, stored := range repo.users {
+		if stored.Username == user.Username {
+			return &core.UsernameExistsError{Username: user.Username}
+		}
+	}
+	user.ID = primitive.NewObjectID()
+	repo.users = append(repo.users, *user)
+
+	return nil
+}
+
+func (repo *memoryUserRepository) UpdateUser(ctx context.Context, user core.User) error {
+	if err := core.ValidateUser(user); err != nil {
+		return err
+	}
+
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	for i, stored := range repo.users {
+		if stored.ID == user.ID {
+			repo.users[i] = user
+			return nil
+		}
+	}
+
+	return &core.UserNotFoundError{ID: user.ID.Hex()}
+}
+
+type memorySessionRepository struct {
+	mutex    sync.Mutex
+	sessions map[string]core.Session
+}
+
+func (repo *memorySessionRepository) GetSession(ctx context.Context, tokenHash string) (core.Session, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	session, ok := repo.sessions[tokenHash]
+	if !ok {
+		return core.Session{}, &core.SessionNotFoundError{}
+	}
+
+	return session, nil
+}
+
+func (repo *memorySessionRepository) AddSession(ctx context.Context, session core.Session) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	repo.sessions[session.TokenHash] = session
+
+	return nil
+}
+
+func (repo *memorySessionRepository) DeleteSession(ctx context.Context, tokenHash string) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	delete(repo.sessions, tokenHash)
+
+	return nil
+}
+
+func (repo *memorySessionRepository) DeleteUserSessions(ctx context.Context, userID primitive.ObjectID) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	for tokenHash, session := range repo.sessions {
+		if session.UserID == userID {
+			delete(repo.sessions, tokenHash)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,158 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/phlashdev/recipe-keeper-api/core"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// DefaultSessionLifetime is how long a login lasts.
+const DefaultSessionLifetime = 30 * 24 * time.Hour
+
+// dummyHash is compared for unknown usernames, so a login takes as long as one with a wrong password.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("not a password"), bcrypt.DefaultCost)
+
+type Authenticator struct {
+	userRepository    core.UserRepository
+	sessionRepository core.SessionRepository
+	sessionLifetime   time.Duration
+}
+
+func NewAuthenticator(userRepository core.UserRepository, sessionRepository core.SessionRepository, sessionLifetime time.Duration) *Authenticator {
+	return &Authenticator{
+		userRepository:    userRepository,
+		sessionRepository: sessionRepository,
+		sessionLifetime:   sessionLifetime,
+	}
+}
+
+// Login checks the password of the user and starts a session. It returns an
+// InvalidCredentialsError without telling whether the username or the password was wrong.
+func (authenticator *Authenticator) Login(ctx context.Context, username string, password string) (string, core.Session, core.User, error) {
+	user, err := authenticator.userRepository.GetUserByUsername(ctx, username)
+	var notFoundErr *core.UserNotFoundError
+	if errors.As(err, &notFoundErr) {
+		CheckPassword(string(dummyHash), password)
+		return "", core.Session{}, core.User{}, &InvalidCredentialsError{}
+	}
+	if err != nil {
+		return "", core.Session{}, core.User{}, err
+	}
+
+	// users of an identity provider have no password
+	if len(user.PasswordHash) == 0 || !CheckPassword(user.PasswordHash, password) {
+		return "", core.Session{}, core.User{}, &InvalidCredentialsError{}
+	}
+
+	token, session, err := authenticator.StartSession(ctx, user)
+	if err != nil {
+		return "", core.Session{}, core.User{}, err
+	}
+
+	return token, session, user, nil
+}
+
+// StartSession starts a session of the user, who was authenticated by the caller, and returns its token.
+func (authenticator *Authenticator) StartSession(ctx context.Context, user core.User) (string, core.Session, error) {
+	token, err := newToken()
+	if err != nil {
+		return "", core.Session{}, err
+	}
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	session := core.Session{
+		TokenHash: hashToken(token),
+		UserID:    user.ID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(authenticator.sessionLifetime),
+	}
+	if err := authenticator.sessionRepository.AddSession(ctx, session); err != nil {
+		return "", core.Session{}, err
+	}
+
+	return token, session, nil
+}
+
+// Authenticate returns the user of the session with the token, or a NotAuthenticatedError if the
+// token is unknown or the session expired.
+func (authenticator *Authenticator) Authenticate(ctx context.Context, token string) (core.User, error) {
+	if len(token) == 0 {
+		return core.User{}, &NotAuthenticatedError{}
+	}
+
+	session, err := authenticator.sessionRepository.GetSession(ctx, hashToken(token))
+	var sessionNotFoundErr *core.SessionNotFoundError
+	if errors.As(err, &sessionNotFoundErr) {
+		return core.User{}, &NotAuthenticatedError{}
+	}
+	if err != nil {
+		return core.User{}, err
+	}
+	// expired sessions are removed by the repository with some delay
+	if !time.Now().Before(session.ExpiresAt) {
+		return core.User{}, &NotAuthenticatedError{}
+	}
+
+	user, err := authenticator.userRepository.GetUserByID(ctx, session.UserID.Hex())
+	var userNotFoundErr *core.UserNotFoundError
+	if errors.As(err, &userNotFoundErr) {
+		return core.User{}, &NotAuthenticatedError{}
+	}
+	if err != nil {
+		return core.User{}, err
+	}
+
+	return user, nil
+}
+
+// Logout ends the session with the token.
+func (authenticator *Authenticator) Logout(ctx context.Context, token string) error {
+	if len(token) == 0 {
+		return nil
+	}
+
+	return authenticator.sessionRepository.DeleteSession(ctx, hashToken(token))
+}
+
+func newToken() (string, error) {
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return "", fmt.Errorf("error while creating session token: %v", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(token), nil
+}
+
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+type InvalidCredentialsError struct{}
+
+func (err *InvalidCredentialsError) Error() string {
+	return "username or password not valid"
+}
+
+type NotAuthenticatedError struct{}
+
+func (err *NotAuthenticatedError) Error() string {
+	return "not logged in or session expired"
+}
+
+// ForbiddenError is returned if the user lacks the role required for a request.
+type ForbiddenError struct {
+	Role string
+}
+
+func (err *ForbiddenError) Error() string {
+	return fmt.Sprintf("the role %s is required", err.Role)
+}
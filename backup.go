@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/phlashdev/recipe-keeper-api/backup"
+	"github.com/phlashdev/recipe-keeper-api/core"
+)
+
+func backupDatabase(args []string, recipeRepository core.RecipeRepository, sourceRepository core.SourceRepository) error {
+	flags := flag.NewFlagSet("backup", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: recipe-keeper backup <file>")
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("missing file")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	archive, err := backup.Create(ctx, recipeRepository, sourceRepository)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if err := backup.Write(file, archive); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	log.Printf("backed up %d recipes and %d sources to %s", archive.Manifest.Recipes, archive.Manifest.Sources, flags.Arg(0))
+	return nil
+}
+
+func restoreDatabase(args []string, recipeRepository core.RecipeRepository, sourceRepository core.SourceRepository) error {
+	flags := flag.NewFlagSet("restore", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: recipe-keeper restore <file>")
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("missing file")
+	}
+
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	archive, err := backup.Read(file, info.Size())
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	if err := backup.Restore(ctx, archive, recipeRepository, sourceRepository); err != nil {
+		return err
+	}
+
+	log.Printf("restored %d recipes and %d sources from backup of %s", len(archive.Recipes), len(archive.Sources), archive.Manifest.CreatedAt.Format(time.RFC3339))
+	return nil
+}
@@ -0,0 +1,239 @@
+package backup
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"github.com/phlashdev/recipe-keeper-api/core"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// FormatVersion is increased whenever the content of the archive changes incompatibly. Version 2
+// added the versions of recipes and sources, version 3 the trash. Older archives are still read.
+const FormatVersion = 3
+
+const (
+	manifestFileName = "manifest.json"
+	recipesFileName  = "recipes.json"
+	sourcesFileName  = "sources.json"
+)
+
+type Manifest struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"createdAt"`
+	Recipes   int       `json:"recipes"`
+	Sources   int       `json:"sources"`
+}
+
+type Archive struct {
+	Manifest Manifest
+	Recipes  []core.Recipe
+	Sources  []core.Source
+}
+
+type VersionNotSupportedError struct {
+	Version int
+}
+
+func (err *VersionNotSupportedError) Error() string {
+	return fmt.Sprintf("backup version %d not supported", err.Version)
+}
+
+type ArchiveNotValidError struct {
+	Reason string
+}
+
+func (err *ArchiveNotValidError) Error() string {
+	return fmt.Sprintf("backup archive not valid: %s", err.Reason)
+}
+
+// Create reads all recipes and sources from the repositories, including the ones in the trash.
+// They keep their deletion time, so a restore puts them back into the trash.
+func Create(ctx context.Context, recipeRepository core.RecipeRepository, sourceRepository core.SourceRepository) (Archive, error) {
+	recipes, err := recipeRepository.GetRecipes(ctx, core.RecipeFilter{})
+	if err != nil {
+		return Archive{}, err
+	}
+
+	deletedRecipes, err := recipeRepository.GetDeletedRecipes(ctx)
+	if err != nil {
+		return Archive{}, err
+	}
+	recipes = append(recipes, deletedRecipes...)
+
+	sources, err := sourceRepository.GetSources(ctx)
+	if err != nil {
+		return Archive{}, err
+	}
+
+	deletedSources, err := sourceRepository.GetDeletedSources(ctx)
+	if err != nil {
+		return Archive{}, err
+	}
+	sources = append(sources, deletedSources...)
+
+	return Archive{
+		Manifest: Manifest{
+			Version:   FormatVersion,
+			CreatedAt: time.Now().UTC(),
+			Recipes:   len(recipes),
+			Sources:   len(sources),
+		},
+		Recipes: recipes,
+		Sources: sources,
+	}, nil
+}
+
+// Restore writes the archive to the repositories keeping the IDs, so references between recipes and
+// sources stay intact. Existing entries with the same ID are replaced, their version is increased
+// so clients holding an ETag of the replaced entry notice the change.
+func Restore(ctx context.Context, archive Archive, recipeRepository core.RecipeRepository, sourceRepository core.SourceRepository) error {
+	if err := sourceRepository.ImportSources(ctx, archive.Sources); err != nil {
+		return err
+	}
+
+	return recipeRepository.ImportRecipes(ctx, archive.Recipes)
+}
+
+// Write stores the archive as zip file.
+func Write(w io.Writer, archive Archive) error {
+	zipWriter := zip.NewWriter(w)
+
+	recipes := make([]recipeRecord, 0, len(archive.Recipes))
+	for _, recipe := range archive.Recipes {
+		recipes = append(recipes, newRecipeRecord(recipe))
+	}
+
+	sources := make([]sourceRecord, 0, len(archive.Sources))
+	for _, source := range archive.Sources {
+		sources = append(sources, newSourceRecord(source))
+	}
+
+	files := []struct {
+		name    string
+		content interface{}
+	}{
+		{manifestFileName, archive.Manifest},
+		{sourcesFileName, sources},
+		{recipesFileName, recipes},
+	}
+	for _, file := range files {
+		writer, err := zipWriter.Create(file.name)
+		if err != nil {
+			return fmt.Errorf("error while writing backup: %v", err)
+		}
+
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.content); err != nil {
+			return fmt.Errorf("error while writing backup: %v", err)
+		}
+	}
+
+	if err := zipWriter.Close(); err != nil {
+		return fmt.Errorf("error while writing backup: %v", err)
+	}
+
+	return nil
+}
+
+// Read loads an archive written by Write.
+func Read(r io.ReaderAt, size int64) (Archive, error) {
+	zipReader, err := zip.NewReader(r, size)
+	if err != nil {
+		return Archive{}, &ArchiveNotValidError{Reason: err.Error()}
+	}
+
+	var archive Archive
+	if err := readFile(zipReader, manifestFileName, &archive.Manifest); err != nil {
+		return Archive{}, err
+	}
+	if archive.Manifest.Version < 1 || archive.Manifest.Version > FormatVersion {
+		return Archive{}, &VersionNotSupportedError{Version: archive.Manifest.Version}
+	}
+
+	var sources []sourceRecord
+	if err := readFile(zipReader, sourcesFileName, &sources); err != nil {
+		return Archive{}, err
+	}
+	for _, record := range sources {
+		source, err := record.toSource()
+		if err != nil {
+			return Archive{}, err
+		}
+		archive.Sources = append(archive.Sources, source)
+	}
+
+	var recipes []recipeRecord
+	if err := readFile(zipReader, recipesFileName, &recipes); err != nil {
+		return Archive{}, err
+	}
+	for _, record := range recipes {
+		recipe, err := record.toRecipe()
+		if err != nil {
+			return Archive{}, err
+		}
+		archive.Recipes = append(archive.Recipes, recipe)
+	}
+
+	return archive, nil
+}
+
+func readFile(zipReader *zip.Reader, name string, content interface{}) error {
+	for _, file := range zipReader.File {
+		if file.Name != name {
+			continue
+		}
+
+		reader, err := file.Open()
+		if err != nil {
+			return &ArchiveNotValidError{Reason: err.Error()}
+		}
+		defer reader.Close()
+
+		data, err := ioutil.ReadAll(reader)
+		if err != nil {
+			return &ArchiveNotValidError{Reason: err.Error()}
+		}
+		if err := json.Unmarshal(data, content); err != nil {
+			return &ArchiveNotValidError{Reason: fmt.Sprintf("%s: %v", name, err)}
+		}
+		return nil
+	}
+
+	return &ArchiveNotValidError{Reason: fmt.Sprintf("%s missing", name)}
+}
+
+func parseObjectID(id string) (primitive.ObjectID, error) {
+	if len(id) == 0 {
+		return primitive.NilObjectID, nil
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return primitive.NilObjectID, &ArchiveNotValidError{Reason: fmt.Sprintf("id '%s' not valid", id)}
+	}
+
+	return objectID, nil
+}
+
+func parseRequiredObjectID(id string) (primitive.ObjectID, error) {
+	if len(id) == 0 {
+		return primitive.NilObjectID, &ArchiveNotValidError{Reason: "id missing"}
+	}
+
+	return parseObjectID(id)
+}
+
+func hexOrEmpty(id primitive.ObjectID) string {
+	if id.IsZero() {
+		return ""
+	}
+
+	return id.Hex()
+}
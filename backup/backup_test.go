@@ -0,0 +1,187 @@
+package backup
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/phlashdev/recipe-keeper-api/core"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestWriteReadKeepsVersions(t *testing.T) {
+	source := core.Source{ID: primitive.NewObjectID(), Version: 3, Type: core.SourceTypeBook, Title: "Basics"}
+	recipe := core.Recipe{
+		ID:      primitive.NewObjectID(),
+		Version: 7,
+		Title:   "Bread",
+		Source:  source.ID,
+		Steps: []core.Step{
+			{Text: "Bake", Timers: []core.Timer{{Name: "Bake", Duration: 40 * time.Minute}}},
+		},
+	}
+	archive := Archive{
+		Manifest: Manifest{Version: FormatVersion, Recipes: 1, Sources: 1},
+		Recipes:  []core.Recipe{recipe},
+		Sources:  []core.Source{source},
+	}
+
+	var buffer bytes.Buffer
+	if err := Write(&buffer, archive); err != nil {
+		t.Fatal(err)
+	}
+	read, err := Read(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(read.Recipes) != 1 || read.Recipes[0].Version != 7 || read.Recipes[0].Source != source.ID {
+		t.Errorf("recipes = %+v, want version 7 with source %s", read.Recipes, source.ID.Hex())
+	}
+	if len(read.Recipes[0].Steps) != 1 || read.Recipes[0].Steps[0].Timers[0].Duration != 40*time.Minute {
+		t.Errorf("steps = %+v, want the bake timer", read.Recipes[0].Steps)
+	}
+	if len(read.Sources) != 1 || read.Sources[0].Version != 3 {
+		t.Errorf("sources = %+v, want version 3", read.Sources)
+	}
+}
+
+// trashRecipeRepository returns the recipes in and out of the trash, the other methods are not used.
+type trashRecipeRepository struct {
+	core.RecipeRepository
+	recipes []core.Recipe
+}
+
+func (repo *trashRecipeRepository) GetRecipes(ctx context.Context, filter core.RecipeFilter) ([]core.Recipe, error) {
+	var recipes []core.Recipe
+	for _, recipe := range repo.recipes {
+		if recipe.DeletedAt.IsZero() {
+			recipes = append(recipes, recipe)
+		}
+	}
+	return recipes, nil
+}
+
+func (repo *trashRecipeRepository) GetDeletedRecipes(ctx context.Context) ([]core.Recipe, error) {
+	var recipes []core.Recipe
+	for _, recipe := range repo.recipes {
+		if !recipe.DeletedAt.IsZero() {
+			recipes = append(recipes, recipe)
+		}
+	}
+	return recipes, nil
+}
+
+type trashSourceRepository struct {
+	core.SourceRepository
+	sources []core.Source
+}
+
+func (repo *trashSourceRepository) GetSources(ctx context.Context) ([]core.Source, error) {
+	var sources []core.Source
+	for _, source := range repo.sources {
+		if source.DeletedAt.IsZero() {
+			sources = append(sources, source)
+		}
+	}
+	return sources, nil
+}
+
+func (repo *trashSourceRepository) GetDeletedSources(ctx context.Context) ([]core.Source, error) {
+	var sources []core.Source
+	for _, source := range repo.sources {
+		if !source.DeletedAt.IsZero() {
+			sources = append(sources, source)
+		}
+	}
+	return sources, nil
+}
+
+func TestCreateKeepsTrash(t *testing.T) {
+	deletedAt := time.Date(2021, 3, 14, 9, 30, 0, 0, time.UTC)
+	recipes := &trashRecipeRepository{recipes: []core.Recipe{
+		{ID: primitive.NewObjectID(), Title: "Bread"},
+		{ID: primitive.NewObjectID(), Title: "Soup", DeletedAt: deletedAt},
+	}}
+	sources := &trashSourceRepository{sources: []core.Source{
+		{ID: primitive.NewObjectID(), Title: "Basics", DeletedAt: deletedAt},
+	}}
+
+	archive, err := Create(context.Background(), recipes, sources)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buffer bytes.Buffer
+	if err := Write(&buffer, archive); err != nil {
+		t.Fatal(err)
+	}
+	read, err := Read(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if read.Manifest.Recipes != 2 || read.Manifest.Sources != 1 {
+		t.Errorf("manifest = %+v, want 2 recipes and 1 source", read.Manifest)
+	}
+	deleted := map[string]time.Time{}
+	for _, recipe := range read.Recipes {
+		deleted[recipe.Title] = recipe.DeletedAt
+	}
+	if len(read.Recipes) != 2 || !deleted["Bread"].IsZero() || !deleted["Soup"].Equal(deletedAt) {
+		t.Errorf("recipes deleted at %v, want only Soup in the trash since %v", deleted, deletedAt)
+	}
+	if len(read.Sources) != 1 || !read.Sources[0].DeletedAt.Equal(deletedAt) {
+		t.Errorf("sources = %+v, want Basics in the trash since %v", read.Sources, deletedAt)
+	}
+}
+
+func TestReadVersion1Archive(t *testing.T) {
+	id := primitive.NewObjectID().Hex()
+	archive := writeZip(t, map[string]string{
+		manifestFileName: `{"version": 1, "recipes": 1, "sources": 0}`,
+		sourcesFileName:  `[]`,
+		recipesFileName:  `[{"id": "` + id + `", "title": "Soup"}]`,
+	})
+
+	read, err := Read(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(read.Recipes) != 1 || read.Recipes[0].Title != "Soup" || read.Recipes[0].Version != 0 {
+		t.Errorf("recipes = %+v, want Soup with version 0", read.Recipes)
+	}
+}
+
+func TestReadNewerVersion(t *testing.T) {
+	archive := writeZip(t, map[string]string{
+		manifestFileName: `{"version": 99}`,
+	})
+
+	_, err := Read(bytes.NewReader(archive), int64(len(archive)))
+	if _, ok := err.(*VersionNotSupportedError); !ok {
+		t.Errorf("err = %v, want VersionNotSupportedError", err)
+	}
+}
+
+func writeZip(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	var buffer bytes.Buffer
+	zipWriter := zip.NewWriter(&buffer)
+	for name, content := range files {
+		writer, err := zipWriter.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := writer.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zipWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buffer.Bytes()
+}
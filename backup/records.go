@@ -0,0 +1,184 @@
+package backup
+
+import (
+	"time"
+
+	"github.com/phlashdev/recipe-keeper-api/core"
+)
+
+// The records define the archive format independently of the storage models, changes here
+// require a new FormatVersion.
+
+// Version was added in format version 2, it is 0 in archives of version 1. DeletedAt was added
+// in format version 3 and is only set for entries in the trash.
+type sourceRecord struct {
+	ID        string     `json:"id"`
+	Version   int        `json:"version,omitempty"`
+	Type      string     `json:"type"`
+	Title     string     `json:"title"`
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
+}
+
+type recipeRecord struct {
+	ID               string             `json:"id"`
+	Version          int                `json:"version,omitempty"`
+	Title            string             `json:"title"`
+	SourceID         string             `json:"sourceId,omitempty"`
+	SourceAnnotation string             `json:"sourceAnnotation,omitempty"`
+	Category         string             `json:"category,omitempty"`
+	Allergens        []string           `json:"allergens,omitempty"`
+	Tags             []string           `json:"tags,omitempty"`
+	Servings         int                `json:"servings,omitempty"`
+	Ingredients      []ingredientRecord `json:"ingredients,omitempty"`
+	Cookware         []string           `json:"cookware,omitempty"`
+	Steps            []stepRecord       `json:"steps,omitempty"`
+	DeletedAt        *time.Time         `json:"deletedAt,omitempty"`
+}
+
+type ingredientRecord struct {
+	Name     string  `json:"name"`
+	Quantity float64 `json:"quantity,omitempty"`
+	Unit     string  `json:"unit,omitempty"`
+}
+
+type stepRecord struct {
+	Text        string        `json:"text"`
+	Ingredients []string      `json:"ingredients,omitempty"`
+	Timers      []timerRecord `json:"timers,omitempty"`
+}
+
+type timerRecord struct {
+	Name            string `json:"name,omitempty"`
+	DurationSeconds int64  `json:"durationSeconds"`
+}
+
+func newSourceRecord(source core.Source) sourceRecord {
+	return sourceRecord{
+		ID:        hexOrEmpty(source.ID),
+		Version:   source.Version,
+		Type:      source.Type,
+		Title:     source.Title,
+		DeletedAt: timeOrNil(source.DeletedAt),
+	}
+}
+
+func (record sourceRecord) toSource() (core.Source, error) {
+	id, err := parseRequiredObjectID(record.ID)
+	if err != nil {
+		return core.Source{}, err
+	}
+
+	return core.Source{
+		ID:        id,
+		Version:   record.Version,
+		Type:      record.Type,
+		Title:     record.Title,
+		DeletedAt: timeOrZero(record.DeletedAt),
+	}, nil
+}
+
+func newRecipeRecord(recipe core.Recipe) recipeRecord {
+	record := recipeRecord{
+		ID:               hexOrEmpty(recipe.ID),
+		Version:          recipe.Version,
+		Title:            recipe.Title,
+		SourceID:         hexOrEmpty(recipe.Source),
+		SourceAnnotation: recipe.SourceAnnotation,
+		Category:         recipe.Category,
+		Allergens:        recipe.Allergens,
+		Tags:             recipe.Tags,
+		Servings:         recipe.Servings,
+		Cookware:         recipe.Cookware,
+		DeletedAt:        timeOrNil(recipe.DeletedAt),
+	}
+
+	for _, ingredient := range recipe.Ingredients {
+		record.Ingredients = append(record.Ingredients, ingredientRecord{
+			Name:     ingredient.Name,
+			Quantity: ingredient.Quantity,
+			Unit:     ingredient.Unit,
+		})
+	}
+
+	for _, step := range recipe.Steps {
+		stepRecord := stepRecord{
+			Text:        step.Text,
+			Ingredients: step.Ingredients,
+		}
+		for _, timer := range step.Timers {
+			stepRecord.Timers = append(stepRecord.Timers, timerRecord{
+				Name:            timer.Name,
+				DurationSeconds: int64(timer.Duration / time.Second),
+			})
+		}
+		record.Steps = append(record.Steps, stepRecord)
+	}
+
+	return record
+}
+
+func (record recipeRecord) toRecipe() (core.Recipe, error) {
+	id, err := parseRequiredObjectID(record.ID)
+	if err != nil {
+		return core.Recipe{}, err
+	}
+
+	sourceID, err := parseObjectID(record.SourceID)
+	if err != nil {
+		return core.Recipe{}, err
+	}
+
+	recipe := core.Recipe{
+		ID:               id,
+		Version:          record.Version,
+		Title:            record.Title,
+		Source:           sourceID,
+		SourceAnnotation: record.SourceAnnotation,
+		Category:         record.Category,
+		Allergens:        record.Allergens,
+		Tags:             record.Tags,
+		Servings:         record.Servings,
+		Cookware:         record.Cookware,
+		DeletedAt:        timeOrZero(record.DeletedAt),
+	}
+
+	for _, ingredient := range record.Ingredients {
+		recipe.Ingredients = append(recipe.Ingredients, core.Ingredient{
+			Name:     ingredient.Name,
+			Quantity: ingredient.Quantity,
+			Unit:     ingredient.Unit,
+		})
+	}
+
+	for _, stepRecord := range record.Steps {
+		step := core.Step{
+			Text:        stepRecord.Text,
+			Ingredients: stepRecord.Ingredients,
+		}
+		for _, timer := range stepRecord.Timers {
+			step.Timers = append(step.Timers, core.Timer{
+				Name:     timer.Name,
+				Duration: time.Duration(timer.DurationSeconds) * time.Second,
+			})
+		}
+		recipe.Steps = append(recipe.Steps, step)
+	}
+
+	return recipe, nil
+}
+
+func timeOrNil(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	return &t
+}
+
+func timeOrZero(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+
+	return t.UTC()
+}
@@ -0,0 +1,282 @@
+// Package client is a typed client for the recipe keeper API. Failed requests are returned as
+// errors of package core where the API reports one, like *core.RecipeNotFoundError or
+// *core.ValidationError, and as *Error otherwise.
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	mathrand "math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultRetries    = 3
+	defaultBackoff    = 200 * time.Millisecond
+	maxBackoff        = 5 * time.Second
+	defaultPageSize   = 100
+	mediaTypeJSON     = "application/json"
+	mediaTypeProblem  = "application/problem+json"
+	mediaTypeMerge    = "application/merge-patch+json"
+	idempotencyHeader = "Idempotency-Key"
+)
+
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	retries    int
+	backoff    time.Duration
+	pageSize   int
+	header     http.Header
+}
+
+type Option func(client *Client)
+
+// WithHTTPClient sets the client used for requests, http.DefaultClient is used otherwise.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(client *Client) {
+		client.httpClient = httpClient
+	}
+}
+
+// WithRetries sets how often a failed request is retried, 0 disables retries.
+func WithRetries(retries int) Option {
+	return func(client *Client) {
+		client.retries = retries
+	}
+}
+
+// WithBackoff sets the delay before the first retry. It doubles with every further retry.
+func WithBackoff(backoff time.Duration) Option {
+	return func(client *Client) {
+		client.backoff = backoff
+	}
+}
+
+// WithPageSize sets how many items the iterators fetch per request.
+func WithPageSize(pageSize int) Option {
+	return func(client *Client) {
+		client.pageSize = pageSize
+	}
+}
+
+// WithHeader adds a header to every request, e.g. for authentication.
+func WithHeader(name string, value string) Option {
+	return func(client *Client) {
+		client.header.Set(name, value)
+	}
+}
+
+// WithToken authenticates every request with the session token returned by a login.
+func WithToken(token string) Option {
+	return WithHeader("Authorization", "Bearer "+token)
+}
+
+// New returns a client for the API at baseURL, e.g. "http://localhost:5000".
+func New(baseURL string, options ...Option) *Client {
+	client := &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: http.DefaultClient,
+		retries:    defaultRetries,
+		backoff:    defaultBackoff,
+		pageSize:   defaultPageSize,
+		header:     http.Header{},
+	}
+	for _, option := range options {
+		option(client)
+	}
+
+	return client
+}
+
+// request describes a call of the API. Body is sent as is if it is a []byte, other values are
+// encoded as JSON.
+type request struct {
+	method      string
+	path        string
+	query       url.Values
+	header      http.Header
+	body        interface{}
+	contentType string
+	// idempotent requests are retried, POST requests only become idempotent with a key
+	idempotent bool
+	// notFound converts a 404 response into the matching core error
+	notFound func() error
+	// version is sent as If-Match, 0 sends none
+	version int
+}
+
+type response struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// do sends the request, retrying on network errors and on responses telling to try again later.
+func (client *Client) do(ctx context.Context, req request) (response, error) {
+	var body []byte
+	contentType := req.contentType
+	switch b := req.body.(type) {
+	case nil:
+	case []byte:
+		body = b
+	default:
+		var err error
+		body, err = json.Marshal(b)
+		if err != nil {
+			return response{}, err
+		}
+		if len(contentType) == 0 {
+			contentType = mediaTypeJSON
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, retryAfter, err := client.send(ctx, req, body, contentType)
+		if attempt >= client.retries || !req.idempotent || !retryable(resp, err) {
+			if err != nil {
+				return response{}, err
+			}
+			if resp.status >= 400 {
+				return resp, decodeError(resp, req)
+			}
+			return resp, nil
+		}
+
+		delay := client.backoff * time.Duration(math.Pow(2, float64(attempt)))
+		if delay > maxBackoff {
+			delay = maxBackoff
+		}
+		// jitter keeps clients failing at the same time from retrying at the same time
+		delay = delay/2 + time.Duration(mathrand.Int63n(int64(delay/2)+1))
+		if retryAfter > delay {
+			delay = retryAfter
+		}
+
+		select {
+		case <-ctx.Done():
+			return response{}, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (client *Client) send(ctx context.Context, req request, body []byte, contentType string) (response, time.Duration, error) {
+	target := client.baseURL + req.path
+	if len(req.query) > 0 {
+		target += "?" + req.query.Encode()
+	}
+
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+	httpRequest, err := http.NewRequestWithContext(ctx, req.method, target, bodyReader)
+	if err != nil {
+		return response{}, 0, err
+	}
+	for name, values := range client.header {
+		httpRequest.Header[name] = values
+	}
+	for name, values := range req.header {
+		httpRequest.Header[name] = values
+	}
+	if req.version != 0 {
+		httpRequest.Header.Set("If-Match", `"`+strconv.Itoa(req.version)+`"`)
+	}
+	if len(contentType) > 0 {
+		httpRequest.Header.Set("Content-Type", contentType)
+	}
+	if len(httpRequest.Header.Get("Accept")) == 0 {
+		httpRequest.Header.Set("Accept", mediaTypeJSON)
+	}
+
+	httpResponse, err := client.httpClient.Do(httpRequest)
+	if err != nil {
+		return response{}, 0, err
+	}
+	defer httpResponse.Body.Close()
+
+	responseBody, err := io.ReadAll(httpResponse.Body)
+	if err != nil {
+		return response{}, 0, err
+	}
+
+	var retryAfter time.Duration
+	if seconds, err := strconv.Atoi(httpResponse.Header.Get("Retry-After")); err == nil {
+		retryAfter = time.Duration(seconds) * time.Second
+	}
+
+	return response{
+		status: httpResponse.StatusCode,
+		header: httpResponse.Header,
+		body:   responseBody,
+	}, retryAfter, nil
+}
+
+func retryable(resp response, err error) bool {
+	if err != nil {
+		var netErr net.Error
+		return errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF)
+	}
+
+	switch resp.status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// decodeJSON decodes the body of a successful response.
+func decodeJSON(resp response, v interface{}) error {
+	if err := json.Unmarshal(resp.body, v); err != nil {
+		return fmt.Errorf("error while decoding response: %v", err)
+	}
+
+	return nil
+}
+
+// newIdempotencyKey returns a random key making a POST request safe to retry.
+func newIdempotencyKey() string {
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		// without a key the request is just not retried
+		return ""
+	}
+
+	return hex.EncodeToString(key)
+}
+
+// idempotentPost returns the request header and whether a POST request can be retried.
+func idempotentPost() (http.Header, bool) {
+	key := newIdempotencyKey()
+	if len(key) == 0 {
+		return http.Header{}, false
+	}
+
+	return http.Header{idempotencyHeader: []string{key}}, true
+}
+
+// parseVersion reads the version from the ETag header of a response.
+func parseVersion(header http.Header) int {
+	tag := strings.Trim(strings.TrimPrefix(header.Get("ETag"), "W/"), `"`)
+	if index := strings.Index(tag, "-"); index >= 0 {
+		tag = tag[:index]
+	}
+	version, _ := strconv.Atoi(tag)
+
+	return version
+}
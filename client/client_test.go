@@ -0,0 +1,225 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/phlashdev/recipe-keeper-api/api"
+	"github.com/phlashdev/recipe-keeper-api/cooking"
+	"github.com/phlashdev/recipe-keeper-api/core"
+)
+
+// testServer serves the API routes with in-memory repositories. The next failures requests are
+// handled but answered with status instead, as if the response got lost on the way back.
+type testServer struct {
+	*httptest.Server
+	recipes   *memoryRecipeRepository
+	sources   *memorySourceRepository
+	revisions *memoryRevisionRepository
+	router    http.Handler
+
+	mutex           sync.Mutex
+	failures        int
+	status          int
+	retryAfter      string
+	requests        int
+	idempotencyKeys []string
+}
+
+func newTestServer(t *testing.T) *testServer {
+	server := &testServer{
+		recipes:   &memoryRecipeRepository{},
+		sources:   &memorySourceRepository{},
+		revisions: &memoryRevisionRepository{},
+	}
+
+	router := mux.NewRouter()
+	api.RegisterRoutes(router, api.Repositories{
+		Recipes:     server.recipes,
+		Sources:     server.sources,
+		Revisions:   server.revisions,
+		Idempotency: &memoryIdempotencyRepository{records: map[string]core.IdempotencyRecord{}},
+	}, nil, cooking.NewTimers(time.Minute))
+	server.router = router
+
+	server.Server = httptest.NewServer(http.HandlerFunc(server.serveHTTP))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func (server *testServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	server.mutex.Lock()
+	server.requests++
+	server.idempotencyKeys = append(server.idempotencyKeys, r.Header.Get(idempotencyHeader))
+	fail := server.failures > 0
+	if fail {
+		server.failures--
+	}
+	server.mutex.Unlock()
+
+	if !fail {
+		server.router.ServeHTTP(w, r)
+		return
+	}
+
+	server.router.ServeHTTP(httptest.NewRecorder(), r)
+	if len(server.retryAfter) > 0 {
+		w.Header().Set("Retry-After", server.retryAfter)
+	}
+	w.WriteHeader(server.status)
+}
+
+// failNext answers the next requests with the status, counting requests from here on.
+func (server *testServer) failNext(failures int, status int, retryAfter string) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	server.failures = failures
+	server.status = status
+	server.retryAfter = retryAfter
+	server.requests = 0
+	server.idempotencyKeys = nil
+}
+
+func (server *testServer) requestCount() int {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	return server.requests
+}
+
+func (server *testServer) client(options ...Option) *Client {
+	return New(server.URL, append([]Option{WithBackoff(time.Millisecond)}, options...)...)
+}
+
+func TestRetryHonoursRetryAfter(t *testing.T) {
+	server := newTestServer(t)
+	client := server.client()
+	server.failNext(1, http.StatusServiceUnavailable, "1")
+
+	start := time.Now()
+	if _, err := client.ListSources(context.Background(), 0, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %v, want at least the Retry-After of 1s", elapsed)
+	}
+	if count := server.requestCount(); count != 2 {
+		t.Errorf("sent %d requests, want 2", count)
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	server := newTestServer(t)
+	client := server.client(WithRetries(2))
+	server.failNext(10, http.StatusBadGateway, "")
+
+	_, err := client.ListSources(context.Background(), 0, 0)
+
+	var clientErr *Error
+	if !errors.As(err, &clientErr) || clientErr.StatusCode != http.StatusBadGateway {
+		t.Fatalf("err = %v, want status %d", err, http.StatusBadGateway)
+	}
+	if count := server.requestCount(); count != 3 {
+		t.Errorf("sent %d requests, want 3", count)
+	}
+}
+
+func TestRetryDoesNotRetryClientErrors(t *testing.T) {
+	server := newTestServer(t)
+	client := server.client()
+	server.failNext(1, http.StatusBadRequest, "")
+
+	if _, err := client.ListSources(context.Background(), 0, 0); err == nil {
+		t.Fatal("expected an error")
+	}
+	if count := server.requestCount(); count != 1 {
+		t.Errorf("sent %d requests, want 1", count)
+	}
+}
+
+func TestRetryPostWithSameIdempotencyKey(t *testing.T) {
+	server := newTestServer(t)
+	client := server.client()
+	server.failNext(2, http.StatusServiceUnavailable, "")
+
+	source, err := client.CreateSource(context.Background(), SourceFields{Title: "Grandma", Type: core.SourceTypeCustom})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if count := server.requestCount(); count != 3 {
+		t.Fatalf("sent %d requests, want 3", count)
+	}
+	keys := server.idempotencyKeys
+	if len(keys[0]) == 0 || keys[1] != keys[0] || keys[2] != keys[0] {
+		t.Errorf("idempotency keys = %q, want the same key on every attempt", keys)
+	}
+
+	// the lost responses were replayed instead of creating the source again
+	sources, _ := server.sources.GetSources(context.Background())
+	if len(sources) != 1 || sources[0].ID.Hex() != source.ID || source.Version != 1 {
+		t.Errorf("stored %v, returned %+v", sources, source)
+	}
+}
+
+func TestRetryPostWithoutIdempotencyKey(t *testing.T) {
+	server := newTestServer(t)
+	client := server.client()
+	recipe, err := client.CreateRecipe(context.Background(), RecipeFields{Title: "Bread", SourceID: newSourceID(t, server)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.failNext(1, http.StatusServiceUnavailable, "")
+
+	err = client.RestoreRevision(context.Background(), recipe.ID, 1)
+
+	var clientErr *Error
+	if !errors.As(err, &clientErr) || clientErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("err = %v, want status %d", err, http.StatusServiceUnavailable)
+	}
+	if count := server.requestCount(); count != 1 {
+		t.Errorf("sent %d requests, want 1 as the request has no idempotency key", count)
+	}
+	if key := server.idempotencyKeys[0]; len(key) > 0 {
+		t.Errorf("sent idempotency key %q", key)
+	}
+}
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		etag string
+		want int
+	}{
+		{`"3"`, 3},
+		{`W/"3"`, 3},
+		{`"12-markdown"`, 12},
+		{``, 0},
+		{`"abc"`, 0},
+	}
+
+	for _, test := range tests {
+		if version := parseVersion(http.Header{"Etag": []string{test.etag}}); version != test.want {
+			t.Errorf("parseVersion(%q) = %d, want %d", test.etag, version, test.want)
+		}
+	}
+}
+
+func newSourceID(t *testing.T, server *testServer) string {
+	t.Helper()
+
+	source := core.Source{Title: "Grandma", Type: core.SourceTypeCustom}
+	if err := server.sources.AddSource(context.Background(), &source); err != nil {
+		t.Fatal(err)
+	}
+
+	return source.ID.Hex()
+}
@@ -0,0 +1,83 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/phlashdev/recipe-keeper-api/core"
+)
+
+// Problem is the problem details object of a failed request.
+type Problem struct {
+	Type          string         `json:"type"`
+	Title         string         `json:"title"`
+	Status        int            `json:"status"`
+	Detail        string         `json:"detail"`
+	Instance      string         `json:"instance"`
+	InvalidParams []InvalidParam `json:"invalid-params"`
+}
+
+type InvalidParam struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// Error is returned for failed requests without a matching error of package core.
+type Error struct {
+	StatusCode int
+	Problem    Problem
+}
+
+func (err *Error) Error() string {
+	if len(err.Problem.Detail) > 0 {
+		return fmt.Sprintf("request failed with status %d: %s", err.StatusCode, err.Problem.Detail)
+	}
+
+	return fmt.Sprintf("request failed with status %d", err.StatusCode)
+}
+
+// Problem types of the API mapped to core errors.
+const (
+	problemTypeInvalidRequest  = "/problems/invalid-request"
+	problemTypeVersionConflict = "/problems/version-conflict"
+)
+
+// decodeError converts the error response of the request.
+func decodeError(resp response, req request) error {
+	var problem Problem
+	if strings.HasPrefix(resp.header.Get("Content-Type"), mediaTypeProblem) {
+		// a body that is no problem object still leaves the status code
+		_ = json.Unmarshal(resp.body, &problem)
+	}
+
+	switch {
+	case resp.status == http.StatusNotFound && req.notFound != nil:
+		return req.notFound()
+	case problem.Type == problemTypeVersionConflict:
+		return &core.VersionConflictError{
+			ID:      resourceID(problem.Instance),
+			Version: req.version,
+		}
+	case problem.Type == problemTypeInvalidRequest && len(problem.InvalidParams) > 0:
+		validationErr := &core.ValidationError{}
+		for _, param := range problem.InvalidParams {
+			validationErr.Errors = append(validationErr.Errors, core.FieldError{
+				Field:   param.Name,
+				Message: param.Reason,
+			})
+		}
+		return validationErr
+	default:
+		return &Error{
+			StatusCode: resp.status,
+			Problem:    problem,
+		}
+	}
+}
+
+// resourceID returns the last segment of a resource path like /api/recipes/{id}.
+func resourceID(path string) string {
+	return path[strings.LastIndex(path, "/")+1:]
+}
@@ -0,0 +1,126 @@
+package client
+
+import "time"
+
+// RecipeFields are the fields of a recipe sent when creating or updating it.
+type RecipeFields struct {
+	Title            string       `json:"title" yaml:"title"`
+	SourceID         string       `json:"sourceId" yaml:"sourceId,omitempty"`
+	SourceAnnotation string       `json:"sourceAnnotation" yaml:"sourceAnnotation,omitempty"`
+	Category         string       `json:"category" yaml:"category,omitempty"`
+	Allergens        []string     `json:"allergens" yaml:"allergens,omitempty"`
+	Tags             []string     `json:"tags" yaml:"tags,omitempty"`
+	Servings         int          `json:"servings" yaml:"servings,omitempty"`
+	Ingredients      []Ingredient `json:"ingredients" yaml:"ingredients,omitempty"`
+	Cookware         []string     `json:"cookware" yaml:"cookware,omitempty"`
+	Steps            []Step       `json:"steps" yaml:"steps,omitempty"`
+}
+
+type Recipe struct {
+	ID string `json:"id"`
+	// Version is read from the entity tag, updates and deletes with a version fail with a
+	// *core.VersionConflictError if the recipe was changed in the meantime.
+	Version int `json:"-"`
+	RecipeFields
+}
+
+type Ingredient struct {
+	Name     string  `json:"name" yaml:"name"`
+	Quantity float64 `json:"quantity" yaml:"quantity,omitempty"`
+	Unit     string  `json:"unit" yaml:"unit,omitempty"`
+}
+
+type Step struct {
+	Text        string   `json:"text" yaml:"text"`
+	Ingredients []string `json:"ingredients" yaml:"ingredients,omitempty"`
+	Timers      []Timer  `json:"timers" yaml:"timers,omitempty"`
+}
+
+type Timer struct {
+	Name            string `json:"name" yaml:"name"`
+	DurationSeconds int64  `json:"durationSeconds" yaml:"durationSeconds,omitempty"`
+}
+
+type RecipeFilter struct {
+	Title    string
+	Category string
+	SourceID string
+	Tag      string
+}
+
+// SourceFields are the fields of a source sent when creating or updating it.
+type SourceFields struct {
+	Title string `json:"title" yaml:"title"`
+	Type  string `json:"type" yaml:"type"`
+}
+
+type Source struct {
+	ID string `json:"id"`
+	// Version is read from the entity tag, see Recipe.
+	Version int `json:"-"`
+	SourceFields
+}
+
+type FieldChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+type RevisionSummary struct {
+	Number    int           `json:"number"`
+	Author    string        `json:"author"`
+	CreatedAt time.Time     `json:"createdAt"`
+	Changes   []FieldChange `json:"changes"`
+}
+
+type Revision struct {
+	Number    int       `json:"number"`
+	Author    string    `json:"author"`
+	CreatedAt time.Time `json:"createdAt"`
+	Recipe    Recipe    `json:"recipe"`
+}
+
+type RevisionDiff struct {
+	From    int           `json:"from"`
+	To      int           `json:"to"`
+	Changes []FieldChange `json:"changes"`
+}
+
+// Operations of a batch, they are named like the constants of package core.
+const (
+	OperationCreate = "create"
+	OperationUpdate = "update"
+	OperationDelete = "delete"
+)
+
+// RecipeOperation is an operation of BatchRecipes. Recipe is not needed for deletes, a Version
+// of 0 skips the version check.
+type RecipeOperation struct {
+	Operation string        `json:"op"`
+	ID        string        `json:"id,omitempty"`
+	Version   int           `json:"version,omitempty"`
+	Recipe    *RecipeFields `json:"recipe,omitempty"`
+}
+
+// SourceOperation is an operation of BatchSources, see RecipeOperation.
+type SourceOperation struct {
+	Operation string        `json:"op"`
+	ID        string        `json:"id,omitempty"`
+	Version   int           `json:"version,omitempty"`
+	Source    *SourceFields `json:"source,omitempty"`
+}
+
+// BatchResult holds a result per operation. If Atomic is set and any operation failed, none
+// of them was applied.
+type BatchResult struct {
+	Atomic  bool              `json:"atomic"`
+	Results []BatchItemResult `json:"results"`
+}
+
+type BatchItemResult struct {
+	Status  int      `json:"status"`
+	ID      string   `json:"id"`
+	Version int      `json:"version"`
+	Error   *Problem `json:"error"`
+}
@@ -0,0 +1,329 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/phlashdev/recipe-keeper-api/core"
+)
+
+const recipesPath = "/api/recipes"
+
+func recipeNotFound(id string) func() error {
+	return func() error {
+		return &core.RecipeNotFoundError{ID: id}
+	}
+}
+
+// ListRecipes returns a page of the recipes matching the filter, a limit of 0 returns all.
+func (client *Client) ListRecipes(ctx context.Context, filter RecipeFilter, offset int, limit int) ([]Recipe, error) {
+	query := url.Values{}
+	for name, value := range map[string]string{"title": filter.Title, "category": filter.Category, "sourceId": filter.SourceID, "tag": filter.Tag} {
+		if len(value) > 0 {
+			query.Set(name, value)
+		}
+	}
+	if offset > 0 {
+		query.Set("offset", strconv.Itoa(offset))
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+
+	resp, err := client.do(ctx, request{
+		method:     http.MethodGet,
+		path:       recipesPath,
+		query:      query,
+		idempotent: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var recipes []Recipe
+	if err := decodeJSON(resp, &recipes); err != nil {
+		return nil, err
+	}
+
+	return recipes, nil
+}
+
+// Recipes returns an iterator over all recipes matching the filter, fetched page by page.
+func (client *Client) Recipes(filter RecipeFilter) *RecipeIterator {
+	return &RecipeIterator{
+		client: client,
+		filter: filter,
+		index:  -1,
+	}
+}
+
+// RecipeIterator iterates over recipes:
+//
+//	recipes := client.Recipes(filter)
+//	for recipes.Next(ctx) {
+//		recipe := recipes.Recipe()
+//	}
+//	if err := recipes.Err(); err != nil {
+type RecipeIterator struct {
+	client *Client
+	filter RecipeFilter
+	page   []Recipe
+	index  int
+	offset int
+	done   bool
+	err    error
+}
+
+// Next advances to the next recipe and fetches the next page if needed. It returns false at the
+// end or on errors.
+func (iterator *RecipeIterator) Next(ctx context.Context) bool {
+	iterator.index++
+	if iterator.index < len(iterator.page) {
+		return true
+	}
+	if iterator.done || iterator.err != nil {
+		return false
+	}
+
+	iterator.page, iterator.err = iterator.client.ListRecipes(ctx, iterator.filter, iterator.offset, iterator.client.pageSize)
+	iterator.index = 0
+	iterator.offset += len(iterator.page)
+	iterator.done = len(iterator.page) < iterator.client.pageSize
+
+	return iterator.err == nil && len(iterator.page) > 0
+}
+
+func (iterator *RecipeIterator) Recipe() Recipe {
+	return iterator.page[iterator.index]
+}
+
+func (iterator *RecipeIterator) Err() error {
+	return iterator.err
+}
+
+func (client *Client) GetRecipe(ctx context.Context, id string) (Recipe, error) {
+	resp, err := client.do(ctx, request{
+		method:     http.MethodGet,
+		path:       recipesPath + "/" + url.PathEscape(id),
+		idempotent: true,
+		notFound:   recipeNotFound(id),
+	})
+	if err != nil {
+		return Recipe{}, err
+	}
+
+	var recipe Recipe
+	if err := decodeJSON(resp, &recipe); err != nil {
+		return Recipe{}, err
+	}
+	recipe.Version = parseVersion(resp.header)
+
+	return recipe, nil
+}
+
+// GetRecipeAs returns another representation of the recipe, like "text/x-cooklang",
+// "text/markdown" or "application/ld+json".
+func (client *Client) GetRecipeAs(ctx context.Context, id string, mediaType string) ([]byte, error) {
+	resp, err := client.do(ctx, request{
+		method:     http.MethodGet,
+		path:       recipesPath + "/" + url.PathEscape(id),
+		header:     http.Header{"Accept": []string{mediaType}},
+		idempotent: true,
+		notFound:   recipeNotFound(id),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.body, nil
+}
+
+func (client *Client) GetRecipePDF(ctx context.Context, id string) ([]byte, error) {
+	resp, err := client.do(ctx, request{
+		method:     http.MethodGet,
+		path:       recipesPath + "/" + url.PathEscape(id) + ".pdf",
+		header:     http.Header{"Accept": []string{"application/pdf"}},
+		idempotent: true,
+		notFound:   recipeNotFound(id),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.body, nil
+}
+
+// CreateRecipe creates the recipe and returns it with its id. The request is sent with an
+// idempotency key, so it is safe to retry.
+func (client *Client) CreateRecipe(ctx context.Context, fields RecipeFields) (Recipe, error) {
+	header, idempotent := idempotentPost()
+	resp, err := client.do(ctx, request{
+		method:     http.MethodPost,
+		path:       recipesPath,
+		header:     header,
+		body:       fields,
+		idempotent: idempotent,
+	})
+	if err != nil {
+		return Recipe{}, err
+	}
+
+	var recipe Recipe
+	if err := decodeJSON(resp, &recipe); err != nil {
+		return Recipe{}, err
+	}
+	recipe.Version = parseVersion(resp.header)
+
+	return recipe, nil
+}
+
+// UpdateRecipe replaces the recipe. It fails with a *core.VersionConflictError if the version of
+// the recipe is set and outdated.
+func (client *Client) UpdateRecipe(ctx context.Context, recipe Recipe) error {
+	_, err := client.do(ctx, request{
+		method:     http.MethodPut,
+		path:       recipesPath + "/" + url.PathEscape(recipe.ID),
+		body:       recipe.RecipeFields,
+		version:    recipe.Version,
+		idempotent: true,
+		notFound:   recipeNotFound(recipe.ID),
+	})
+
+	return err
+}
+
+// PatchRecipe applies a JSON merge patch, e.g. map[string]interface{}{"servings": 4}, and returns
+// the changed recipe. A version of 0 skips the version check.
+func (client *Client) PatchRecipe(ctx context.Context, id string, version int, mergePatch interface{}) (Recipe, error) {
+	resp, err := client.do(ctx, request{
+		method:      http.MethodPatch,
+		path:        recipesPath + "/" + url.PathEscape(id),
+		body:        mergePatch,
+		contentType: mediaTypeMerge,
+		version:     version,
+		// a merge patch sets values, applying it twice gives the same recipe
+		idempotent: true,
+		notFound:   recipeNotFound(id),
+	})
+	if err != nil {
+		return Recipe{}, err
+	}
+
+	var recipe Recipe
+	if err := decodeJSON(resp, &recipe); err != nil {
+		return Recipe{}, err
+	}
+	recipe.Version = parseVersion(resp.header)
+
+	return recipe, nil
+}
+
+// DeleteRecipe moves the recipe to the trash. A version of 0 skips the version check.
+func (client *Client) DeleteRecipe(ctx context.Context, id string, version int) error {
+	_, err := client.do(ctx, request{
+		method:     http.MethodDelete,
+		path:       recipesPath + "/" + url.PathEscape(id),
+		version:    version,
+		idempotent: true,
+		notFound:   recipeNotFound(id),
+	})
+
+	return err
+}
+
+func (client *Client) BatchRecipes(ctx context.Context, operations []RecipeOperation) (BatchResult, error) {
+	header, idempotent := idempotentPost()
+	resp, err := client.do(ctx, request{
+		method:     http.MethodPost,
+		path:       recipesPath + "/batch",
+		header:     header,
+		body:       operations,
+		idempotent: idempotent,
+	})
+	if err != nil {
+		return BatchResult{}, err
+	}
+
+	var result BatchResult
+	if err := decodeJSON(resp, &result); err != nil {
+		return BatchResult{}, err
+	}
+
+	return result, nil
+}
+
+func (client *Client) GetRevisions(ctx context.Context, recipeID string) ([]RevisionSummary, error) {
+	resp, err := client.do(ctx, request{
+		method:     http.MethodGet,
+		path:       recipesPath + "/" + url.PathEscape(recipeID) + "/revisions",
+		idempotent: true,
+		notFound:   recipeNotFound(recipeID),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var revisions []RevisionSummary
+	if err := decodeJSON(resp, &revisions); err != nil {
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+func (client *Client) GetRevision(ctx context.Context, recipeID string, number int) (Revision, error) {
+	resp, err := client.do(ctx, request{
+		method:     http.MethodGet,
+		path:       recipesPath + "/" + url.PathEscape(recipeID) + "/revisions/" + strconv.Itoa(number),
+		idempotent: true,
+		notFound: func() error {
+			return &core.RevisionNotFoundError{RecipeID: recipeID, Number: number}
+		},
+	})
+	if err != nil {
+		return Revision{}, err
+	}
+
+	var revision Revision
+	if err := decodeJSON(resp, &revision); err != nil {
+		return Revision{}, err
+	}
+
+	return revision, nil
+}
+
+func (client *Client) DiffRevisions(ctx context.Context, recipeID string, from int, to int) (RevisionDiff, error) {
+	resp, err := client.do(ctx, request{
+		method:     http.MethodGet,
+		path:       recipesPath + "/" + url.PathEscape(recipeID) + "/revisions/diff",
+		query:      url.Values{"from": []string{strconv.Itoa(from)}, "to": []string{strconv.Itoa(to)}},
+		idempotent: true,
+		notFound:   recipeNotFound(recipeID),
+	})
+	if err != nil {
+		return RevisionDiff{}, err
+	}
+
+	var diff RevisionDiff
+	if err := decodeJSON(resp, &diff); err != nil {
+		return RevisionDiff{}, err
+	}
+
+	return diff, nil
+}
+
+// RestoreRevision sets the recipe back to the revision. It is not retried, every restore adds
+// a revision.
+func (client *Client) RestoreRevision(ctx context.Context, recipeID string, number int) error {
+	_, err := client.do(ctx, request{
+		method: http.MethodPost,
+		path:   recipesPath + "/" + url.PathEscape(recipeID) + "/revisions/" + strconv.Itoa(number) + "/restore",
+		notFound: func() error {
+			return &core.RevisionNotFoundError{RecipeID: recipeID, Number: number}
+		},
+	})
+
+	return err
+}
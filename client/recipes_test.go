@@ -0,0 +1,159 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/phlashdev/recipe-keeper-api/core"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestRecipesIterator(t *testing.T) {
+	server := newTestServer(t)
+	client := server.client(WithPageSize(2))
+	sourceID := newSourceID(t, server)
+	var want []string
+	for i := 1; i <= 5; i++ {
+		title := fmt.Sprintf("Bread %d", i)
+		want = append(want, title)
+		if _, err := client.CreateRecipe(context.Background(), RecipeFields{Title: title, SourceID: sourceID}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := client.CreateRecipe(context.Background(), RecipeFields{Title: "Soup", SourceID: sourceID}); err != nil {
+		t.Fatal(err)
+	}
+	server.failNext(0, 0, "")
+
+	var titles []string
+	recipes := client.Recipes(RecipeFilter{Title: "bread"})
+	for recipes.Next(context.Background()) {
+		titles = append(titles, recipes.Recipe().Title)
+	}
+
+	if err := recipes.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(titles, want) {
+		t.Errorf("titles = %q, want %q", titles, want)
+	}
+	if count := server.requestCount(); count != 3 {
+		t.Errorf("fetched %d pages, want 3", count)
+	}
+}
+
+func TestRecipesIteratorStopsOnError(t *testing.T) {
+	server := newTestServer(t)
+	client := server.client(WithRetries(0))
+	server.failNext(1, http.StatusInternalServerError, "")
+
+	recipes := client.Recipes(RecipeFilter{})
+	if recipes.Next(context.Background()) {
+		t.Fatal("Next returned true for a failed request")
+	}
+
+	var clientErr *Error
+	if !errors.As(recipes.Err(), &clientErr) || clientErr.StatusCode != http.StatusInternalServerError {
+		t.Errorf("Err() = %v, want status %d", recipes.Err(), http.StatusInternalServerError)
+	}
+}
+
+func TestGetRecipeNotFound(t *testing.T) {
+	server := newTestServer(t)
+	id := primitive.NewObjectID().Hex()
+
+	_, err := server.client().GetRecipe(context.Background(), id)
+
+	var notFoundErr *core.RecipeNotFoundError
+	if !errors.As(err, &notFoundErr) || notFoundErr.ID != id {
+		t.Errorf("err = %#v, want a RecipeNotFoundError for %s", err, id)
+	}
+}
+
+func TestUpdateRecipeVersionConflict(t *testing.T) {
+	server := newTestServer(t)
+	client := server.client()
+	recipe, err := client.CreateRecipe(context.Background(), RecipeFields{Title: "Bread", SourceID: newSourceID(t, server)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changed := recipe
+	changed.Servings = 4
+	if err := client.UpdateRecipe(context.Background(), changed); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := client.GetRecipe(context.Background(), recipe.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Version != recipe.Version+1 || stored.Servings != 4 {
+		t.Errorf("stored version %d with %d servings, want version %d with 4", stored.Version, stored.Servings, recipe.Version+1)
+	}
+
+	// recipe still has the version before the update
+	for name, write := range map[string]func() error{
+		"update": func() error { return client.UpdateRecipe(context.Background(), recipe) },
+		"delete": func() error { return client.DeleteRecipe(context.Background(), recipe.ID, recipe.Version) },
+	} {
+		err := write()
+		var conflictErr *core.VersionConflictError
+		if !errors.As(err, &conflictErr) || conflictErr.ID != recipe.ID || conflictErr.Version != recipe.Version {
+			t.Errorf("%s: err = %#v, want a VersionConflictError for %s version %d", name, err, recipe.ID, recipe.Version)
+		}
+	}
+}
+
+func TestCreateRecipeValidation(t *testing.T) {
+	server := newTestServer(t)
+
+	_, err := server.client().CreateRecipe(context.Background(), RecipeFields{
+		SourceID:    newSourceID(t, server),
+		Servings:    -1,
+		Ingredients: []Ingredient{{Name: "Flour"}},
+	})
+
+	var validationErr *core.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("err = %#v, want a ValidationError", err)
+	}
+	var fields []string
+	for _, fieldErr := range validationErr.Errors {
+		fields = append(fields, fieldErr.Field)
+	}
+	if want := []string{"title", "servings"}; !reflect.DeepEqual(fields, want) {
+		t.Errorf("invalid fields = %q, want %q", fields, want)
+	}
+}
+
+func TestRestoreRevisionReturnsETag(t *testing.T) {
+	server := newTestServer(t)
+	client := server.client()
+	recipe, err := client.CreateRecipe(context.Background(), RecipeFields{Title: "Bread", SourceID: newSourceID(t, server)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed := recipe
+	changed.Title = "Rye bread"
+	if err := client.UpdateRecipe(context.Background(), changed); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := http.Post(server.URL+recipesPath+"/"+recipe.ID+"/revisions/1/restore", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	stored, err := client.GetRecipe(context.Background(), recipe.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusNoContent || parseVersion(res.Header) != stored.Version || stored.Title != "Bread" {
+		t.Errorf("restore answered %d with ETag %q, stored version %d titled %q", res.StatusCode, res.Header.Get("ETag"), stored.Version, stored.Title)
+	}
+}
@@ -0,0 +1,367 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/phlashdev/recipe-keeper-api/core"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// The repositories below keep their data in memory, they implement what the tested routes use
+// like the mongo repositories do.
+
+var errNotSupported = errors.New("not supported by the test repository")
+
+type memoryRecipeRepository struct {
+	mutex   sync.Mutex
+	recipes []core.Recipe
+}
+
+func (repo *memoryRecipeRepository) GetRecipes(ctx context.Context, filter core.RecipeFilter) ([]core.Recipe, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	recipes := []core.Recipe{}
+	for _, recipe := range repo.recipes {
+		if !recipe.DeletedAt.IsZero() {
+			continue
+		}
+		if len(filter.Title) > 0 && !strings.Contains(strings.ToLower(recipe.Title), strings.ToLower(filter.Title)) {
+			continue
+		}
+		if len(filter.Category) > 0 && recipe.Category != filter.Category {
+			continue
+		}
+		recipes = append(recipes, recipe)
+	}
+
+	if filter.Offset > len(recipes) {
+		return []core.Recipe{}, nil
+	}
+	recipes = recipes[filter.Offset:]
+	if filter.Limit > 0 && filter.Limit < len(recipes) {
+		recipes = recipes[:filter.Limit]
+	}
+
+	return recipes, nil
+}
+
+func (repo *memoryRecipeRepository) GetRecipeByID(ctx context.Context, id string) (core.Recipe, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	index, err := repo.find(id)
+	if err != nil {
+		return core.Recipe{}, err
+	}
+
+	return repo.recipes[index], nil
+}
+
+// find expects the mutex to be locked.
+func (repo *memoryRecipeRepository) find(id string) (int, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return 0, &core.RecipeIDNotValidError{ID: id}
+	}
+	for i, recipe := range repo.recipes {
+		if recipe.ID == objectID && recipe.DeletedAt.IsZero() {
+			return i, nil
+		}
+	}
+
+	return 0, &core.RecipeNotFoundError{ID: id}
+}
+
+func (repo *memoryRecipeRepository) AddRecipe(ctx context.Context, recipe *core.Recipe) error {
+	if err := core.ValidateRecipe(*recipe); err != nil {
+		return err
+	}
+
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	recipe.ID = primitive.NewObjectID()
+	recipe.Version = 1
+	repo.recipes = append(repo.recipes, *recipe)
+
+	return nil
+}
+
+func (repo *memoryRecipeRepository) UpdateRecipe(ctx context.Context, recipe core.Recipe) error {
+	if err := core.ValidateRecipe(recipe); err != nil {
+		return err
+	}
+
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	index, err := repo.find(recipe.ID.Hex())
+	if err != nil || repo.recipes[index].Version != recipe.Version {
+		return &core.VersionConflictError{ID: recipe.ID.Hex(), Version: recipe.Version}
+	}
+	recipe.Version++
+	repo.recipes[index] = recipe
+
+	return nil
+}
+
+func (repo *memoryRecipeRepository) DeleteRecipe(ctx context.Context, recipe core.Recipe) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	index, err := repo.find(recipe.ID.Hex())
+	if err != nil || repo.recipes[index].Version != recipe.Version {
+		return &core.VersionConflictError{ID: recipe.ID.Hex(), Version: recipe.Version}
+	}
+	repo.recipes[index].DeletedAt = time.Now()
+
+	return nil
+}
+
+func (repo *memoryRecipeRepository) ImportRecipes(ctx context.Context, recipes []core.Recipe) error {
+	return errNotSupported
+}
+
+func (repo *memoryRecipeRepository) BatchRecipes(ctx context.Context, operations []core.RecipeOperation) (core.BatchResult, error) {
+	return core.BatchResult{}, errNotSupported
+}
+
+func (repo *memoryRecipeRepository) PatchRecipe(ctx context.Context, id string, patch func(recipe *core.Recipe) error) (core.Recipe, error) {
+	return core.Recipe{}, errNotSupported
+}
+
+func (repo *memoryRecipeRepository) GetDeletedRecipes(ctx context.Context) ([]core.Recipe, error) {
+	return nil, errNotSupported
+}
+
+func (repo *memoryRecipeRepository) RestoreRecipe(ctx context.Context, id string) error {
+	return errNotSupported
+}
+
+func (repo *memoryRecipeRepository) PurgeRecipe(ctx context.Context, id string) error {
+	return errNotSupported
+}
+
+func (repo *memoryRecipeRepository) PurgeDeletedRecipes(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	return 0, errNotSupported
+}
+
+type memorySourceRepository struct {
+	mutex   sync.Mutex
+	sources []core.Source
+}
+
+func (repo *memorySourceRepository) GetSources(ctx context.Context) ([]core.Source, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	sources := []core.Source{}
+	for _, source := range repo.sources {
+		if source.DeletedAt.IsZero() {
+			sources = append(sources, source)
+		}
+	}
+
+	return sources, nil
+}
+
+func (repo *memorySourceRepository) GetSourceByID(ctx context.Context, id string) (core.Source, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	index, err := repo.find(id)
+	if err != nil {
+		return core.Source{}, err
+	}
+
+	return repo.sources[index], nil
+}
+
+// find expects the mutex to be locked.
+func (repo *memorySourceRepository) find(id string) (int, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return 0, &core.SourceIDNotValidError{ID: id}
+	}
+	for i, source := range repo.sources {
+		if source.ID == objectID && source.DeletedAt.IsZero() {
+			return i, nil
+		}
+	}
+
+	return 0, &core.SourceNotFoundError{ID: id}
+}
+
+func (repo *memorySourceRepository) AddSource(ctx context.Context, source *core.Source) error {
+	if err := core.ValidateSource(*source); err != nil {
+		return err
+	}
+
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	source.ID = primitive.NewObjectID()
+	source.Version = 1
+	repo.sources = append(repo.sources, *source)
+
+	return nil
+}
+
+func (repo *memorySourceRepository) UpdateSource(ctx context.Context, source core.Source) error {
+	if err := core.ValidateSource(source); err != nil {
+		return err
+	}
+
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	index, err := repo.find(source.ID.Hex())
+	if err != nil || repo.sources[index].Version != source.Version {
+		return &core.VersionConflictError{ID: source.ID.Hex(), Version: source.Version}
+	}
+	source.Version++
+	repo.sources[index] = source
+
+	return nil
+}
+
+func (repo *memorySourceRepository) DeleteSource(ctx context.Context, source core.Source) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	index, err := repo.find(source.ID.Hex())
+	if err != nil || repo.sources[index].Version != source.Version {
+		return &core.VersionConflictError{ID: source.ID.Hex(), Version: source.Version}
+	}
+	repo.sources[index].DeletedAt = time.Now()
+
+	return nil
+}
+
+func (repo *memorySourceRepository) ImportSources(ctx context.Context, sources []core.Source) error {
+	return errNotSupported
+}
+
+func (repo *memorySourceRepository) BatchSources(ctx context.Context, operations []core.SourceOperation) (core.BatchResult, error) {
+	return core.BatchResult{}, errNotSupported
+}
+
+func (repo *memorySourceRepository) PatchSource(ctx context.Context, id string, patch func(source *core.Source) error) (core.Source, error) {
+	return core.Source{}, errNotSupported
+}
+
+func (repo *memorySourceRepository) GetDeletedSources(ctx context.Context) ([]core.Source, error) {
+	return nil, errNotSupported
+}
+
+func (repo *memorySourceRepository) RestoreSource(ctx context.Context, id string) error {
+	return errNotSupported
+}
+
+func (repo *memorySourceRepository) PurgeSource(ctx context.Context, id string) error {
+	return errNotSupported
+}
+
+func (repo *memorySourceRepository) PurgeDeletedSources(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	return 0, errNotSupported
+}
+
+type memoryRevisionRepository struct {
+	mutex     sync.Mutex
+	revisions []core.Revision
+}
+
+func (repo *memoryRevisionRepository) GetRevisions(ctx context.Context, recipeID string) ([]core.Revision, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	revisions := []core.Revision{}
+	for _, revision := range repo.revisions {
+		if revision.Recipe.Hex() == recipeID {
+			revisions = append(revisions, revision)
+		}
+	}
+
+	return revisions, nil
+}
+
+func (repo *memoryRevisionRepository) GetRevision(ctx context.Context, recipeID string, number int) (core.Revision, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	for _, revision := range repo.revisions {
+		if revision.Recipe.Hex() == recipeID && revision.Number == number {
+			return revision, nil
+		}
+	}
+
+	return core.Revision{}, &core.RevisionNotFoundError{RecipeID: recipeID, Number: number}
+}
+
+func (repo *memoryRevisionRepository) AddRevision(ctx context.Context, revision *core.Revision) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	revision.ID = primitive.NewObjectID()
+	revision.Number = 1
+	for _, stored := range repo.revisions {
+		if stored.Recipe == revision.Recipe && stored.Number >= revision.Number {
+			revision.Number = stored.Number + 1
+		}
+	}
+	repo.revisions = append(repo.revisions, *revision)
+
+	return nil
+}
+
+type memoryIdempotencyRepository struct {
+	mutex   sync.Mutex
+	records map[string]core.IdempotencyRecord
+}
+
+func (repo *memoryIdempotencyRepository) GetIdempotencyRecord(ctx context.Context, key string) (core.IdempotencyRecord, error) {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	record, ok := repo.records[key]
+	if !ok {
+		return core.IdempotencyRecord{}, &core.IdempotencyRecordNotFoundError{Key: key}
+	}
+
+	return record, nil
+}
+
+func (repo *memoryIdempotencyRepository) AddIdempotencyRecord(ctx context.Context, record core.IdempotencyRecord) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	if _, ok := repo.records[record.Key]; ok {
+		return &core.IdempotencyKeyExistsError{Key: record.Key}
+	}
+	repo.records[record.Key] = record
+
+	return nil
+}
+
+func (repo *memoryIdempotencyRepository) CompleteIdempotencyRecord(ctx context.Context, record core.IdempotencyRecord) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	repo.records[record.Key] = record
+
+	return nil
+}
+
+func (repo *memoryIdempotencyRepository) DeleteIdempotencyRecord(ctx context.Context, key string) error {
+	repo.mutex.Lock()
+	defer repo.mutex.Unlock()
+
+	delete(repo.records, key)
+
+	return nil
+}
@@ -0,0 +1,209 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/phlashdev/recipe-keeper-api/core"
+)
+
+const sourcesPath = "/api/sources"
+
+func sourceNotFound(id string) func() error {
+	return func() error {
+		return &core.SourceNotFoundError{ID: id}
+	}
+}
+
+// ListSources returns a page of the sources, a limit of 0 returns all.
+func (client *Client) ListSources(ctx context.Context, offset int, limit int) ([]Source, error) {
+	query := url.Values{}
+	if offset > 0 {
+		query.Set("offset", strconv.Itoa(offset))
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+
+	resp, err := client.do(ctx, request{
+		method:     http.MethodGet,
+		path:       sourcesPath,
+		query:      query,
+		idempotent: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var sources []Source
+	if err := decodeJSON(resp, &sources); err != nil {
+		return nil, err
+	}
+
+	return sources, nil
+}
+
+// Sources returns an iterator over all sources, fetched page by page.
+func (client *Client) Sources() *SourceIterator {
+	return &SourceIterator{
+		client: client,
+		index:  -1,
+	}
+}
+
+// SourceIterator iterates over sources like RecipeIterator.
+type SourceIterator struct {
+	client *Client
+	page   []Source
+	index  int
+	offset int
+	done   bool
+	err    error
+}
+
+// Next advances to the next source and fetches the next page if needed. It returns false at the
+// end or on errors.
+func (iterator *SourceIterator) Next(ctx context.Context) bool {
+	iterator.index++
+	if iterator.index < len(iterator.page) {
+		return true
+	}
+	if iterator.done || iterator.err != nil {
+		return false
+	}
+
+	iterator.page, iterator.err = iterator.client.ListSources(ctx, iterator.offset, iterator.client.pageSize)
+	iterator.index = 0
+	iterator.offset += len(iterator.page)
+	iterator.done = len(iterator.page) < iterator.client.pageSize
+
+	return iterator.err == nil && len(iterator.page) > 0
+}
+
+func (iterator *SourceIterator) Source() Source {
+	return iterator.page[iterator.index]
+}
+
+func (iterator *SourceIterator) Err() error {
+	return iterator.err
+}
+
+func (client *Client) GetSource(ctx context.Context, id string) (Source, error) {
+	resp, err := client.do(ctx, request{
+		method:     http.MethodGet,
+		path:       sourcesPath + "/" + url.PathEscape(id),
+		idempotent: true,
+		notFound:   sourceNotFound(id),
+	})
+	if err != nil {
+		return Source{}, err
+	}
+
+	var source Source
+	if err := decodeJSON(resp, &source); err != nil {
+		return Source{}, err
+	}
+	source.Version = parseVersion(resp.header)
+
+	return source, nil
+}
+
+// CreateSource creates the source and returns it with its id. The request is sent with an
+// idempotency key, so it is safe to retry.
+func (client *Client) CreateSource(ctx context.Context, fields SourceFields) (Source, error) {
+	header, idempotent := idempotentPost()
+	resp, err := client.do(ctx, request{
+		method:     http.MethodPost,
+		path:       sourcesPath,
+		header:     header,
+		body:       fields,
+		idempotent: idempotent,
+	})
+	if err != nil {
+		return Source{}, err
+	}
+
+	var source Source
+	if err := decodeJSON(resp, &source); err != nil {
+		return Source{}, err
+	}
+	source.Version = parseVersion(resp.header)
+
+	return source, nil
+}
+
+// UpdateSource replaces the source. It fails with a *core.VersionConflictError if the version of
+// the source is set and outdated.
+func (client *Client) UpdateSource(ctx context.Context, source Source) error {
+	_, err := client.do(ctx, request{
+		method:     http.MethodPut,
+		path:       sourcesPath + "/" + url.PathEscape(source.ID),
+		body:       source.SourceFields,
+		version:    source.Version,
+		idempotent: true,
+		notFound:   sourceNotFound(source.ID),
+	})
+
+	return err
+}
+
+// PatchSource applies a JSON merge patch and returns the changed source. A version of 0 skips
+// the version check.
+func (client *Client) PatchSource(ctx context.Context, id string, version int, mergePatch interface{}) (Source, error) {
+	resp, err := client.do(ctx, request{
+		method:      http.MethodPatch,
+		path:        sourcesPath + "/" + url.PathEscape(id),
+		body:        mergePatch,
+		contentType: mediaTypeMerge,
+		version:     version,
+		idempotent:  true,
+		notFound:    sourceNotFound(id),
+	})
+	if err != nil {
+		return Source{}, err
+	}
+
+	var source Source
+	if err := decodeJSON(resp, &source); err != nil {
+		return Source{}, err
+	}
+	source.Version = parseVersion(resp.header)
+
+	return source, nil
+}
+
+// DeleteSource moves the source to the trash. A version of 0 skips the version check.
+func (client *Client) DeleteSource(ctx context.Context, id string, version int) error {
+	_, err := client.do(ctx, request{
+		method:     http.MethodDelete,
+		path:       sourcesPath + "/" + url.PathEscape(id),
+		version:    version,
+		idempotent: true,
+		notFound:   sourceNotFound(id),
+	})
+
+	return err
+}
+
+func (client *Client) BatchSources(ctx context.Context, operations []SourceOperation) (BatchResult, error) {
+	header, idempotent := idempotentPost()
+	resp, err := client.do(ctx, request{
+		method:     http.MethodPost,
+		path:       sourcesPath + "/batch",
+		header:     header,
+		body:       operations,
+		idempotent: idempotent,
+	})
+	if err != nil {
+		return BatchResult{}, err
+	}
+
+	var result BatchResult
+	if err := decodeJSON(resp, &result); err != nil {
+		return BatchResult{}, err
+	}
+
+	return result, nil
+}
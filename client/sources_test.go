@@ -0,0 +1,94 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/phlashdev/recipe-keeper-api/core"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestSourcesIterator(t *testing.T) {
+	server := newTestServer(t)
+	client := server.client(WithPageSize(2))
+	var want []string
+	for i := 1; i <= 4; i++ {
+		title := fmt.Sprintf("Book %d", i)
+		want = append(want, title)
+		if _, err := client.CreateSource(context.Background(), SourceFields{Title: title, Type: core.SourceTypeBook}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	server.failNext(0, 0, "")
+
+	var titles []string
+	sources := client.Sources()
+	for sources.Next(context.Background()) {
+		titles = append(titles, sources.Source().Title)
+	}
+
+	if err := sources.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(titles, want) {
+		t.Errorf("titles = %q, want %q", titles, want)
+	}
+	// a full last page needs another request to find the end
+	if count := server.requestCount(); count != 3 {
+		t.Errorf("fetched %d pages, want 3", count)
+	}
+}
+
+func TestGetSourceNotFound(t *testing.T) {
+	server := newTestServer(t)
+	id := primitive.NewObjectID().Hex()
+
+	_, err := server.client().GetSource(context.Background(), id)
+
+	var notFoundErr *core.SourceNotFoundError
+	if !errors.As(err, &notFoundErr) || notFoundErr.ID != id {
+		t.Errorf("err = %#v, want a SourceNotFoundError for %s", err, id)
+	}
+}
+
+func TestUpdateSourceVersionConflict(t *testing.T) {
+	server := newTestServer(t)
+	client := server.client()
+	source, err := client.CreateSource(context.Background(), SourceFields{Title: "Grandma", Type: core.SourceTypeCustom})
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed := source
+	changed.Title = "Grandpa"
+	if err := client.UpdateSource(context.Background(), changed); err != nil {
+		t.Fatal(err)
+	}
+
+	err = client.UpdateSource(context.Background(), source)
+
+	var conflictErr *core.VersionConflictError
+	if !errors.As(err, &conflictErr) || conflictErr.ID != source.ID || conflictErr.Version != source.Version {
+		t.Errorf("err = %#v, want a VersionConflictError for %s version %d", err, source.ID, source.Version)
+	}
+}
+
+func TestCreateSourceValidation(t *testing.T) {
+	server := newTestServer(t)
+
+	_, err := server.client().CreateSource(context.Background(), SourceFields{Type: "magazine"})
+
+	var validationErr *core.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("err = %#v, want a ValidationError", err)
+	}
+	var fields []string
+	for _, fieldErr := range validationErr.Errors {
+		fields = append(fields, fieldErr.Field)
+	}
+	if want := []string{"title", "type"}; !reflect.DeepEqual(fields, want) {
+		t.Errorf("invalid fields = %q, want %q", fields, want)
+	}
+}
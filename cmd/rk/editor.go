@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// errNotChanged is returned by editYAML if the file was saved unchanged.
+var errNotChanged = errors.New("no changes")
+
+// editYAML opens v as YAML in the editor of the user and decodes the saved file into v.
+func editYAML(v interface{}, comment string) error {
+	document, err := yaml.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	var original bytes.Buffer
+	for _, line := range strings.Split(comment, "\n") {
+		fmt.Fprintf(&original, "# %s\n", line)
+	}
+	original.Write(document)
+
+	file, err := os.CreateTemp("", "rk-*.yaml")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.Write(original.Bytes())
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := runEditor(file.Name()); err != nil {
+		return err
+	}
+
+	edited, err := os.ReadFile(file.Name())
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(edited, original.Bytes()) {
+		return errNotChanged
+	}
+
+	return decodeYAML(bytes.NewReader(edited), v)
+}
+
+// runEditor opens the file in $VISUAL or $EDITOR, which may contain arguments like "code --wait".
+func runEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if len(editor) == 0 {
+		editor = os.Getenv("EDITOR")
+	}
+	if len(editor) == 0 {
+		editor = "vi"
+	}
+
+	args := strings.Fields(editor)
+	cmd := exec.Command(args[0], append(args[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor %q failed: %v", editor, err)
+	}
+
+	return nil
+}
+
+// decodeYAML decodes a document into v, rejecting unknown fields so typos are not dropped silently.
+// Fields missing in the document are cleared.
+func decodeYAML(r io.Reader, v interface{}) error {
+	value := reflect.ValueOf(v).Elem()
+	value.Set(reflect.Zero(value.Type()))
+
+	decoder := yaml.NewDecoder(r)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("error while reading YAML: %v", err)
+	}
+
+	return nil
+}
+
+// readYAMLFile decodes the file, "-" reads from standard input.
+func readYAMLFile(path string, v interface{}) error {
+	if path == "-" {
+		return decodeYAML(os.Stdin, v)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return decodeYAML(file, v)
+}
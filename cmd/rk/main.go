@@ -0,0 +1,86 @@
+// Command rk manages the recipes and sources of a running recipe keeper server.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/phlashdev/recipe-keeper-api/client"
+)
+
+const (
+	ServerURLEnv     = "RECIPEKEEPER_URL"
+	TokenEnv         = "RECIPEKEEPER_TOKEN"
+	defaultServerURL = "http://localhost:5000"
+)
+
+func main() {
+	log.SetFlags(0)
+
+	serverURL := os.Getenv(ServerURLEnv)
+	if len(serverURL) == 0 {
+		serverURL = defaultServerURL
+	}
+
+	server := flag.String("server", serverURL, "URL of the recipe keeper server, defaults to $"+ServerURLEnv)
+	token := flag.String("token", os.Getenv(TokenEnv), "session token of the user, defaults to $"+TokenEnv)
+	output := flag.String("output", outputTable, "output format: table or json")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), `Usage: rk [-server <url>] [-token <token>] [-output table|json] <resource> <command> [arguments]
+
+Resources and commands:
+  recipes list [-category <category>] [-tag <tag>] [-source <id>] [-limit <n>]
+  recipes search <title> [-category <category>] [-tag <tag>] [-source <id>] [-limit <n>]
+  recipes show <id>
+  recipes add [-file <yaml file>]
+  recipes edit <id>
+  recipes delete <id>
+  sources list
+  sources search <title>
+  sources show <id>
+  sources add [-file <yaml file>]
+  sources edit <id>
+  sources delete <id>
+
+Without -file, add and edit open the recipe or source as YAML in $VISUAL or $EDITOR.
+
+A token is returned by POST /api/login, it is sent with every request.
+
+Flags:`)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() < 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *output != outputTable && *output != outputJSON {
+		log.Fatalf("unknown output format %q", *output)
+	}
+
+	var options []client.Option
+	if len(*token) > 0 {
+		options = append(options, client.WithToken(*token))
+	}
+
+	cli := &cli{
+		client: client.New(*server, options...),
+		output: *output,
+		stdout: os.Stdout,
+	}
+
+	var err error
+	switch flag.Arg(0) {
+	case "recipes", "recipe":
+		err = cli.recipes(flag.Arg(1), flag.Args()[2:])
+	case "sources", "source":
+		err = cli.sources(flag.Arg(1), flag.Args()[2:])
+	default:
+		err = fmt.Errorf("unknown resource %q", flag.Arg(0))
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/phlashdev/recipe-keeper-api/client"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+type cli struct {
+	client *client.Client
+	output string
+	stdout io.Writer
+}
+
+func (cli *cli) printJSON(v interface{}) error {
+	encoder := json.NewEncoder(cli.stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func (cli *cli) printTable(header []string, rows [][]string) error {
+	writer := tabwriter.NewWriter(cli.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(writer, strings.Join(row, "\t"))
+	}
+
+	return writer.Flush()
+}
+
+// printMessage reports the outcome of a change. With JSON output the changed item is printed instead.
+func (cli *cli) printMessage(message string, item interface{}) error {
+	if cli.output == outputJSON {
+		return cli.printJSON(item)
+	}
+
+	_, err := fmt.Fprintln(cli.stdout, message)
+	return err
+}
+
+func (cli *cli) printRecipes(recipes []client.Recipe) error {
+	if cli.output == outputJSON {
+		return cli.printJSON(recipes)
+	}
+
+	rows := make([][]string, 0, len(recipes))
+	for _, recipe := range recipes {
+		servings := ""
+		if recipe.Servings > 0 {
+			servings = fmt.Sprint(recipe.Servings)
+		}
+		rows = append(rows, []string{recipe.ID, recipe.Title, recipe.Category, servings, strings.Join(recipe.Tags, ", ")})
+	}
+
+	return cli.printTable([]string{"ID", "TITLE", "CATEGORY", "SERVINGS", "TAGS"}, rows)
+}
+
+func (cli *cli) printRecipe(recipe client.Recipe) error {
+	if cli.output == outputJSON {
+		return cli.printJSON(recipe)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n%s\n", recipe.Title, strings.Repeat("=", len([]rune(recipe.Title))))
+	fmt.Fprintf(&b, "ID:        %s\n", recipe.ID)
+	fmt.Fprintf(&b, "Version:   %d\n", recipe.Version)
+	if len(recipe.SourceID) > 0 {
+		fmt.Fprintf(&b, "Source:    %s %s\n", recipe.SourceID, recipe.SourceAnnotation)
+	}
+	if len(recipe.Category) > 0 {
+		fmt.Fprintf(&b, "Category:  %s\n", recipe.Category)
+	}
+	if recipe.Servings > 0 {
+		fmt.Fprintf(&b, "Servings:  %d\n", recipe.Servings)
+	}
+	if len(recipe.Tags) > 0 {
+		fmt.Fprintf(&b, "Tags:      %s\n", strings.Join(recipe.Tags, ", "))
+	}
+	if len(recipe.Allergens) > 0 {
+		fmt.Fprintf(&b, "Allergens: %s\n", strings.Join(recipe.Allergens, ", "))
+	}
+
+	if len(recipe.Ingredients) > 0 {
+		fmt.Fprintln(&b, "\nIngredients:")
+		for _, ingredient := range recipe.Ingredients {
+			amount := strings.TrimSpace(fmt.Sprintf("%s %s", formatQuantity(ingredient.Quantity), ingredient.Unit))
+			if len(amount) > 0 {
+				fmt.Fprintf(&b, "  - %s %s\n", amount, ingredient.Name)
+			} else {
+				fmt.Fprintf(&b, "  - %s\n", ingredient.Name)
+			}
+		}
+	}
+	if len(recipe.Steps) > 0 {
+		fmt.Fprintln(&b, "\nSteps:")
+		for i, step := range recipe.Steps {
+			fmt.Fprintf(&b, "  %d. %s\n", i+1, step.Text)
+		}
+	}
+
+	_, err := io.WriteString(cli.stdout, b.String())
+	return err
+}
+
+func formatQuantity(quantity float64) string {
+	if quantity == 0 {
+		return ""
+	}
+
+	return strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.2f", quantity), "0"), ".")
+}
+
+func (cli *cli) printSources(sources []client.Source) error {
+	if cli.output == outputJSON {
+		return cli.printJSON(sources)
+	}
+
+	rows := make([][]string, 0, len(sources))
+	for _, source := range sources {
+		rows = append(rows, []string{source.ID, source.Type, source.Title})
+	}
+
+	return cli.printTable([]string{"ID", "TYPE", "TITLE"}, rows)
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/phlashdev/recipe-keeper-api/client"
+)
+
+func (cli *cli) recipes(command string, args []string) error {
+	switch command {
+	case "list":
+		return cli.listRecipes(args, false)
+	case "search":
+		return cli.listRecipes(args, true)
+	case "show":
+		return cli.showRecipe(args)
+	case "add":
+		return cli.addRecipe(args)
+	case "edit":
+		return cli.editRecipe(args)
+	case "delete":
+		return cli.deleteRecipe(args)
+	default:
+		return fmt.Errorf("unknown command %q for recipes", command)
+	}
+}
+
+func (cli *cli) listRecipes(args []string, search bool) error {
+	name := "list"
+	usage := "Usage: rk recipes list [-category <category>] [-tag <tag>] [-source <id>] [-limit <n>]"
+	if search {
+		name = "search"
+		usage = "Usage: rk recipes search <title> [-category <category>] [-tag <tag>] [-source <id>] [-limit <n>]"
+	}
+
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	category := flags.String("category", "", "only recipes of the category")
+	tag := flags.String("tag", "", "only recipes with the tag")
+	source := flags.String("source", "", "only recipes of the source with the id")
+	limit := flags.Int("limit", 0, "maximum number of recipes, all if 0")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), usage)
+		flags.PrintDefaults()
+	}
+
+	filter := client.RecipeFilter{}
+	if search {
+		// the title comes first, so the flags follow it
+		if len(args) == 0 || len(args[0]) == 0 || args[0][0] == '-' {
+			flags.Usage()
+			return errors.New("missing title")
+		}
+		filter.Title = args[0]
+		args = args[1:]
+	}
+	flags.Parse(args)
+	filter.Category = *category
+	filter.Tag = *tag
+	filter.SourceID = *source
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	var recipes []client.Recipe
+	iterator := cli.client.Recipes(filter)
+	for (*limit == 0 || len(recipes) < *limit) && iterator.Next(ctx) {
+		recipes = append(recipes, iterator.Recipe())
+	}
+	if err := iterator.Err(); err != nil {
+		return err
+	}
+
+	return cli.printRecipes(recipes)
+}
+
+func (cli *cli) showRecipe(args []string) error {
+	id, err := singleID("show", args)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	recipe, err := cli.client.GetRecipe(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return cli.printRecipe(recipe)
+}
+
+func (cli *cli) addRecipe(args []string) error {
+	flags := flag.NewFlagSet("add", flag.ExitOnError)
+	file := flags.String("file", "", "YAML file with the recipe, - reads standard input")
+	flags.Parse(args)
+
+	var fields client.RecipeFields
+	if len(*file) > 0 {
+		if err := readYAMLFile(*file, &fields); err != nil {
+			return err
+		}
+	} else {
+		fields = client.RecipeFields{
+			Title:       "",
+			Servings:    2,
+			Ingredients: []client.Ingredient{{Name: ""}},
+			Steps:       []client.Step{{Text: ""}},
+		}
+		err := editYAML(&fields, "New recipe, save and close the editor to add it.\nLeave the file unchanged to cancel.")
+		if errors.Is(err, errNotChanged) {
+			return errors.New("recipe not added")
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	recipe, err := cli.client.CreateRecipe(ctx, fields)
+	if err != nil {
+		return err
+	}
+
+	return cli.printMessage(fmt.Sprintf("added recipe %s", recipe.ID), recipe)
+}
+
+func (cli *cli) editRecipe(args []string) error {
+	id, err := singleID("edit", args)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	recipe, err := cli.client.GetRecipe(ctx, id)
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	// no timeout while the user edits
+	err = editYAML(&recipe.RecipeFields, fmt.Sprintf("Recipe %s, version %d.\nSave and close the editor to update it, leave the file unchanged to cancel.", recipe.ID, recipe.Version))
+	if errors.Is(err, errNotChanged) {
+		return cli.printMessage("no changes", recipe)
+	}
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// the version makes the update fail if someone else changed the recipe while editing
+	if err := cli.client.UpdateRecipe(ctx, recipe); err != nil {
+		return err
+	}
+
+	updated, err := cli.client.GetRecipe(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return cli.printMessage(fmt.Sprintf("updated recipe %s", id), updated)
+}
+
+func (cli *cli) deleteRecipe(args []string) error {
+	id, err := singleID("delete", args)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := cli.client.DeleteRecipe(ctx, id, 0); err != nil {
+		return err
+	}
+
+	return cli.printMessage(fmt.Sprintf("moved recipe %s to the trash", id), map[string]string{"id": id})
+}
+
+// singleID returns the id argument of commands like show and delete.
+func singleID(command string, args []string) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("usage: %s <id>", command)
+	}
+
+	return args[0], nil
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/phlashdev/recipe-keeper-api/client"
+	"github.com/phlashdev/recipe-keeper-api/core"
+)
+
+func (cli *cli) sources(command string, args []string) error {
+	switch command {
+	case "list":
+		return cli.listSources(args, "")
+	case "search":
+		if len(args) != 1 {
+			return errors.New("usage: rk sources search <title>")
+		}
+		return cli.listSources(nil, args[0])
+	case "show":
+		return cli.showSource(args)
+	case "add":
+		return cli.addSource(args)
+	case "edit":
+		return cli.editSource(args)
+	case "delete":
+		return cli.deleteSource(args)
+	default:
+		return fmt.Errorf("unknown command %q for sources", command)
+	}
+}
+
+// listSources prints all sources, or those with the title containing search. The API has no
+// source filter, there are few sources.
+func (cli *cli) listSources(args []string, search string) error {
+	if len(args) > 0 {
+		return errors.New("usage: rk sources list")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	var sources []client.Source
+	iterator := cli.client.Sources()
+	for iterator.Next(ctx) {
+		source := iterator.Source()
+		if strings.Contains(strings.ToLower(source.Title), strings.ToLower(search)) {
+			sources = append(sources, source)
+		}
+	}
+	if err := iterator.Err(); err != nil {
+		return err
+	}
+
+	return cli.printSources(sources)
+}
+
+func (cli *cli) showSource(args []string) error {
+	id, err := singleID("show", args)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	source, err := cli.client.GetSource(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return cli.printSources([]client.Source{source})
+}
+
+func (cli *cli) addSource(args []string) error {
+	flags := flag.NewFlagSet("add", flag.ExitOnError)
+	file := flags.String("file", "", "YAML file with the source, - reads standard input")
+	flags.Parse(args)
+
+	var fields client.SourceFields
+	if len(*file) > 0 {
+		if err := readYAMLFile(*file, &fields); err != nil {
+			return err
+		}
+	} else {
+		fields = client.SourceFields{Type: core.SourceTypeBook}
+		comment := fmt.Sprintf("New source, the type is one of %s, %s or %s.\nSave and close the editor to add it, leave the file unchanged to cancel.",
+			core.SourceTypeBook, core.SourceTypeUrl, core.SourceTypeCustom)
+		err := editYAML(&fields, comment)
+		if errors.Is(err, errNotChanged) {
+			return errors.New("source not added")
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	source, err := cli.client.CreateSource(ctx, fields)
+	if err != nil {
+		return err
+	}
+
+	return cli.printMessage(fmt.Sprintf("added source %s", source.ID), source)
+}
+
+func (cli *cli) editSource(args []string) error {
+	id, err := singleID("edit", args)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	source, err := cli.client.GetSource(ctx, id)
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	err = editYAML(&source.SourceFields, fmt.Sprintf("Source %s, version %d.\nSave and close the editor to update it, leave the file unchanged to cancel.", source.ID, source.Version))
+	if errors.Is(err, errNotChanged) {
+		return cli.printMessage("no changes", source)
+	}
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := cli.client.UpdateSource(ctx, source); err != nil {
+		return err
+	}
+
+	updated, err := cli.client.GetSource(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return cli.printMessage(fmt.Sprintf("updated source %s", id), updated)
+}
+
+func (cli *cli) deleteSource(args []string) error {
+	id, err := singleID("delete", args)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := cli.client.DeleteSource(ctx, id, 0); err != nil {
+		return err
+	}
+
+	return cli.printMessage(fmt.Sprintf("moved source %s to the trash", id), map[string]string{"id": id})
+}
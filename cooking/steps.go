@@ -0,0 +1,129 @@
+// Package cooking supports cook mode: it relates the steps of a recipe to its ingredients and keeps
+// the step timers that run while cooking.
+package cooking
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/phlashdev/recipe-keeper-api/core"
+)
+
+// Segment is a part of a step text, Ingredient is set if the part names an ingredient of the step.
+type Segment struct {
+	Text       string
+	Ingredient string
+}
+
+// StepIngredients returns the recipe ingredients used in the step with their quantities. Steps
+// that do not list their ingredients use those mentioned in the text.
+func StepIngredients(recipe core.Recipe, step core.Step) []core.Ingredient {
+	var ingredients []core.Ingredient
+	if len(step.Ingredients) > 0 {
+		for _, name := range step.Ingredients {
+			ingredient, ok := findIngredient(recipe.Ingredients, name)
+			if !ok {
+				ingredient = core.Ingredient{Name: name}
+			}
+			ingredients = append(ingredients, ingredient)
+		}
+		return ingredients
+	}
+
+	text := strings.ToLower(step.Text)
+	for _, ingredient := range recipe.Ingredients {
+		if len(ingredient.Name) > 0 && strings.Contains(text, strings.ToLower(ingredient.Name)) {
+			ingredients = append(ingredients, ingredient)
+		}
+	}
+
+	return ingredients
+}
+
+func findIngredient(ingredients []core.Ingredient, name string) (core.Ingredient, bool) {
+	for _, ingredient := range ingredients {
+		if strings.EqualFold(strings.TrimSpace(ingredient.Name), strings.TrimSpace(name)) {
+			return ingredient, true
+		}
+	}
+
+	return core.Ingredient{}, false
+}
+
+// Highlight splits the text into segments so the ingredients mentioned in it can be highlighted.
+// Names are matched ignoring case, longer names first so "brown sugar" wins over "sugar".
+func Highlight(text string, ingredients []core.Ingredient) []Segment {
+	names := make([]string, 0, len(ingredients))
+	for _, ingredient := range ingredients {
+		if name := strings.TrimSpace(ingredient.Name); len(name) > 0 {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return []Segment{{Text: text}}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return len(names[i]) > len(names[j])
+	})
+
+	quoted := make([]string, 0, len(names))
+	for _, name := range names {
+		quoted = append(quoted, regexp.QuoteMeta(name))
+	}
+	pattern := regexp.MustCompile("(?i)" + strings.Join(quoted, "|"))
+
+	var segments []Segment
+	start := 0
+	for _, match := range pattern.FindAllStringIndex(text, -1) {
+		if match[0] > start {
+			segments = append(segments, Segment{Text: text[start:match[0]]})
+		}
+		matched := text[match[0]:match[1]]
+		ingredient, _ := findIngredient(ingredients, matched)
+		segments = append(segments, Segment{Text: matched, Ingredient: ingredient.Name})
+		start = match[1]
+	}
+	if start < len(text) {
+		segments = append(segments, Segment{Text: text[start:]})
+	}
+
+	return segments
+}
+
+// Step returns the step with the number, steps are numbered starting at 1.
+func Step(recipe core.Recipe, number int) (core.Step, error) {
+	if number < 1 || number > len(recipe.Steps) {
+		return core.Step{}, &StepNotFoundError{RecipeID: recipe.ID.Hex(), Number: number}
+	}
+
+	return recipe.Steps[number-1], nil
+}
+
+// StepTimer returns the timer with the index of the step with the index.
+func StepTimer(recipe core.Recipe, stepIndex int, timerIndex int) (core.Timer, error) {
+	if stepIndex < 0 || stepIndex >= len(recipe.Steps) || timerIndex < 0 || timerIndex >= len(recipe.Steps[stepIndex].Timers) {
+		return core.Timer{}, &StepTimerNotFoundError{StepIndex: stepIndex, TimerIndex: timerIndex}
+	}
+
+	return recipe.Steps[stepIndex].Timers[timerIndex], nil
+}
+
+type StepNotFoundError struct {
+	RecipeID string
+	Number   int
+}
+
+func (err *StepNotFoundError) Error() string {
+	return fmt.Sprintf("step %d of recipe with id '%s' not found", err.Number, err.RecipeID)
+}
+
+type StepTimerNotFoundError struct {
+	StepIndex  int
+	TimerIndex int
+}
+
+func (err *StepTimerNotFoundError) Error() string {
+	return fmt.Sprintf("step %d has no timer %d", err.StepIndex, err.TimerIndex)
+}
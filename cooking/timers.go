@@ -0,0 +1,197 @@
+package cooking
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Timer is a step timer started in cook mode. It is shared by all devices cooking the recipe.
+type Timer struct {
+	ID        string
+	RecipeID  string
+	StepIndex int
+	Name      string
+	Duration  time.Duration
+	StartedAt time.Time
+	EndsAt    time.Time
+}
+
+// Remaining returns the time left at now, it is zero once the timer finished.
+func (timer Timer) Remaining(now time.Time) time.Duration {
+	if now.After(timer.EndsAt) {
+		return 0
+	}
+
+	return timer.EndsAt.Sub(now)
+}
+
+type runningTimer struct {
+	Timer
+	finish *time.Timer
+	remove *time.Timer
+}
+
+// Timers keeps the timers of all recipes in memory, they are lost on restart. Subscribers are
+// notified with the timers of the recipe whenever one is started, finishes or is removed.
+type Timers struct {
+	// keepFinished is how long finished timers are kept, so every device notices them.
+	keepFinished time.Duration
+
+	mutex       sync.Mutex
+	timers      map[string]map[string]*runningTimer
+	subscribers map[string]map[chan []Timer]bool
+}
+
+func NewTimers(keepFinished time.Duration) *Timers {
+	return &Timers{
+		keepFinished: keepFinished,
+		timers:       map[string]map[string]*runningTimer{},
+		subscribers:  map[string]map[chan []Timer]bool{},
+	}
+}
+
+// Start starts a timer for the step of the recipe.
+func (timers *Timers) Start(recipeID string, stepIndex int, name string, duration time.Duration) (Timer, error) {
+	id, err := newTimerID()
+	if err != nil {
+		return Timer{}, err
+	}
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	running := &runningTimer{
+		Timer: Timer{
+			ID:        id,
+			RecipeID:  recipeID,
+			StepIndex: stepIndex,
+			Name:      name,
+			Duration:  duration,
+			StartedAt: now,
+			EndsAt:    now.Add(duration),
+		},
+	}
+
+	timers.mutex.Lock()
+	defer timers.mutex.Unlock()
+
+	if timers.timers[recipeID] == nil {
+		timers.timers[recipeID] = map[string]*runningTimer{}
+	}
+	timers.timers[recipeID][id] = running
+	running.finish = time.AfterFunc(duration, func() {
+		timers.mutex.Lock()
+		defer timers.mutex.Unlock()
+		timers.publish(recipeID)
+	})
+	running.remove = time.AfterFunc(duration+timers.keepFinished, func() {
+		timers.mutex.Lock()
+		defer timers.mutex.Unlock()
+		timers.delete(recipeID, id)
+	})
+	timers.publish(recipeID)
+
+	return running.Timer, nil
+}
+
+// Remove cancels a running timer or dismisses a finished one.
+func (timers *Timers) Remove(recipeID string, id string) error {
+	timers.mutex.Lock()
+	defer timers.mutex.Unlock()
+
+	running, ok := timers.timers[recipeID][id]
+	if !ok {
+		return &TimerNotFoundError{ID: id}
+	}
+	running.finish.Stop()
+	running.remove.Stop()
+	timers.delete(recipeID, id)
+
+	return nil
+}
+
+// List returns the timers of the recipe, the one ending first comes first.
+func (timers *Timers) List(recipeID string) []Timer {
+	timers.mutex.Lock()
+	defer timers.mutex.Unlock()
+
+	return timers.list(recipeID)
+}
+
+// Subscribe returns a channel receiving the timers of the recipe, first the current ones and then
+// after every change. Only the latest state is kept for slow receivers. The returned function
+// ends the subscription.
+func (timers *Timers) Subscribe(recipeID string) (<-chan []Timer, func()) {
+	updates := make(chan []Timer, 1)
+
+	timers.mutex.Lock()
+	defer timers.mutex.Unlock()
+
+	if timers.subscribers[recipeID] == nil {
+		timers.subscribers[recipeID] = map[chan []Timer]bool{}
+	}
+	timers.subscribers[recipeID][updates] = true
+	updates <- timers.list(recipeID)
+
+	return updates, func() {
+		timers.mutex.Lock()
+		defer timers.mutex.Unlock()
+
+		delete(timers.subscribers[recipeID], updates)
+		if len(timers.subscribers[recipeID]) == 0 {
+			delete(timers.subscribers, recipeID)
+		}
+	}
+}
+
+// list and the following methods expect the mutex to be locked.
+func (timers *Timers) list(recipeID string) []Timer {
+	list := make([]Timer, 0, len(timers.timers[recipeID]))
+	for _, running := range timers.timers[recipeID] {
+		list = append(list, running.Timer)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].EndsAt.Before(list[j].EndsAt)
+	})
+
+	return list
+}
+
+func (timers *Timers) delete(recipeID string, id string) {
+	delete(timers.timers[recipeID], id)
+	if len(timers.timers[recipeID]) == 0 {
+		delete(timers.timers, recipeID)
+	}
+	timers.publish(recipeID)
+}
+
+func (timers *Timers) publish(recipeID string) {
+	list := timers.list(recipeID)
+	for updates := range timers.subscribers[recipeID] {
+		// replace a state the subscriber has not received yet, only the latest matters
+		select {
+		case <-updates:
+		default:
+		}
+		updates <- list
+	}
+}
+
+func newTimerID() (string, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", fmt.Errorf("error while creating timer id: %v", err)
+	}
+
+	return hex.EncodeToString(id), nil
+}
+
+type TimerNotFoundError struct {
+	ID string
+}
+
+func (err *TimerNotFoundError) Error() string {
+	return fmt.Sprintf("timer with id '%s' not found", err.ID)
+}
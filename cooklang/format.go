@@ -0,0 +1,262 @@
+package cooklang
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/phlashdev/recipe-keeper-api/core"
+)
+
+// Format writes the recipe in Cooklang format. Ingredients and cookware are marked at their first
+// mention in the step texts, the ones not mentioned in any step are listed in a leading step.
+func Format(w io.Writer, recipe core.Recipe, source *core.Source) error {
+	writer := bufio.NewWriter(w)
+
+	writeMetadata(writer, MetadataTitle, recipe.Title)
+	if recipe.Servings > 0 {
+		writeMetadata(writer, MetadataServings, strconv.Itoa(recipe.Servings))
+	}
+	writeMetadata(writer, MetadataCategory, recipe.Category)
+	writeMetadata(writer, MetadataAllergens, strings.Join(recipe.Allergens, ", "))
+	writeMetadata(writer, MetadataTags, strings.Join(recipe.Tags, ", "))
+	if source != nil {
+		writeMetadata(writer, MetadataSource, source.Title)
+	}
+	writeMetadata(writer, MetadataPage, recipe.SourceAnnotation)
+
+	quantified := map[string]bool{}
+	var steps []string
+	var unmentioned []string
+	for _, ingredient := range recipe.Ingredients {
+		if !usedInSteps(recipe.Steps, ingredient.Name) {
+			unmentioned = append(unmentioned, "@"+ingredient.Name+formatAmount(ingredient))
+			quantified[strings.ToLower(ingredient.Name)] = true
+		}
+	}
+	for _, cookware := range recipe.Cookware {
+		if !mentionedInSteps(recipe.Steps, cookware) {
+			unmentioned = append(unmentioned, "#"+cookware+"{}")
+		}
+	}
+	if len(unmentioned) > 0 {
+		steps = append(steps, strings.Join(unmentioned, ", "))
+	}
+
+	for _, step := range recipe.Steps {
+		steps = append(steps, formatStep(step, recipe, quantified))
+	}
+
+	if len(steps) > 0 {
+		writer.WriteString("\n")
+	}
+	writer.WriteString(strings.Join(steps, "\n\n"))
+	writer.WriteString("\n")
+
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("error while writing cooklang: %v", err)
+	}
+
+	return nil
+}
+
+func writeMetadata(writer *bufio.Writer, key string, value string) {
+	if len(value) == 0 {
+		return
+	}
+	fmt.Fprintf(writer, ">> %s: %s\n", key, value)
+}
+
+type markup struct {
+	start int
+	end   int
+	text  string
+}
+
+func formatStep(step core.Step, recipe core.Recipe, quantified map[string]bool) string {
+	var markups []markup
+	var appended []string
+
+	for _, name := range step.Ingredients {
+		amount := "{}"
+		if !quantified[strings.ToLower(name)] {
+			if ingredient, ok := findIngredient(recipe.Ingredients, name); ok {
+				amount = formatAmount(ingredient)
+			}
+			quantified[strings.ToLower(name)] = true
+		}
+
+		component := "@" + name + amount
+		if !addMarkup(&markups, step.Text, name, component) {
+			appended = append(appended, component)
+		}
+	}
+
+	for _, cookware := range recipe.Cookware {
+		addMarkup(&markups, step.Text, cookware, "#"+cookware+"{}")
+	}
+
+	for _, timer := range step.Timers {
+		if !addTimerMarkup(&markups, step.Text, timer) {
+			quantity, unit := formatDuration(timer.Duration)
+			appended = append(appended, "~"+timer.Name+"{"+quantity+"%"+unit+"}")
+		}
+	}
+
+	sort.Slice(markups, func(i, j int) bool {
+		return markups[i].start < markups[j].start
+	})
+
+	var result strings.Builder
+	position := 0
+	for _, m := range markups {
+		result.WriteString(step.Text[position:m.start])
+		result.WriteString(m.text)
+		position = m.end
+	}
+	result.WriteString(step.Text[position:])
+
+	for _, component := range appended {
+		result.WriteString(" ")
+		result.WriteString(component)
+	}
+
+	return strings.TrimSpace(result.String())
+}
+
+// addMarkup replaces the first whole-word occurrence of term which does not overlap with other markup.
+func addMarkup(markups *[]markup, text string, term string, replacement string) bool {
+	if len(term) == 0 {
+		return false
+	}
+
+	lowerText := strings.ToLower(text)
+	lowerTerm := strings.ToLower(term)
+	offset := 0
+	for {
+		index := strings.Index(lowerText[offset:], lowerTerm)
+		if index < 0 || len(lowerText) != len(text) {
+			return false
+		}
+		start := offset + index
+		end := start + len(term)
+		offset = start + 1
+
+		if !isWordBoundary(text, start, end) || overlaps(*markups, start, end) {
+			continue
+		}
+
+		*markups = append(*markups, markup{start: start, end: end, text: replacement})
+		return true
+	}
+}
+
+// addTimerMarkup looks for the duration of the timer written with any of the known units, e.g. "1.5 minutes".
+func addTimerMarkup(markups *[]markup, text string, timer core.Timer) bool {
+	units := make([]string, 0, len(durationUnits))
+	for unit := range durationUnits {
+		units = append(units, unit)
+	}
+	sort.Slice(units, func(i, j int) bool {
+		return len(units[i]) > len(units[j]) || (len(units[i]) == len(units[j]) && units[i] < units[j])
+	})
+
+	for _, unit := range units {
+		quantity := formatQuantity(float64(timer.Duration) / float64(durationUnits[unit]))
+		if addMarkup(markups, text, quantity+" "+unit, "~"+timer.Name+"{"+quantity+"%"+unit+"}") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isWordBoundary(text string, start int, end int) bool {
+	if start > 0 {
+		r, _ := utf8.DecodeLastRuneInString(text[:start])
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return false
+		}
+	}
+	if end < len(text) {
+		r, _ := utf8.DecodeRuneInString(text[end:])
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func overlaps(markups []markup, start int, end int) bool {
+	for _, m := range markups {
+		if start < m.end && m.start < end {
+			return true
+		}
+	}
+
+	return false
+}
+
+func usedInSteps(steps []core.Step, ingredient string) bool {
+	for _, step := range steps {
+		if containsString(step.Ingredients, ingredient) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func mentionedInSteps(steps []core.Step, name string) bool {
+	for _, step := range steps {
+		var markups []markup
+		if addMarkup(&markups, step.Text, name, "") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func findIngredient(ingredients []core.Ingredient, name string) (core.Ingredient, bool) {
+	for _, ingredient := range ingredients {
+		if strings.EqualFold(ingredient.Name, name) {
+			return ingredient, true
+		}
+	}
+
+	return core.Ingredient{}, false
+}
+
+func formatAmount(ingredient core.Ingredient) string {
+	switch {
+	case ingredient.Quantity == 0:
+		return "{" + ingredient.Unit + "}"
+	case len(ingredient.Unit) == 0:
+		return "{" + formatQuantity(ingredient.Quantity) + "}"
+	default:
+		return "{" + formatQuantity(ingredient.Quantity) + "%" + ingredient.Unit + "}"
+	}
+}
+
+func formatQuantity(quantity float64) string {
+	return strconv.FormatFloat(quantity, 'f', -1, 64)
+}
+
+func formatDuration(duration time.Duration) (string, string) {
+	switch {
+	case duration >= time.Hour && duration%time.Hour == 0:
+		return formatQuantity(float64(duration / time.Hour)), "hours"
+	case duration >= time.Minute && duration%time.Minute == 0:
+		return formatQuantity(float64(duration / time.Minute)), "minutes"
+	default:
+		return formatQuantity(duration.Seconds()), "seconds"
+	}
+}
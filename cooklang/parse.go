@@ -0,0 +1,423 @@
+package cooklang
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/phlashdev/recipe-keeper-api/core"
+)
+
+const (
+	MetadataTitle     = "title"
+	MetadataServings  = "servings"
+	MetadataCategory  = "category"
+	MetadataAllergens = "allergens"
+	MetadataTags      = "tags"
+	MetadataSource    = "source"
+	MetadataPage      = "page"
+)
+
+type Metadata map[string]string
+
+type ParseError struct {
+	Line    int
+	Message string
+}
+
+func (err *ParseError) Error() string {
+	if err.Line == 0 {
+		return fmt.Sprintf("cooklang: %s", err.Message)
+	}
+	return fmt.Sprintf("cooklang line %d: %s", err.Line, err.Message)
+}
+
+// Parse reads a recipe in Cooklang format. Metadata known to the recipe model is applied to the
+// returned recipe, everything else (e.g. the source) is returned as metadata.
+func Parse(r io.Reader) (core.Recipe, Metadata, error) {
+	var recipe core.Recipe
+	metadata := Metadata{}
+
+	lines, err := readLines(r)
+	if err != nil {
+		return core.Recipe{}, nil, err
+	}
+
+	lineNumber := 0
+	if len(lines) > 0 && strings.TrimSpace(lines[0]) == "---" {
+		for lineNumber = 1; lineNumber < len(lines); lineNumber++ {
+			if strings.TrimSpace(lines[lineNumber]) == "---" {
+				lineNumber++
+				break
+			}
+			addMetadata(metadata, lines[lineNumber])
+		}
+	}
+
+	var paragraph []string
+	paragraphStart := 0
+	flush := func() error {
+		if len(paragraph) == 0 {
+			return nil
+		}
+		step, err := parseStep(strings.Join(paragraph, " "), &recipe, paragraphStart)
+		paragraph = nil
+		if err != nil {
+			return err
+		}
+		if len(step.Text) > 0 {
+			recipe.Steps = append(recipe.Steps, step)
+		}
+		return nil
+	}
+
+	inBlockComment := false
+	for ; lineNumber < len(lines); lineNumber++ {
+		line := lines[lineNumber]
+		line, inBlockComment = stripComments(line, inBlockComment)
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(trimmed, ">>"):
+			addMetadata(metadata, strings.TrimPrefix(trimmed, ">>"))
+		case len(trimmed) == 0, strings.HasPrefix(trimmed, "="), strings.HasPrefix(trimmed, ">"):
+			if err := flush(); err != nil {
+				return core.Recipe{}, nil, err
+			}
+		default:
+			if len(paragraph) == 0 {
+				paragraphStart = lineNumber + 1
+			}
+			paragraph = append(paragraph, trimmed)
+		}
+	}
+	if err := flush(); err != nil {
+		return core.Recipe{}, nil, err
+	}
+
+	if err := applyMetadata(&recipe, metadata); err != nil {
+		return core.Recipe{}, nil, err
+	}
+
+	return recipe, metadata, nil
+}
+
+func readLines(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error while reading cooklang: %v", err)
+	}
+
+	return lines, nil
+}
+
+func stripComments(line string, inBlockComment bool) (string, bool) {
+	var result strings.Builder
+	for len(line) > 0 {
+		if inBlockComment {
+			end := strings.Index(line, "-]")
+			if end < 0 {
+				return result.String(), true
+			}
+			line = line[end+2:]
+			inBlockComment = false
+			continue
+		}
+
+		lineComment := strings.Index(line, "--")
+		blockComment := strings.Index(line, "[-")
+		if blockComment >= 0 && (lineComment < 0 || blockComment < lineComment) {
+			result.WriteString(line[:blockComment])
+			line = line[blockComment+2:]
+			inBlockComment = true
+			continue
+		}
+		if lineComment >= 0 {
+			result.WriteString(line[:lineComment])
+			break
+		}
+		result.WriteString(line)
+		break
+	}
+
+	return result.String(), inBlockComment
+}
+
+func addMetadata(metadata Metadata, line string) {
+	parts := strings.SplitN(line, ":", 2)
+	if len(parts) != 2 {
+		return
+	}
+
+	key := strings.ToLower(strings.TrimSpace(parts[0]))
+	value := strings.Trim(strings.TrimSpace(parts[1]), `"'`)
+	if len(key) > 0 {
+		metadata[key] = value
+	}
+}
+
+func applyMetadata(recipe *core.Recipe, metadata Metadata) error {
+	if title, ok := metadata[MetadataTitle]; ok {
+		recipe.Title = title
+		delete(metadata, MetadataTitle)
+	}
+
+	if servings, ok := metadata[MetadataServings]; ok {
+		// servings may be given as a range like "2|4", the first value is the default
+		value := strings.TrimSpace(strings.SplitN(servings, "|", 2)[0])
+		number, err := strconv.Atoi(value)
+		if err != nil {
+			return &ParseError{Message: fmt.Sprintf("servings '%s' not valid", servings)}
+		}
+		recipe.Servings = number
+		delete(metadata, MetadataServings)
+	}
+
+	if category, ok := metadata[MetadataCategory]; ok {
+		recipe.Category = category
+		delete(metadata, MetadataCategory)
+	}
+
+	if allergens, ok := metadata[MetadataAllergens]; ok {
+		recipe.Allergens = splitList(allergens)
+		delete(metadata, MetadataAllergens)
+	}
+
+	if tags, ok := metadata[MetadataTags]; ok {
+		recipe.Tags = splitList(strings.Trim(tags, "[]"))
+		delete(metadata, MetadataTags)
+	}
+
+	if page, ok := metadata[MetadataPage]; ok {
+		recipe.SourceAnnotation = page
+		delete(metadata, MetadataPage)
+	}
+
+	return nil
+}
+
+func parseStep(text string, recipe *core.Recipe, lineNumber int) (core.Step, error) {
+	var step core.Step
+	var plain strings.Builder
+
+	for i := 0; i < len(text); {
+		marker := text[i]
+		if marker != '@' && marker != '#' && marker != '~' {
+			plain.WriteByte(marker)
+			i++
+			continue
+		}
+
+		name, amount, hasAmount, next := scanComponent(text, i+1)
+		if len(name) == 0 && !hasAmount {
+			plain.WriteByte(marker)
+			i++
+			continue
+		}
+
+		switch marker {
+		case '@':
+			ingredient, err := parseIngredient(name, amount)
+			if err != nil {
+				return core.Step{}, &ParseError{Line: lineNumber, Message: err.Error()}
+			}
+			addIngredient(recipe, ingredient, hasAmount && len(strings.TrimSpace(amount)) > 0)
+			if !containsString(step.Ingredients, ingredient.Name) {
+				step.Ingredients = append(step.Ingredients, ingredient.Name)
+			}
+			plain.WriteString(ingredient.Name)
+		case '#':
+			if !containsString(recipe.Cookware, name) {
+				recipe.Cookware = append(recipe.Cookware, name)
+			}
+			plain.WriteString(name)
+		case '~':
+			// without braces a tilde is prose like "stir ~5 minutes", only a timer block can be malformed
+			if !hasAmount {
+				plain.WriteString(text[i:next])
+				break
+			}
+			timer, timerText, err := parseTimer(name, amount)
+			if err != nil {
+				return core.Step{}, &ParseError{Line: lineNumber, Message: err.Error()}
+			}
+			step.Timers = append(step.Timers, timer)
+			plain.WriteString(timerText)
+		}
+		i = next
+	}
+
+	step.Text = strings.Join(strings.Fields(plain.String()), " ")
+	return step, nil
+}
+
+// scanComponent reads the name and the optional {amount} of an ingredient, cookware or timer.
+// Multi-word names are only allowed if they are terminated by braces.
+func scanComponent(text string, start int) (string, string, bool, int) {
+	if brace := strings.IndexByte(text[start:], '{'); brace >= 0 {
+		candidate := text[start : start+brace]
+		if !strings.ContainsAny(candidate, "@#~{}.,;:!?()") {
+			end := strings.IndexByte(text[start+brace:], '}')
+			if end >= 0 {
+				amount := text[start+brace+1 : start+brace+end]
+				return strings.TrimSpace(candidate), amount, true, start + brace + end + 1
+			}
+		}
+	}
+
+	end := start
+	for end < len(text) {
+		r := rune(text[end])
+		if r >= 0x80 {
+			// keep multi-byte characters like umlauts as part of the word
+			end++
+			continue
+		}
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '-' {
+			break
+		}
+		end++
+	}
+
+	return text[start:end], "", false, end
+}
+
+func parseIngredient(name string, amount string) (core.Ingredient, error) {
+	ingredient := core.Ingredient{Name: name}
+
+	quantity, unit := splitAmount(amount)
+	if len(quantity) == 0 {
+		ingredient.Unit = unit
+		return ingredient, nil
+	}
+
+	number, err := parseQuantity(quantity)
+	if err != nil {
+		// non-numeric quantities like "a pinch" are kept as unit
+		ingredient.Unit = strings.TrimSpace(strings.Join([]string{quantity, unit}, " "))
+		return ingredient, nil
+	}
+	ingredient.Quantity = number
+	ingredient.Unit = unit
+
+	return ingredient, nil
+}
+
+func addIngredient(recipe *core.Recipe, ingredient core.Ingredient, hasAmount bool) {
+	for i := range recipe.Ingredients {
+		existing := &recipe.Ingredients[i]
+		if !strings.EqualFold(existing.Name, ingredient.Name) {
+			continue
+		}
+		if !hasAmount {
+			return
+		}
+		if existing.Unit == ingredient.Unit {
+			existing.Quantity += ingredient.Quantity
+			return
+		}
+	}
+
+	recipe.Ingredients = append(recipe.Ingredients, ingredient)
+}
+
+func parseTimer(name string, amount string) (core.Timer, string, error) {
+	quantity, unit := splitAmount(amount)
+	number, err := parseQuantity(quantity)
+	if err != nil {
+		return core.Timer{}, "", fmt.Errorf("timer duration '%s' not valid", amount)
+	}
+
+	durationUnit, ok := durationUnits[strings.ToLower(unit)]
+	if !ok {
+		return core.Timer{}, "", fmt.Errorf("timer unit '%s' not valid", unit)
+	}
+
+	timer := core.Timer{
+		Name:     name,
+		Duration: time.Duration(number * float64(durationUnit)),
+	}
+	return timer, strings.TrimSpace(quantity + " " + unit), nil
+}
+
+var durationUnits = map[string]time.Duration{
+	"s":        time.Second,
+	"sec":      time.Second,
+	"secs":     time.Second,
+	"second":   time.Second,
+	"seconds":  time.Second,
+	"sekunde":  time.Second,
+	"sekunden": time.Second,
+	"m":        time.Minute,
+	"min":      time.Minute,
+	"mins":     time.Minute,
+	"minute":   time.Minute,
+	"minutes":  time.Minute,
+	"minuten":  time.Minute,
+	"h":        time.Hour,
+	"hr":       time.Hour,
+	"hrs":      time.Hour,
+	"hour":     time.Hour,
+	"hours":    time.Hour,
+	"stunde":   time.Hour,
+	"stunden":  time.Hour,
+}
+
+func splitAmount(amount string) (string, string) {
+	parts := strings.SplitN(amount, "%", 2)
+	quantity := strings.TrimSpace(parts[0])
+	if len(parts) == 1 {
+		return quantity, ""
+	}
+
+	return quantity, strings.TrimSpace(parts[1])
+}
+
+func parseQuantity(quantity string) (float64, error) {
+	quantity = strings.ReplaceAll(strings.TrimSpace(quantity), ",", ".")
+	if parts := strings.SplitN(quantity, "/", 2); len(parts) == 2 {
+		numerator, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+		if err != nil {
+			return 0, err
+		}
+		denominator, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+		if err != nil {
+			return 0, err
+		}
+		if denominator == 0 {
+			return 0, fmt.Errorf("division by zero in quantity '%s'", quantity)
+		}
+		return numerator / denominator, nil
+	}
+
+	return strconv.ParseFloat(quantity, 64)
+}
+
+func splitList(text string) []string {
+	var values []string
+	for _, value := range strings.Split(text, ",") {
+		if value = strings.Trim(strings.TrimSpace(value), `"'`); len(value) > 0 {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,46 @@
+package cooklang
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTimer(t *testing.T) {
+	recipe, _, err := Parse(strings.NewReader("Bake for ~bake{25%minutes}.\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	step := recipe.Steps[0]
+	if step.Text != "Bake for 25 minutes." {
+		t.Errorf("text = %q", step.Text)
+	}
+	if len(step.Timers) != 1 || step.Timers[0].Name != "bake" || step.Timers[0].Duration != 25*time.Minute {
+		t.Errorf("timers = %+v, want bake for 25 minutes", step.Timers)
+	}
+}
+
+func TestParseTildeInProse(t *testing.T) {
+	recipe, _, err := Parse(strings.NewReader("Stir ~5 minutes until ~thick, then serve.\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	step := recipe.Steps[0]
+	if step.Text != "Stir ~5 minutes until ~thick, then serve." {
+		t.Errorf("text = %q", step.Text)
+	}
+	if len(step.Timers) != 0 {
+		t.Errorf("timers = %+v, want none", step.Timers)
+	}
+}
+
+func TestParseMalformedTimer(t *testing.T) {
+	for _, input := range []string{"Bake ~{soon}.\n", "Bake ~{25%fortnights}.\n"} {
+		_, _, err := Parse(strings.NewReader(input))
+		if _, ok := err.(*ParseError); !ok {
+			t.Errorf("Parse(%q) error = %v, want ParseError", input, err)
+		}
+	}
+}
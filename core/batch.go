@@ -0,0 +1,79 @@
+package core
+
+import "fmt"
+
+const (
+	BatchOperationCreate = "create"
+	BatchOperationUpdate = "update"
+	BatchOperationDelete = "delete"
+)
+
+// RecipeOperation is one operation of a recipe batch. Updates and deletes need the id and the
+// version of the recipe like UpdateRecipe and DeleteRecipe.
+type RecipeOperation struct {
+	Operation string
+	Recipe    Recipe
+}
+
+// SourceOperation is one operation of a source batch, see RecipeOperation.
+type SourceOperation struct {
+	Operation string
+	Source    Source
+}
+
+// BatchResult holds the outcome of every operation of a batch in the order of the operations.
+// Atomic is set if the batch ran in a transaction, then either all operations were applied or,
+// if one of them failed, none.
+type BatchResult struct {
+	Atomic bool
+	Items  []BatchItemResult
+}
+
+// BatchItemResult is the outcome of a single operation. ID and Version describe the recipe or
+// source after the operation, Err is set if the operation failed or was not applied.
+type BatchItemResult struct {
+	ID      string
+	Version int
+	Err     error
+}
+
+// Failed reports whether any operation of the batch failed.
+func (result BatchResult) Failed() bool {
+	for _, item := range result.Items {
+		if item.Err != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AbortBatch returns the results of a batch of which no operation is applied because of the
+// given errors. Operations without an error are reported as aborted.
+func AbortBatch(errs []error) BatchResult {
+	items := make([]BatchItemResult, len(errs))
+	for i, err := range errs {
+		if err == nil {
+			err = &BatchAbortedError{}
+		}
+		items[i] = BatchItemResult{Err: err}
+	}
+
+	return BatchResult{Atomic: true, Items: items}
+}
+
+type BatchOperationNotValidError struct {
+	Operation string
+}
+
+func (err *BatchOperationNotValidError) Error() string {
+	return fmt.Sprintf("batch operation '%s' not valid", err.Operation)
+}
+
+// BatchAbortedError is reported for operations that were not applied, or rolled back, because
+// another operation of the same batch failed.
+type BatchAbortedError struct{}
+
+func (err *BatchAbortedError) Error() string {
+	return "not applied because another operation of the batch failed"
+}
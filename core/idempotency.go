@@ -0,0 +1,50 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// IdempotencyRecord remembers the response to a request sent with an idempotency key, so a retry
+// of the request gets the same response instead of creating the resource again. Status is zero
+// while the first request is still being processed.
+type IdempotencyRecord struct {
+	// Key combines the user, the request path and the key sent by the client.
+	Key string `bson:"_id"`
+	// Fingerprint is a hash of the request body, a key must not be reused for another request.
+	Fingerprint string    `bson:"fingerprint"`
+	CreatedAt   time.Time `bson:"createdAt"`
+	Status      int       `bson:"status,omitempty"`
+	Header      Header    `bson:"header,omitempty"`
+	Body        []byte    `bson:"body,omitempty"`
+}
+
+// Header holds the response headers replayed for a retried request.
+type Header map[string]string
+
+// IdempotencyRepository stores idempotency records. Records expire after a day, clients must not
+// retry a request later than that.
+type IdempotencyRepository interface {
+	GetIdempotencyRecord(ctx context.Context, key string) (IdempotencyRecord, error)
+	// AddIdempotencyRecord returns an IdempotencyKeyExistsError if a record with the key exists.
+	AddIdempotencyRecord(ctx context.Context, record IdempotencyRecord) error
+	CompleteIdempotencyRecord(ctx context.Context, record IdempotencyRecord) error
+	DeleteIdempotencyRecord(ctx context.Context, key string) error
+}
+
+type IdempotencyKeyExistsError struct {
+	Key string
+}
+
+func (err *IdempotencyKeyExistsError) Error() string {
+	return fmt.Sprintf("idempotency key '%s' already used", err.Key)
+}
+
+type IdempotencyRecordNotFoundError struct {
+	Key string
+}
+
+func (err *IdempotencyRecordNotFoundError) Error() string {
+	return fmt.Sprintf("idempotency record with key '%s' not found", err.Key)
+}
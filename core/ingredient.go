@@ -0,0 +1,154 @@
+package core
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var unicodeFractions = map[rune]float64{
+	'¼': 0.25,
+	'½': 0.5,
+	'¾': 0.75,
+	'⅓': 1.0 / 3,
+	'⅔': 2.0 / 3,
+	'⅛': 0.125,
+}
+
+var ingredientUnits = map[string]bool{
+	"g": true, "gr": true, "kg": true, "mg": true, "ml": true, "cl": true, "dl": true, "l": true,
+	"tsp": true, "tbsp": true, "cup": true, "cups": true, "oz": true, "lb": true, "lbs": true,
+	"pinch": true, "clove": true, "cloves": true, "can": true, "cans": true, "slice": true, "slices": true,
+	"el": true, "tl": true, "msp": true, "prise": true, "prisen": true, "stk": true, "stück": true,
+	"pck": true, "pkg": true, "päckchen": true, "dose": true, "dosen": true, "bund": true, "zehe": true, "zehen": true,
+	"becher": true, "tasse": true, "tassen": true, "scheibe": true, "scheiben": true,
+}
+
+// String formats the ingredient the way ParseIngredient reads it, e.g. "125 g flour".
+func (ingredient Ingredient) String() string {
+	var parts []string
+	if ingredient.Quantity != 0 {
+		parts = append(parts, FormatQuantity(ingredient.Quantity))
+	}
+	if len(ingredient.Unit) > 0 {
+		parts = append(parts, ingredient.Unit)
+	}
+	if len(ingredient.Name) > 0 {
+		parts = append(parts, ingredient.Name)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// FormatQuantity formats a quantity with at most two decimals.
+func FormatQuantity(quantity float64) string {
+	return strconv.FormatFloat(math.Round(quantity*100)/100, 'f', -1, 64)
+}
+
+// ParseIngredient splits a free text ingredient line like "1 1/2 cups sugar" or "200g Mehl"
+// into quantity, unit and name. Lines without a leading quantity are returned as name only.
+func ParseIngredient(text string) Ingredient {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return Ingredient{}
+	}
+
+	quantity, ok := parseIngredientQuantity(fields[0])
+	if !ok {
+		// quantity and unit might be written without space like "200g"
+		number, unit := splitNumberPrefix(fields[0])
+		quantity, ok = parseIngredientQuantity(number)
+		if !ok || !ingredientUnits[strings.ToLower(unit)] {
+			return Ingredient{Name: strings.Join(fields, " ")}
+		}
+		return Ingredient{
+			Name:     strings.Join(fields[1:], " "),
+			Quantity: quantity,
+			Unit:     unit,
+		}
+	}
+	fields = fields[1:]
+
+	if len(fields) > 0 {
+		if fraction, ok := parseIngredientQuantity(fields[0]); ok && fraction < 1 {
+			quantity += fraction
+			fields = fields[1:]
+		}
+	}
+
+	ingredient := Ingredient{Quantity: quantity}
+	if len(fields) > 1 && ingredientUnits[strings.ToLower(strings.TrimSuffix(fields[0], "."))] {
+		ingredient.Unit = strings.TrimSuffix(fields[0], ".")
+		fields = fields[1:]
+	}
+	ingredient.Name = strings.Join(fields, " ")
+
+	return ingredient
+}
+
+func parseIngredientQuantity(text string) (float64, bool) {
+	if len(text) == 0 {
+		return 0, false
+	}
+
+	// ranges like "2-3" use the lower bound
+	if index := strings.IndexAny(text, "-–"); index > 0 {
+		text = text[:index]
+	}
+
+	runes := []rune(text)
+	if fraction, ok := unicodeFractions[runes[len(runes)-1]]; ok {
+		if len(runes) == 1 {
+			return fraction, true
+		}
+		whole, err := strconv.Atoi(string(runes[:len(runes)-1]))
+		if err != nil {
+			return 0, false
+		}
+		return float64(whole) + fraction, true
+	}
+
+	if parts := strings.SplitN(text, "/", 2); len(parts) == 2 {
+		numerator, err := strconv.ParseFloat(parts[0], 64)
+		if err != nil {
+			return 0, false
+		}
+		denominator, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil || denominator == 0 {
+			return 0, false
+		}
+		return numerator / denominator, true
+	}
+
+	quantity, err := strconv.ParseFloat(strings.ReplaceAll(text, ",", "."), 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return quantity, true
+}
+
+func splitNumberPrefix(text string) (string, string) {
+	for i, r := range text {
+		if !unicode.IsDigit(r) && r != '.' && r != ',' && r != '/' {
+			return text[:i], text[i:]
+		}
+	}
+
+	return text, ""
+}
+
+// NewStep creates a step from free text and links the ingredients mentioned in it.
+func NewStep(text string, ingredients []Ingredient) Step {
+	step := Step{Text: strings.Join(strings.Fields(text), " ")}
+
+	lowerText := strings.ToLower(step.Text)
+	for _, ingredient := range ingredients {
+		if len(ingredient.Name) > 0 && strings.Contains(lowerText, strings.ToLower(ingredient.Name)) {
+			step.Ingredients = append(step.Ingredients, ingredient.Name)
+		}
+	}
+
+	return step
+}
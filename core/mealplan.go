@@ -0,0 +1,75 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	MealSlotBreakfast = "breakfast"
+	MealSlotLunch     = "lunch"
+	MealSlotSnack     = "snack"
+	MealSlotDinner    = "dinner"
+)
+
+type mealSlot = string
+
+type MealPlan struct {
+	ID      primitive.ObjectID `bson:"_id,omitempty"`
+	Title   string             `bson:"title,omitempty"`
+	Entries []MealPlanEntry    `bson:"entries,omitempty"`
+}
+
+type MealPlanEntry struct {
+	Date     time.Time          `bson:"date"`
+	Slot     mealSlot           `bson:"slot,omitempty"`
+	Recipe   primitive.ObjectID `bson:"recipe,omitempty"`
+	Servings int                `bson:"servings,omitempty"`
+}
+
+type MealPlanRepository interface {
+	GetMealPlans(ctx context.Context) ([]MealPlan, error)
+	GetMealPlanByID(ctx context.Context, id string) (MealPlan, error)
+	AddMealPlan(ctx context.Context, mealPlan *MealPlan) error
+	UpdateMealPlan(ctx context.Context, mealPlan MealPlan) error
+	DeleteMealPlan(ctx context.Context, mealPlan MealPlan) error
+}
+
+func IsMealSlotValid(slot string) bool {
+	return slot == MealSlotBreakfast || slot == MealSlotLunch || slot == MealSlotSnack || slot == MealSlotDinner
+}
+
+type MealSlotNotValidError struct {
+	Slot mealSlot
+}
+
+func (err *MealSlotNotValidError) Error() string {
+	return fmt.Sprintf("meal slot '%s' not valid", err.Slot)
+}
+
+type MealPlanNotFoundError struct {
+	ID string
+}
+
+func (err *MealPlanNotFoundError) Error() string {
+	return fmt.Sprintf("meal plan with id '%s' not found", err.ID)
+}
+
+type MealPlanIDNotValidError struct {
+	ID string
+}
+
+func (err *MealPlanIDNotValidError) Error() string {
+	return fmt.Sprintf("meal plan id '%s' not valid", err.ID)
+}
+
+type DateNotValidError struct {
+	Date string
+}
+
+func (err *DateNotValidError) Error() string {
+	return fmt.Sprintf("date '%s' not valid", err.Date)
+}
@@ -0,0 +1,202 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type PantryItem struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty"`
+	Name       string             `bson:"name,omitempty"`
+	Quantity   float64            `bson:"quantity,omitempty"`
+	Unit       string             `bson:"unit,omitempty"`
+	BestBefore time.Time          `bson:"bestBefore,omitempty"`
+}
+
+// RecipeCoverage tells how much of a recipe can be cooked from the pantry. Missing ingredients
+// only list the quantity still to buy.
+type RecipeCoverage struct {
+	Recipe    Recipe
+	Available []Ingredient
+	Missing   []Ingredient
+}
+
+type PantryRepository interface {
+	GetPantryItems(ctx context.Context) ([]PantryItem, error)
+	GetPantryItemByID(ctx context.Context, id string) (PantryItem, error)
+	AddPantryItem(ctx context.Context, pantryItem *PantryItem) error
+	UpdatePantryItem(ctx context.Context, pantryItem PantryItem) error
+	DeletePantryItem(ctx context.Context, pantryItem PantryItem) error
+	RestockPantry(ctx context.Context, items []ShoppingListItem) error
+}
+
+// IsExpired reports whether the best-before date of the item has passed. Items without a
+// best-before date never expire.
+func (item PantryItem) IsExpired(now time.Time) bool {
+	return !item.BestBefore.IsZero() && item.BestBefore.Before(now)
+}
+
+// Coverage returns the share of ingredients available, between 0 and 1.
+func (coverage RecipeCoverage) Coverage() float64 {
+	total := len(coverage.Available) + len(coverage.Missing)
+	if total == 0 {
+		return 0
+	}
+
+	return float64(len(coverage.Available)) / float64(total)
+}
+
+// RankRecipesByPantry checks the ingredients of every recipe against the pantry and sorts the
+// recipes with the best coverage first. Expired pantry items are not taken into account.
+func RankRecipesByPantry(recipes []Recipe, pantryItems []PantryItem, now time.Time) []RecipeCoverage {
+	stock := map[string][]PantryItem{}
+	for _, item := range pantryItems {
+		if item.IsExpired(now) {
+			continue
+		}
+		name := strings.ToLower(strings.TrimSpace(item.Name))
+		stock[name] = append(stock[name], item)
+	}
+
+	coverages := make([]RecipeCoverage, 0, len(recipes))
+	for _, recipe := range recipes {
+		coverage := RecipeCoverage{Recipe: recipe}
+		for _, ingredient := range recipe.Ingredients {
+			if len(strings.TrimSpace(ingredient.Name)) == 0 {
+				continue
+			}
+
+			shortfall := pantryShortfall(ingredient, stock[strings.ToLower(strings.TrimSpace(ingredient.Name))])
+			if shortfall == nil {
+				coverage.Available = append(coverage.Available, ingredient)
+			} else {
+				coverage.Missing = append(coverage.Missing, *shortfall)
+			}
+		}
+		coverages = append(coverages, coverage)
+	}
+
+	sort.SliceStable(coverages, func(i, j int) bool {
+		if coverages[i].Coverage() != coverages[j].Coverage() {
+			return coverages[i].Coverage() > coverages[j].Coverage()
+		}
+		if len(coverages[i].Missing) != len(coverages[j].Missing) {
+			return len(coverages[i].Missing) < len(coverages[j].Missing)
+		}
+		return strings.ToLower(coverages[i].Recipe.Title) < strings.ToLower(coverages[j].Recipe.Title)
+	})
+
+	return coverages
+}
+
+// pantryShortfall returns the part of the ingredient not in stock or nil if there is enough.
+// Stock kept in a unit that cannot be converted, or without a quantity, is assumed to suffice.
+func pantryShortfall(ingredient Ingredient, stock []PantryItem) *Ingredient {
+	if len(stock) == 0 {
+		return &ingredient
+	}
+	if ingredient.Quantity == 0 {
+		return nil
+	}
+
+	available := 0.0
+	for _, item := range stock {
+		if item.Quantity == 0 {
+			return nil
+		}
+		quantity, ok := ConvertQuantity(item.Quantity, item.Unit, ingredient.Unit)
+		if !ok {
+			return nil
+		}
+		available += quantity
+	}
+
+	if available >= ingredient.Quantity {
+		return nil
+	}
+
+	shortfall := ingredient
+	shortfall.Quantity = ingredient.Quantity - available
+	return &shortfall
+}
+
+// RestockPantryItems adds the checked items of a shopping list to the pantry. Items already in
+// the pantry in a convertible unit are returned as updated with the quantity to add in their
+// unit, so it can be added to the stored quantity. All others are returned as added.
+func RestockPantryItems(pantryItems []PantryItem, items []ShoppingListItem) (updated []PantryItem, added []PantryItem) {
+	stock := make([]PantryItem, len(pantryItems))
+	copy(stock, pantryItems)
+
+	increments := make([]float64, len(pantryItems))
+	var updatedIndexes []int
+	for _, item := range items {
+		if !item.Checked || len(strings.TrimSpace(item.Name)) == 0 {
+			continue
+		}
+
+		index := -1
+		for i := range stock {
+			if !strings.EqualFold(strings.TrimSpace(stock[i].Name), strings.TrimSpace(item.Name)) {
+				continue
+			}
+			if quantity, ok := ConvertQuantity(item.Quantity, item.Unit, stock[i].Unit); ok {
+				stock[i].Quantity += quantity
+				if i < len(pantryItems) {
+					increments[i] += quantity
+				}
+				index = i
+				break
+			}
+		}
+
+		if index < 0 {
+			stock = append(stock, PantryItem{
+				Name:     item.Name,
+				Quantity: item.Quantity,
+				Unit:     item.Unit,
+			})
+		} else if index < len(pantryItems) && !containsIndex(updatedIndexes, index) {
+			updatedIndexes = append(updatedIndexes, index)
+		}
+	}
+
+	for _, i := range updatedIndexes {
+		increment := pantryItems[i]
+		increment.Quantity = increments[i]
+		updated = append(updated, increment)
+	}
+	added = stock[len(pantryItems):]
+
+	return updated, added
+}
+
+func containsIndex(indexes []int, index int) bool {
+	for _, candidate := range indexes {
+		if candidate == index {
+			return true
+		}
+	}
+
+	return false
+}
+
+type PantryItemNotFoundError struct {
+	ID string
+}
+
+func (err *PantryItemNotFoundError) Error() string {
+	return fmt.Sprintf("pantry item with id '%s' not found", err.ID)
+}
+
+type PantryItemIDNotValidError struct {
+	ID string
+}
+
+func (err *PantryItemIDNotValidError) Error() string {
+	return fmt.Sprintf("pantry item id '%s' not valid", err.ID)
+}
@@ -0,0 +1,32 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestRestockPantryItems(t *testing.T) {
+	flour := PantryItem{ID: primitive.NewObjectID(), Name: "Flour", Quantity: 1, Unit: UnitKilogram}
+	eggs := PantryItem{ID: primitive.NewObjectID(), Name: "Eggs", Quantity: 6}
+	items := []ShoppingListItem{
+		{Name: "flour", Quantity: 500, Unit: UnitGram, Checked: true},
+		{Name: "Flour ", Quantity: 250, Unit: UnitGram, Checked: true},
+		{Name: "Eggs", Quantity: 10, Checked: false},
+		{Name: "Milk", Quantity: 1, Unit: "l", Checked: true},
+		{Name: "Milk", Quantity: 500, Unit: "ml", Checked: true},
+	}
+
+	updated, added := RestockPantryItems([]PantryItem{flour, eggs}, items)
+
+	// updated items hold the quantity to add, not the new total
+	wantUpdated := []PantryItem{{ID: flour.ID, Name: "Flour", Quantity: 0.75, Unit: UnitKilogram}}
+	if !reflect.DeepEqual(updated, wantUpdated) {
+		t.Errorf("updated = %+v, want %+v", updated, wantUpdated)
+	}
+	wantAdded := []PantryItem{{Name: "Milk", Quantity: 1.5, Unit: "l"}}
+	if !reflect.DeepEqual(added, wantAdded) {
+		t.Errorf("added = %+v, want %+v", added, wantAdded)
+	}
+}
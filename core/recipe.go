@@ -3,25 +3,73 @@ package core
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type Recipe struct {
 	ID               primitive.ObjectID `bson:"_id,omitempty"`
+	Version          int                `bson:"version"`
 	Title            string             `bson:"title,omitempty"`
 	Source           primitive.ObjectID `bson:"source,omitempty"`
 	SourceAnnotation string             `bson:"sourceAnnotation,omitempty"`
 	Category         string             `bson:"category,omitempty"`
 	Allergens        []string           `bson:"allergens,omitempty"`
+	Tags             []string           `bson:"tags,omitempty"`
+	Servings         int                `bson:"servings,omitempty"`
+	Ingredients      []Ingredient       `bson:"ingredients,omitempty"`
+	Cookware         []string           `bson:"cookware,omitempty"`
+	Steps            []Step             `bson:"steps,omitempty"`
+	// DeletedAt is set while the recipe is in the trash.
+	DeletedAt time.Time `bson:"deletedAt,omitempty"`
+}
+
+type Ingredient struct {
+	Name     string  `bson:"name,omitempty"`
+	Quantity float64 `bson:"quantity,omitempty"`
+	Unit     string  `bson:"unit,omitempty"`
+}
+
+type Step struct {
+	Text        string   `bson:"text,omitempty"`
+	Ingredients []string `bson:"ingredients,omitempty"`
+	Timers      []Timer  `bson:"timers,omitempty"`
+}
+
+type Timer struct {
+	Name     string        `bson:"name,omitempty"`
+	Duration time.Duration `bson:"duration,omitempty"`
+}
+
+type RecipeFilter struct {
+	Title    string
+	Category string
+	SourceID string
+	Tag      string
+	// Offset and Limit select a page of the recipes ordered by id, a Limit of 0 selects all.
+	Offset int
+	Limit  int
 }
 
 type RecipeRepository interface {
-	GetRecipes(ctx context.Context) ([]Recipe, error)
+	GetRecipes(ctx context.Context, filter RecipeFilter) ([]Recipe, error)
 	GetRecipeByID(ctx context.Context, id string) (Recipe, error)
 	AddRecipe(ctx context.Context, recipe *Recipe) error
 	UpdateRecipe(ctx context.Context, recipe Recipe) error
 	DeleteRecipe(ctx context.Context, recipe Recipe) error
+	// ImportRecipes stores restored recipes with their ids. Replaced recipes get a version above
+	// the stored one, so an ETag never stands for two contents.
+	ImportRecipes(ctx context.Context, recipes []Recipe) error
+	// BatchRecipes applies the operations, in a transaction if the backend supports it.
+	BatchRecipes(ctx context.Context, operations []RecipeOperation) (BatchResult, error)
+	// PatchRecipe reads the recipe, lets patch change it and writes it back unless it was changed
+	// in the meantime. It returns the recipe as stored.
+	PatchRecipe(ctx context.Context, id string, patch func(recipe *Recipe) error) (Recipe, error)
+	GetDeletedRecipes(ctx context.Context) ([]Recipe, error)
+	RestoreRecipe(ctx context.Context, id string) error
+	PurgeRecipe(ctx context.Context, id string) error
+	PurgeDeletedRecipes(ctx context.Context, deletedBefore time.Time) (int64, error)
 }
 
 type RecipeNotFoundError struct {
@@ -0,0 +1,123 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	RecipeFieldTitle            = "title"
+	RecipeFieldSource           = "sourceId"
+	RecipeFieldSourceAnnotation = "sourceAnnotation"
+	RecipeFieldCategory         = "category"
+	RecipeFieldAllergens        = "allergens"
+	RecipeFieldTags             = "tags"
+	RecipeFieldServings         = "servings"
+	RecipeFieldIngredients      = "ingredients"
+	RecipeFieldCookware         = "cookware"
+	RecipeFieldSteps            = "steps"
+)
+
+// Revision is the state of a recipe after a change. Revisions are numbered per recipe starting
+// at 1 and never changed once written.
+type Revision struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	Recipe    primitive.ObjectID `bson:"recipe"`
+	Number    int                `bson:"number"`
+	Author    string             `bson:"author,omitempty"`
+	CreatedAt time.Time          `bson:"createdAt"`
+	Snapshot  Recipe             `bson:"snapshot"`
+}
+
+// FieldChange holds the old and new value of a recipe field, the values have the type of the field
+// in Recipe.
+type FieldChange struct {
+	Field string
+	Old   interface{}
+	New   interface{}
+}
+
+type RevisionRepository interface {
+	GetRevisions(ctx context.Context, recipeID string) ([]Revision, error)
+	GetRevision(ctx context.Context, recipeID string, number int) (Revision, error)
+	AddRevision(ctx context.Context, revision *Revision) error
+}
+
+// DiffRecipes lists the fields that differ between two states of a recipe.
+func DiffRecipes(old Recipe, new Recipe) []FieldChange {
+	fields := []struct {
+		name string
+		old  interface{}
+		new  interface{}
+	}{
+		{RecipeFieldTitle, old.Title, new.Title},
+		{RecipeFieldSource, old.Source, new.Source},
+		{RecipeFieldSourceAnnotation, old.SourceAnnotation, new.SourceAnnotation},
+		{RecipeFieldCategory, old.Category, new.Category},
+		{RecipeFieldAllergens, old.Allergens, new.Allergens},
+		{RecipeFieldTags, old.Tags, new.Tags},
+		{RecipeFieldServings, old.Servings, new.Servings},
+		{RecipeFieldIngredients, old.Ingredients, new.Ingredients},
+		{RecipeFieldCookware, old.Cookware, new.Cookware},
+		{RecipeFieldSteps, old.Steps, new.Steps},
+	}
+
+	var changes []FieldChange
+	for _, field := range fields {
+		if isEmptyValue(field.old) && isEmptyValue(field.new) {
+			continue
+		}
+		if !reflect.DeepEqual(field.old, field.new) {
+			changes = append(changes, FieldChange{Field: field.name, Old: field.old, New: field.new})
+		}
+	}
+
+	return changes
+}
+
+// RecordRevision appends the new state of the recipe to its history. Recipes created before
+// revisions were kept get their previous state recorded first, so it can be restored.
+func RecordRevision(ctx context.Context, revisionRepository RevisionRepository, previous *Recipe, recipe Recipe, author string) error {
+	if previous != nil {
+		revisions, err := revisionRepository.GetRevisions(ctx, previous.ID.Hex())
+		if err != nil {
+			return err
+		}
+		if len(revisions) == 0 {
+			err = revisionRepository.AddRevision(ctx, &Revision{
+				Recipe:    previous.ID,
+				CreatedAt: time.Now().UTC().Truncate(time.Millisecond),
+				Snapshot:  *previous,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return revisionRepository.AddRevision(ctx, &Revision{
+		Recipe:    recipe.ID,
+		Author:    author,
+		CreatedAt: time.Now().UTC().Truncate(time.Millisecond),
+		Snapshot:  recipe,
+	})
+}
+
+// isEmptyValue treats nil and empty slices the same, mongo decodes missing arrays as nil.
+func isEmptyValue(value interface{}) bool {
+	v := reflect.ValueOf(value)
+	return v.Kind() == reflect.Slice && v.Len() == 0
+}
+
+type RevisionNotFoundError struct {
+	RecipeID string
+	Number   int
+}
+
+func (err *RevisionNotFoundError) Error() string {
+	return fmt.Sprintf("revision %d of recipe with id '%s' not found", err.Number, err.RecipeID)
+}
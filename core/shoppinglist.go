@@ -0,0 +1,212 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	AisleProduce   = "produce"
+	AisleBakery    = "bakery"
+	AisleDairy     = "dairy"
+	AisleMeat      = "meat and fish"
+	AisleFrozen    = "frozen"
+	AisleSpices    = "spices"
+	AislePantry    = "pantry"
+	AisleBeverages = "beverages"
+	AisleOther     = "other"
+)
+
+// aisleOrder is the order in which the aisles are usually passed in a store.
+var aisleOrder = []string{AisleProduce, AisleBakery, AisleMeat, AisleDairy, AisleFrozen, AislePantry, AisleSpices, AisleBeverages, AisleOther}
+
+// aisleKeywords assigns ingredients to aisles by parts of their name. The first match wins,
+// so more specific keywords like "coconut milk" come before "milk" and dairy comes before
+// spices to keep "unsalted butter" away from the salt.
+var aisleKeywords = []struct {
+	aisle    string
+	keywords []string
+}{
+	{AisleFrozen, []string{"frozen", "tiefkühl", "ice cream"}},
+	{AislePantry, []string{"coconut milk", "kokosmilch", "canned", "stock", "brühe", "broth"}},
+	{AisleProduce, []string{"bell pepper", "red pepper", "green pepper", "yellow pepper"}},
+	{AisleDairy, []string{"milk", "milch", "butter", "cream", "sahne", "cheese", "käse", "yogurt", "yoghurt", "joghurt", "quark", "egg", "eggs", "ei", "eier", "parmesan", "mozzarella", "schmand"}},
+	{AisleSpices, []string{"salt", "salz", "pepper", "pfeffer", "paprika powder", "cinnamon", "zimt", "cumin", "kreuzkümmel", "nutmeg", "muskat", "oregano", "thyme", "thymian", "curry", "chili flakes", "vanilla", "vanille", "bay lea", "lorbeer"}},
+	{AisleMeat, []string{"chicken", "hähnchen", "huhn", "beef", "rind", "pork", "schwein", "bacon", "speck", "ham", "schinken", "sausage", "wurst", "mince", "hack", "fish", "fisch", "salmon", "lachs", "tuna", "thunfisch", "shrimp", "garnelen"}},
+	{AisleBakery, []string{"bread", "brot", "bun", "brötchen", "baguette", "tortilla", "toast"}},
+	{AisleProduce, []string{"onion", "zwiebel", "garlic", "knoblauch", "tomato", "tomate", "potato", "kartoffel", "carrot", "karotte", "möhre", "lemon", "zitrone", "lime", "limette", "apple", "apfel", "banana", "banane", "pepper", "paprika", "lettuce", "salat", "spinach", "spinat", "zucchini", "mushroom", "pilz", "champignon", "ginger", "ingwer", "herb", "parsley", "petersilie", "basil", "basilikum", "cilantro", "koriander", "leek", "lauch", "celery", "sellerie", "cucumber", "gurke", "avocado", "berries", "beeren"}},
+	{AisleBeverages, []string{"wine", "wein", "beer", "bier", "juice", "saft"}},
+	{AislePantry, []string{"flour", "mehl", "sugar", "zucker", "rice", "reis", "pasta", "nudel", "spaghetti", "oil", "öl", "vinegar", "essig", "honey", "honig", "lentil", "linsen", "bean", "bohne", "oats", "hafer", "baking", "backpulver", "yeast", "hefe", "nuts", "nüsse", "chocolate", "schokolade", "sauce", "soße", "mustard", "senf"}},
+}
+
+type ShoppingList struct {
+	ID    primitive.ObjectID `bson:"_id,omitempty"`
+	Title string             `bson:"title,omitempty"`
+	Items []ShoppingListItem `bson:"items,omitempty"`
+	// CompletedAt is set once the shopping is done.
+	CompletedAt time.Time `bson:"completedAt,omitempty"`
+}
+
+// ShoppingListItem is addressed by its ID, positions change when the list is edited.
+type ShoppingListItem struct {
+	ID       primitive.ObjectID `bson:"id,omitempty"`
+	Name     string             `bson:"name,omitempty"`
+	Quantity float64            `bson:"quantity,omitempty"`
+	Unit     string             `bson:"unit,omitempty"`
+	Aisle    string             `bson:"aisle,omitempty"`
+	Checked  bool               `bson:"checked"`
+}
+
+// PlannedRecipe is a recipe to be cooked for the given number of servings.
+type PlannedRecipe struct {
+	Recipe   Recipe
+	Servings int
+}
+
+type ShoppingListRepository interface {
+	GetShoppingLists(ctx context.Context) ([]ShoppingList, error)
+	GetShoppingListByID(ctx context.Context, id string) (ShoppingList, error)
+	AddShoppingList(ctx context.Context, shoppingList *ShoppingList) error
+	UpdateShoppingList(ctx context.Context, shoppingList ShoppingList) error
+	DeleteShoppingList(ctx context.Context, shoppingList ShoppingList) error
+	CheckShoppingListItem(ctx context.Context, shoppingList ShoppingList, itemID string, checked bool) error
+	CompleteShoppingList(ctx context.Context, shoppingList *ShoppingList) error
+	// ReopenShoppingList undoes CompleteShoppingList, e.g. if restocking the pantry failed.
+	ReopenShoppingList(ctx context.Context, shoppingList ShoppingList) error
+}
+
+// AisleOf returns the store aisle an ingredient is usually found in.
+func AisleOf(name string) string {
+	lowerName := strings.ToLower(name)
+	words := strings.FieldsFunc(lowerName, func(r rune) bool {
+		return r == ' ' || r == ',' || r == '-' || r == '(' || r == ')'
+	})
+
+	for _, entry := range aisleKeywords {
+		for _, keyword := range entry.keywords {
+			// short keywords like "ei" must match whole words
+			if len([]rune(keyword)) <= 3 {
+				for _, word := range words {
+					if word == keyword {
+						return entry.aisle
+					}
+				}
+				continue
+			}
+			if strings.Contains(lowerName, keyword) {
+				return entry.aisle
+			}
+		}
+	}
+
+	return AisleOther
+}
+
+// NewShoppingListItems scales the ingredients of the recipes to the planned servings and merges
+// identical ingredients. The items are sorted by aisle and name.
+func NewShoppingListItems(plannedRecipes []PlannedRecipe) []ShoppingListItem {
+	type key struct {
+		name string
+		unit string
+	}
+
+	var keys []key
+	items := map[key]*ShoppingListItem{}
+	for _, planned := range plannedRecipes {
+		factor := 1.0
+		if planned.Servings > 0 && planned.Recipe.Servings > 0 {
+			factor = float64(planned.Servings) / float64(planned.Recipe.Servings)
+		}
+
+		for _, ingredient := range planned.Recipe.Ingredients {
+			name := strings.TrimSpace(ingredient.Name)
+			if len(name) == 0 {
+				continue
+			}
+
+			quantity, unit := NormalizeQuantity(ingredient.Quantity*factor, ingredient.Unit)
+			k := key{name: strings.ToLower(name), unit: unit}
+			if item, ok := items[k]; ok {
+				item.Quantity += quantity
+				continue
+			}
+
+			keys = append(keys, k)
+			items[k] = &ShoppingListItem{
+				ID:       primitive.NewObjectID(),
+				Name:     name,
+				Quantity: quantity,
+				Unit:     unit,
+				Aisle:    AisleOf(name),
+			}
+		}
+	}
+
+	shoppingListItems := make([]ShoppingListItem, 0, len(keys))
+	for _, k := range keys {
+		item := *items[k]
+		item.Quantity, item.Unit = DisplayQuantity(item.Quantity, item.Unit)
+		shoppingListItems = append(shoppingListItems, item)
+	}
+
+	SortShoppingListItems(shoppingListItems)
+
+	return shoppingListItems
+}
+
+// SortShoppingListItems sorts the items in the order of the aisles in a store and by name.
+func SortShoppingListItems(items []ShoppingListItem) {
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].Aisle != items[j].Aisle {
+			return aisleRank(items[i].Aisle) < aisleRank(items[j].Aisle)
+		}
+		return strings.ToLower(items[i].Name) < strings.ToLower(items[j].Name)
+	})
+}
+
+func aisleRank(aisle string) int {
+	for i, candidate := range aisleOrder {
+		if candidate == aisle {
+			return i
+		}
+	}
+
+	return len(aisleOrder)
+}
+
+type ShoppingListNotFoundError struct {
+	ID string
+}
+
+func (err *ShoppingListNotFoundError) Error() string {
+	return fmt.Sprintf("shopping list with id '%s' not found", err.ID)
+}
+
+type ShoppingListIDNotValidError struct {
+	ID string
+}
+
+func (err *ShoppingListIDNotValidError) Error() string {
+	return fmt.Sprintf("shopping list id '%s' not valid", err.ID)
+}
+
+type ShoppingListItemNotFoundError struct {
+	ID string
+}
+
+func (err *ShoppingListItemNotFoundError) Error() string {
+	return fmt.Sprintf("shopping list item with id '%s' not found", err.ID)
+}
+
+type ShoppingListCompletedError struct {
+	ID string
+}
+
+func (err *ShoppingListCompletedError) Error() string {
+	return fmt.Sprintf("shopping list with id '%s' already completed", err.ID)
+}
@@ -3,6 +3,7 @@ package core
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -16,9 +17,12 @@ const (
 type sourceType = string
 
 type Source struct {
-	ID    primitive.ObjectID `bson:"_id,omitempty"`
-	Type  sourceType         `bson:"type,omitempty"`
-	Title string             `bson:"title,omitempty"`
+	ID      primitive.ObjectID `bson:"_id,omitempty"`
+	Version int                `bson:"version"`
+	Type    sourceType         `bson:"type,omitempty"`
+	Title   string             `bson:"title,omitempty"`
+	// DeletedAt is set while the source is in the trash.
+	DeletedAt time.Time `bson:"deletedAt,omitempty"`
 }
 
 type SourceRepository interface {
@@ -27,6 +31,21 @@ type SourceRepository interface {
 	AddSource(ctx context.Context, source *Source) error
 	UpdateSource(ctx context.Context, source Source) error
 	DeleteSource(ctx context.Context, source Source) error
+	// ImportSources stores restored sources like ImportRecipes.
+	ImportSources(ctx context.Context, sources []Source) error
+	// BatchSources applies the operations, in a transaction if the backend supports it.
+	BatchSources(ctx context.Context, operations []SourceOperation) (BatchResult, error)
+	// PatchSource reads the source, lets patch change it and writes it back unless it was changed
+	// in the meantime. It returns the source as stored.
+	PatchSource(ctx context.Context, id string, patch func(source *Source) error) (Source, error)
+	GetDeletedSources(ctx context.Context) ([]Source, error)
+	RestoreSource(ctx context.Context, id string) error
+	PurgeSource(ctx context.Context, id string) error
+	PurgeDeletedSources(ctx context.Context, deletedBefore time.Time) (int64, error)
+}
+
+func IsSourceTypeValid(sourceType string) bool {
+	return sourceType == SourceTypeBook || sourceType == SourceTypeUrl || sourceType == SourceTypeCustom
 }
 
 type SourceTypeNotValidError struct {
@@ -34,7 +53,7 @@ type SourceTypeNotValidError struct {
 }
 
 func (err *SourceTypeNotValidError) Error() string {
-	return fmt.Sprintf("source type '%s* not valid", err.SourceType)
+	return fmt.Sprintf("source type '%s' not valid", err.SourceType)
 }
 
 type SourceNotFoundError struct {
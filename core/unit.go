@@ -0,0 +1,85 @@
+package core
+
+import (
+	"math"
+	"strings"
+)
+
+const (
+	UnitGram       = "g"
+	UnitKilogram   = "kg"
+	UnitMilliliter = "ml"
+	UnitLiter      = "l"
+	UnitTeaspoon   = "tsp"
+	UnitTablespoon = "tbsp"
+)
+
+type unitConversion struct {
+	base   string
+	factor float64
+}
+
+// unitConversions maps the known units to the base unit of their dimension. Spoons are kept
+// apart from volumes, "3 tsp salt" reads better on a shopping list than "15 ml salt".
+var unitConversions = map[string]unitConversion{
+	"g": {UnitGram, 1}, "gr": {UnitGram, 1}, "kg": {UnitGram, 1000}, "mg": {UnitGram, 0.001},
+	"oz": {UnitGram, 28.3495}, "lb": {UnitGram, 453.592}, "lbs": {UnitGram, 453.592},
+	"ml": {UnitMilliliter, 1}, "cl": {UnitMilliliter, 10}, "dl": {UnitMilliliter, 100}, "l": {UnitMilliliter, 1000},
+	"cup": {UnitMilliliter, 240}, "cups": {UnitMilliliter, 240}, "tasse": {UnitMilliliter, 240}, "tassen": {UnitMilliliter, 240},
+	"tsp": {UnitTeaspoon, 1}, "tl": {UnitTeaspoon, 1}, "tbsp": {UnitTeaspoon, 3}, "el": {UnitTeaspoon, 3},
+}
+
+// unitSingulars folds plural forms of count units.
+var unitSingulars = map[string]string{
+	"cloves": "clove", "cans": "can", "slices": "slice", "pinches": "pinch",
+	"prisen": "prise", "dosen": "dose", "zehen": "zehe", "scheiben": "scheibe",
+	"stück": "", "stk": "", "piece": "", "pieces": "",
+}
+
+// NormalizeQuantity converts a quantity to the base unit of its dimension, e.g. 1.5 kg to 1500 g.
+// Units without a known conversion are lower cased and their plural folded.
+func NormalizeQuantity(quantity float64, unit string) (float64, string) {
+	unit = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(unit), "."))
+
+	if conversion, ok := unitConversions[unit]; ok {
+		return quantity * conversion.factor, conversion.base
+	}
+	if singular, ok := unitSingulars[unit]; ok {
+		return quantity, singular
+	}
+
+	return quantity, unit
+}
+
+// DisplayQuantity converts a quantity in a base unit returned by NormalizeQuantity to the
+// unit reading best, e.g. 1500 g to 1.5 kg.
+func DisplayQuantity(quantity float64, unit string) (float64, string) {
+	switch unit {
+	case UnitGram:
+		if quantity >= 1000 {
+			return quantity / 1000, UnitKilogram
+		}
+	case UnitMilliliter:
+		if quantity >= 1000 {
+			return quantity / 1000, UnitLiter
+		}
+	case UnitTeaspoon:
+		if quantity >= 3 && math.Mod(quantity, 3) == 0 {
+			return quantity / 3, UnitTablespoon
+		}
+	}
+
+	return quantity, unit
+}
+
+// ConvertQuantity converts a quantity between two units of the same dimension, e.g. 500 g to
+// 0.5 kg. It reports false if the units cannot be converted into each other.
+func ConvertQuantity(quantity float64, fromUnit string, toUnit string) (float64, bool) {
+	baseQuantity, baseUnit := NormalizeQuantity(quantity, fromUnit)
+	targetFactor, targetUnit := NormalizeQuantity(1, toUnit)
+	if baseUnit != targetUnit {
+		return 0, false
+	}
+
+	return baseQuantity / targetFactor, true
+}
@@ -0,0 +1,113 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Roles of users, each role includes the permissions of the ones before it. Viewers read recipes
+// and cook, editors change recipes and sources, admins also manage backups.
+const (
+	RoleViewer = "viewer"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+var roleRanks = map[string]int{
+	RoleViewer: 1,
+	RoleEditor: 2,
+	RoleAdmin:  3,
+}
+
+type User struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	Username    string             `bson:"username"`
+	DisplayName string             `bson:"displayName,omitempty"`
+	// PasswordHash is a bcrypt hash, the password is never stored.
+	PasswordHash string `bson:"passwordHash,omitempty"`
+	// Issuer and Subject identify the account of a user signing in with an OpenID Connect
+	// provider, such users have no password.
+	Issuer    string    `bson:"issuer,omitempty"`
+	Subject   string    `bson:"subject,omitempty"`
+	Role      string    `bson:"role"`
+	CreatedAt time.Time `bson:"createdAt"`
+}
+
+// HasRole tells whether the user has the role or one including it.
+func (user User) HasRole(role string) bool {
+	return roleRanks[user.Role] > 0 && roleRanks[user.Role] >= roleRanks[role]
+}
+
+// Name is the name shown for the user, for example as author of a revision.
+func (user User) Name() string {
+	if len(user.DisplayName) > 0 {
+		return user.DisplayName
+	}
+
+	return user.Username
+}
+
+func IsRoleValid(role string) bool {
+	return roleRanks[role] > 0
+}
+
+// Session is a login of a user. The client holds a random token, only its hash is stored so the
+// tokens cannot be taken from the database.
+type Session struct {
+	TokenHash string             `bson:"_id"`
+	UserID    primitive.ObjectID `bson:"user"`
+	CreatedAt time.Time          `bson:"createdAt"`
+	ExpiresAt time.Time          `bson:"expiresAt"`
+}
+
+type UserRepository interface {
+	GetUsers(ctx context.Context) ([]User, error)
+	GetUserByID(ctx context.Context, id string) (User, error)
+	GetUserByUsername(ctx context.Context, username string) (User, error)
+	// GetUserBySubject returns the user of the account at an OpenID Connect provider.
+	GetUserBySubject(ctx context.Context, issuer string, subject string) (User, error)
+	// AddUser returns a UsernameExistsError if the username is taken.
+	AddUser(ctx context.Context, user *User) error
+	UpdateUser(ctx context.Context, user User) error
+}
+
+// SessionRepository stores sessions, expired sessions are removed by the repository.
+type SessionRepository interface {
+	GetSession(ctx context.Context, tokenHash string) (Session, error)
+	AddSession(ctx context.Context, session Session) error
+	DeleteSession(ctx context.Context, tokenHash string) error
+	DeleteUserSessions(ctx context.Context, userID primitive.ObjectID) error
+}
+
+type UserNotFoundError struct {
+	ID string
+}
+
+func (err *UserNotFoundError) Error() string {
+	return fmt.Sprintf("user '%s' not found", err.ID)
+}
+
+type UserIDNotValidError struct {
+	ID string
+}
+
+func (err *UserIDNotValidError) Error() string {
+	return fmt.Sprintf("user id '%s' not valid", err.ID)
+}
+
+type UsernameExistsError struct {
+	Username string
+}
+
+func (err *UsernameExistsError) Error() string {
+	return fmt.Sprintf("username '%s' already taken", err.Username)
+}
+
+type SessionNotFoundError struct{}
+
+func (err *SessionNotFoundError) Error() string {
+	return "session not found"
+}
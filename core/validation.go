@@ -0,0 +1,144 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Length limits in characters of the text fields of recipes and sources.
+const (
+	MaxTitleLength = 200
+	MaxNameLength  = 100
+	MaxTextLength  = 5000
+)
+
+// FieldError describes the problem of a single field. Field uses the names of the API, nested
+// fields and list elements are written as "ingredients[2].name".
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// ValidationError lists all problems found in a recipe or source, so they can be fixed at once.
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (err *ValidationError) Error() string {
+	messages := make([]string, 0, len(err.Errors))
+	for _, fieldErr := range err.Errors {
+		messages = append(messages, fmt.Sprintf("%s: %s", fieldErr.Field, fieldErr.Message))
+	}
+
+	return "validation failed: " + strings.Join(messages, "; ")
+}
+
+type validator struct {
+	errors []FieldError
+}
+
+func (v *validator) add(field string, format string, args ...interface{}) {
+	v.errors = append(v.errors, FieldError{
+		Field:   field,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *validator) required(field string, value string, maxLength int) {
+	if len(strings.TrimSpace(value)) == 0 {
+		v.add(field, "must not be empty")
+		return
+	}
+	v.maxLength(field, value, maxLength)
+}
+
+func (v *validator) maxLength(field string, value string, maxLength int) {
+	if utf8.RuneCountInString(value) > maxLength {
+		v.add(field, "must not be longer than %d characters", maxLength)
+	}
+}
+
+// uniqueNames checks that the list contains no empty and, ignoring case, no repeated names.
+func (v *validator) uniqueNames(field string, names []string) {
+	seen := make(map[string]bool, len(names))
+	for i, name := range names {
+		elementField := fmt.Sprintf("%s[%d]", field, i)
+		v.required(elementField, name, MaxNameLength)
+
+		key := strings.ToLower(strings.TrimSpace(name))
+		if len(key) > 0 && seen[key] {
+			v.add(elementField, "duplicate '%s'", name)
+		}
+		seen[key] = true
+	}
+}
+
+func (v *validator) err() error {
+	if len(v.errors) == 0 {
+		return nil
+	}
+
+	return &ValidationError{Errors: v.errors}
+}
+
+// ValidateRecipe returns a *ValidationError if the recipe must not be stored.
+func ValidateRecipe(recipe Recipe) error {
+	var v validator
+	v.required("title", recipe.Title, MaxTitleLength)
+	v.maxLength("sourceAnnotation", recipe.SourceAnnotation, MaxNameLength)
+	v.maxLength("category", recipe.Category, MaxNameLength)
+	v.uniqueNames("allergens", recipe.Allergens)
+	v.uniqueNames("tags", recipe.Tags)
+	v.uniqueNames("cookware", recipe.Cookware)
+	if recipe.Servings < 0 {
+		v.add("servings", "must not be negative")
+	}
+
+	for i, ingredient := range recipe.Ingredients {
+		field := fmt.Sprintf("ingredients[%d]", i)
+		v.required(field+".name", ingredient.Name, MaxNameLength)
+		v.maxLength(field+".unit", ingredient.Unit, MaxNameLength)
+		if ingredient.Quantity < 0 {
+			v.add(field+".quantity", "must not be negative")
+		}
+	}
+
+	for i, step := range recipe.Steps {
+		field := fmt.Sprintf("steps[%d]", i)
+		v.required(field+".text", step.Text, MaxTextLength)
+		for j, timer := range step.Timers {
+			if timer.Duration < 0 {
+				v.add(fmt.Sprintf("%s.timers[%d].durationSeconds", field, j), "must not be negative")
+			}
+		}
+	}
+
+	return v.err()
+}
+
+// ValidateSource returns a *ValidationError if the source must not be stored.
+func ValidateSource(source Source) error {
+	var v validator
+	v.required("title", source.Title, MaxTitleLength)
+	if !IsSourceTypeValid(source.Type) {
+		v.add("type", (&SourceTypeNotValidError{SourceType: source.Type}).Error())
+	}
+
+	return v.err()
+}
+
+// ValidateUser returns a *ValidationError if the user must not be stored.
+func ValidateUser(user User) error {
+	var v validator
+	v.required("username", user.Username, MaxNameLength)
+	if strings.ContainsAny(user.Username, " \t\r\n") {
+		v.add("username", "must not contain spaces")
+	}
+	v.maxLength("displayName", user.DisplayName, MaxNameLength)
+	if !IsRoleValid(user.Role) {
+		v.add("role", "role '%s' not valid, use %s, %s or %s", user.Role, RoleViewer, RoleEditor, RoleAdmin)
+	}
+
+	return v.err()
+}
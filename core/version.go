@@ -0,0 +1,14 @@
+package core
+
+import "fmt"
+
+// VersionConflictError is returned when a recipe or source was changed by someone else since
+// it was read. Every successful update increments the version by one.
+type VersionConflictError struct {
+	ID      string
+	Version int
+}
+
+func (err *VersionConflictError) Error() string {
+	return fmt.Sprintf("version %d of '%s' is outdated", err.Version, err.ID)
+}
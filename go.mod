@@ -3,6 +3,9 @@ module github.com/phlashdev/recipe-keeper-api
 go 1.16
 
 require (
-	github.com/gorilla/mux v1.8.0 // indirect
-	go.mongodb.org/mongo-driver v1.7.0 // indirect
+	github.com/go-pdf/fpdf v0.6.0
+	github.com/gorilla/mux v1.8.0
+	go.mongodb.org/mongo-driver v1.7.0
+	golang.org/x/crypto v0.0.0-20200302210943-78000ba7a073
+	gopkg.in/yaml.v3 v3.0.1
 )
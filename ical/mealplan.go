@@ -0,0 +1,102 @@
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/phlashdev/recipe-keeper-api/core"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	dateTimeFormat    = "20060102T150405"
+	utcDateTimeFormat = "20060102T150405Z"
+	maxLineLength     = 75
+)
+
+// slotTimes are the local times events of a meal slot start at.
+var slotTimes = map[string]time.Duration{
+	core.MealSlotBreakfast: 8 * time.Hour,
+	core.MealSlotLunch:     12 * time.Hour,
+	core.MealSlotSnack:     15 * time.Hour,
+	core.MealSlotDinner:    18 * time.Hour,
+}
+
+const slotDuration = time.Hour
+
+// WriteMealPlan writes the meal plan as iCalendar with one event per entry. The events use floating
+// times so they show up at the slot time in the time zone of the calendar.
+func WriteMealPlan(w io.Writer, mealPlan core.MealPlan, recipes map[primitive.ObjectID]core.Recipe) error {
+	writer := bufio.NewWriter(w)
+	stamp := time.Now().UTC().Format(utcDateTimeFormat)
+
+	writeLine(writer, "BEGIN:VCALENDAR")
+	writeLine(writer, "VERSION:2.0")
+	writeLine(writer, "PRODID:-//recipe-keeper//meal plan//EN")
+	writeLine(writer, "CALSCALE:GREGORIAN")
+	writeLine(writer, "X-WR-CALNAME:"+escape(mealPlan.Title))
+
+	uids := map[string]int{}
+	for _, entry := range mealPlan.Entries {
+		date := time.Date(entry.Date.Year(), entry.Date.Month(), entry.Date.Day(), 0, 0, 0, 0, time.UTC)
+		start := date.Add(slotTimes[entry.Slot])
+
+		summary := strings.Title(entry.Slot)
+		if recipe, ok := recipes[entry.Recipe]; ok {
+			summary += ": " + recipe.Title
+		}
+
+		// the uid must not change when other entries are added or removed, or calendars
+		// subscribed to the plan show an event twice
+		uid := fmt.Sprintf("%s-%s-%s-%s", mealPlan.ID.Hex(), date.Format("20060102"), entry.Slot, entry.Recipe.Hex())
+		uids[uid]++
+		if uids[uid] > 1 {
+			uid += fmt.Sprintf("-%d", uids[uid])
+		}
+
+		writeLine(writer, "BEGIN:VEVENT")
+		writeLine(writer, "UID:"+uid+"@recipe-keeper")
+		writeLine(writer, "DTSTAMP:"+stamp)
+		writeLine(writer, "DTSTART:"+start.Format(dateTimeFormat))
+		writeLine(writer, "DTEND:"+start.Add(slotDuration).Format(dateTimeFormat))
+		writeLine(writer, "SUMMARY:"+escape(summary))
+		if entry.Servings > 0 {
+			writeLine(writer, "DESCRIPTION:"+escape(fmt.Sprintf("%d servings", entry.Servings)))
+		}
+		writeLine(writer, "END:VEVENT")
+	}
+
+	writeLine(writer, "END:VCALENDAR")
+
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("error while writing icalendar: %v", err)
+	}
+
+	return nil
+}
+
+// writeLine terminates the line with CRLF and folds it if it is longer than 75 octets.
+func writeLine(writer *bufio.Writer, line string) {
+	limit := maxLineLength
+	for len(line) > limit {
+		cut := limit
+		// do not split multi-byte characters
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		writer.WriteString(line[:cut])
+		writer.WriteString("\r\n ")
+		line = line[cut:]
+		// continuation lines start with a space
+		limit = maxLineLength - 1
+	}
+	writer.WriteString(line)
+	writer.WriteString("\r\n")
+}
+
+func escape(text string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(text)
+}
@@ -0,0 +1,52 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/phlashdev/recipe-keeper-api/core"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestWriteMealPlanUIDsAreStable(t *testing.T) {
+	monday := time.Date(2021, 5, 3, 0, 0, 0, 0, time.UTC)
+	soup := core.MealPlanEntry{Date: monday, Slot: core.MealSlotLunch, Recipe: primitive.NewObjectID()}
+	bread := core.MealPlanEntry{Date: monday, Slot: core.MealSlotDinner, Recipe: primitive.NewObjectID()}
+	mealPlan := core.MealPlan{ID: primitive.NewObjectID(), Entries: []core.MealPlanEntry{soup, bread}}
+
+	before := eventUIDs(t, mealPlan)
+	// removing the first entry must not hand its uid to the next one
+	mealPlan.Entries = []core.MealPlanEntry{bread, bread}
+	after := eventUIDs(t, mealPlan)
+
+	if len(before) != 2 || len(after) != 2 {
+		t.Fatalf("uids = %v and %v, want two events each", before, after)
+	}
+	if after[0] != before[1] {
+		t.Errorf("uid of the dinner = %s, want %s as before", after[0], before[1])
+	}
+	if after[1] == after[0] || after[1] == before[0] {
+		t.Errorf("uids = %v, want a new uid for the second dinner", after)
+	}
+}
+
+func eventUIDs(t *testing.T, mealPlan core.MealPlan) []string {
+	t.Helper()
+
+	var buffer bytes.Buffer
+	if err := WriteMealPlan(&buffer, mealPlan, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var uids []string
+	unfolded := strings.ReplaceAll(buffer.String(), "\r\n ", "")
+	for _, line := range strings.Split(unfolded, "\r\n") {
+		if strings.HasPrefix(line, "UID:") {
+			uids = append(uids, strings.TrimPrefix(line, "UID:"))
+		}
+	}
+
+	return uids
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/phlashdev/recipe-keeper-api/core"
+	"github.com/phlashdev/recipe-keeper-api/importer"
+)
+
+func importRecipes(args []string, recipeRepository core.RecipeRepository, sourceRepository core.SourceRepository) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	format := flags.String("format", importer.FormatCooklang, "format of the export: cooklang (directory of .cook files), paprika, mealie, mealmaster, csv or xlsx")
+	dryRun := flags.Bool("dry-run", false, "only report what would be imported")
+	columns := flags.String("columns", "", "column mapping for csv and xlsx, e.g. title=Titel,source=Buch,sourceAnnotation=Seite,category=Kategorie")
+	sourceType := flags.String("source-type", core.SourceTypeBook, "type of sources created for csv and xlsx rows without sourceType column")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: recipe-keeper import [-format <format>] [-columns <mapping>] [-dry-run] <path>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("missing path")
+	}
+
+	columnMapping, err := importer.ParseColumnMapping(*columns)
+	if err != nil {
+		return err
+	}
+
+	options := importer.Options{
+		Columns:    columnMapping,
+		SourceType: *sourceType,
+	}
+	records, failures, err := importer.Read(*format, flags.Arg(0), options)
+	if err != nil {
+		return err
+	}
+
+	report, err := importer.NewImporter(recipeRepository, sourceRepository, *dryRun).Import(records)
+	if err != nil {
+		return err
+	}
+	report.Failures = append(failures, report.Failures...)
+
+	printImportReport(report)
+	if len(report.Failures) > 0 {
+		return fmt.Errorf("%d recipes could not be imported", len(report.Failures))
+	}
+
+	return nil
+}
+
+func printImportReport(report importer.Report) {
+	action := "created"
+	if report.DryRun {
+		action = "would create"
+	}
+
+	for _, source := range report.Sources {
+		log.Printf("%s source %q (%s)", action, source.Title, source.Type)
+	}
+	for _, recipe := range report.Recipes {
+		log.Printf("%s recipe %q", action, recipe.Title)
+	}
+	for _, failure := range report.Failures {
+		log.Printf("failed %s: %v", failure.Origin, failure.Err)
+	}
+
+	log.Printf("%s %d recipes and %d sources, %d failed", action, len(report.Recipes), len(report.Sources), len(report.Failures))
+}
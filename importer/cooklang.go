@@ -0,0 +1,54 @@
+package importer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/phlashdev/recipe-keeper-api/cooklang"
+)
+
+// ReadCooklang reads all .cook files of a directory.
+func ReadCooklang(dir string) ([]Record, []Failure, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.cook"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("error while listing cooklang files: %v", err)
+	}
+
+	var records []Record
+	var failures []Failure
+	for _, path := range paths {
+		record, err := readCooklangFile(path)
+		if err != nil {
+			failures = append(failures, Failure{Origin: path, Err: err})
+			continue
+		}
+		records = append(records, record)
+	}
+
+	return records, failures, nil
+}
+
+func readCooklangFile(path string) (Record, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Record{}, err
+	}
+	defer file.Close()
+
+	recipe, metadata, err := cooklang.Parse(file)
+	if err != nil {
+		return Record{}, err
+	}
+
+	if len(recipe.Title) == 0 {
+		recipe.Title = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+
+	return Record{
+		Origin: path,
+		Recipe: recipe,
+		Source: newSource(metadata[cooklang.MetadataSource]),
+	}, nil
+}
@@ -0,0 +1,211 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/phlashdev/recipe-keeper-api/core"
+)
+
+const (
+	FormatCooklang   = "cooklang"
+	FormatPaprika    = "paprika"
+	FormatMealie     = "mealie"
+	FormatMealMaster = "mealmaster"
+	FormatCSV        = "csv"
+	FormatXLSX       = "xlsx"
+)
+
+// Record is a recipe read from an export together with the source it should be linked to.
+// Sources are matched by type and title against the existing ones and only created if missing.
+type Record struct {
+	Origin string
+	Recipe core.Recipe
+	Source *core.Source
+}
+
+type Failure struct {
+	Origin string
+	Err    error
+}
+
+type Report struct {
+	DryRun   bool
+	Recipes  []core.Recipe
+	Sources  []core.Source
+	Failures []Failure
+}
+
+type FormatNotSupportedError struct {
+	Format string
+}
+
+func (err *FormatNotSupportedError) Error() string {
+	return fmt.Sprintf("import format '%s' not supported", err.Format)
+}
+
+// Options configure formats without a fixed layout like spreadsheets.
+type Options struct {
+	Columns    ColumnMapping
+	SourceType string
+}
+
+// Read parses the export at path in the given format.
+func Read(format string, path string, options Options) ([]Record, []Failure, error) {
+	switch format {
+	case FormatCooklang:
+		return ReadCooklang(path)
+	case FormatPaprika:
+		return ReadPaprika(path)
+	case FormatMealie:
+		return ReadMealie(path)
+	case FormatMealMaster:
+		return ReadMealMaster(path)
+	case FormatCSV:
+		return ReadCSV(path, options)
+	case FormatXLSX:
+		return ReadXLSX(path, options)
+	default:
+		return nil, nil, &FormatNotSupportedError{Format: format}
+	}
+}
+
+type Importer struct {
+	recipeRepository core.RecipeRepository
+	sourceRepository core.SourceRepository
+	dryRun           bool
+}
+
+func NewImporter(recipeRepository core.RecipeRepository, sourceRepository core.SourceRepository, dryRun bool) *Importer {
+	return &Importer{
+		recipeRepository: recipeRepository,
+		sourceRepository: sourceRepository,
+		dryRun:           dryRun,
+	}
+}
+
+// Import adds the records to the repositories. In a dry run nothing is written and the report
+// lists what would have been created.
+func (importer *Importer) Import(records []Record) (Report, error) {
+	report := Report{DryRun: importer.dryRun}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	sources, err := importer.sourceRepository.GetSources(ctx)
+	cancel()
+	if err != nil {
+		return report, err
+	}
+
+	for _, record := range records {
+		if err := importer.importRecord(record, &sources, &report); err != nil {
+			report.Failures = append(report.Failures, Failure{Origin: record.Origin, Err: err})
+		}
+	}
+
+	return report, nil
+}
+
+func (importer *Importer) importRecord(record Record, sources *[]core.Source, report *Report) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// validated up front, so a dry run reports the same failures as a real import
+	recipe := record.Recipe
+	if err := core.ValidateRecipe(recipe); err != nil {
+		return err
+	}
+
+	if record.Source != nil && len(record.Source.Title) > 0 {
+		source, found := findSource(*sources, record.Source.Type, record.Source.Title)
+		if !found {
+			source = *record.Source
+			if err := core.ValidateSource(source); err != nil {
+				return err
+			}
+			if !importer.dryRun {
+				if err := importer.sourceRepository.AddSource(ctx, &source); err != nil {
+					return err
+				}
+			}
+			*sources = append(*sources, source)
+			report.Sources = append(report.Sources, source)
+		}
+		recipe.Source = source.ID
+	}
+
+	if !importer.dryRun {
+		if err := importer.recipeRepository.AddRecipe(ctx, &recipe); err != nil {
+			return err
+		}
+	}
+	report.Recipes = append(report.Recipes, recipe)
+
+	return nil
+}
+
+func findSource(sources []core.Source, sourceType string, title string) (core.Source, bool) {
+	for _, source := range sources {
+		if source.Type == sourceType && strings.EqualFold(source.Title, title) {
+			return source, true
+		}
+	}
+
+	return core.Source{}, false
+}
+
+// newSource guesses the source type from the title, URLs become url sources and everything else custom ones.
+func newSource(title string) *core.Source {
+	title = strings.TrimSpace(title)
+	if len(title) == 0 {
+		return nil
+	}
+
+	sourceType := core.SourceTypeCustom
+	if strings.HasPrefix(title, "http://") || strings.HasPrefix(title, "https://") {
+		sourceType = core.SourceTypeUrl
+	}
+
+	return &core.Source{
+		Type:  sourceType,
+		Title: title,
+	}
+}
+
+// parseIngredients parses one ingredient per non-empty line.
+func parseIngredients(text string) []core.Ingredient {
+	var ingredients []core.Ingredient
+	for _, line := range strings.Split(text, "\n") {
+		ingredient := core.ParseIngredient(line)
+		if len(ingredient.Name) > 0 {
+			ingredients = append(ingredients, ingredient)
+		}
+	}
+
+	return ingredients
+}
+
+// parseSteps treats every non-empty line as a step.
+func parseSteps(text string, ingredients []core.Ingredient) []core.Step {
+	var steps []core.Step
+	for _, line := range strings.Split(text, "\n") {
+		if len(strings.TrimSpace(line)) > 0 {
+			steps = append(steps, core.NewStep(line, ingredients))
+		}
+	}
+
+	return steps
+}
+
+// parseServings reads the leading number of yields like "4 servings".
+func parseServings(text string) int {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return 0
+	}
+
+	var servings int
+	fmt.Sscanf(fields[0], "%d", &servings)
+	return servings
+}
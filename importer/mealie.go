@@ -0,0 +1,334 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/phlashdev/recipe-keeper-api/core"
+)
+
+// mealieRecipe is a recipe as exported by Mealie, older versions use different field names.
+type mealieRecipe struct {
+	Name               string            `json:"name"`
+	RecipeYield        string            `json:"recipeYield"`
+	RecipeYieldV1      string            `json:"recipe_yield"`
+	OrgURL             string            `json:"orgURL"`
+	OrgURLV1           string            `json:"org_url"`
+	RecipeCategory     []json.RawMessage `json:"recipeCategory"`
+	RecipeIngredient   []json.RawMessage `json:"recipeIngredient"`
+	RecipeInstructions []struct {
+		Text string `json:"text"`
+	} `json:"recipeInstructions"`
+}
+
+type mealieIngredient struct {
+	Note         string   `json:"note"`
+	Quantity     *float64 `json:"quantity"`
+	OriginalText string   `json:"originalText"`
+	Unit         *struct {
+		Name string `json:"name"`
+	} `json:"unit"`
+	Food *struct {
+		Name string `json:"name"`
+	} `json:"food"`
+}
+
+// mealieDatabase contains the tables of the database.json in a Mealie v1 backup.
+type mealieDatabase struct {
+	Recipes []struct {
+		ID          string `json:"id"`
+		Name        string `json:"name"`
+		RecipeYield string `json:"recipe_yield"`
+		OrgURL      string `json:"org_url"`
+	} `json:"recipes"`
+	RecipesIngredients []struct {
+		RecipeID     string   `json:"recipe_id"`
+		Position     int      `json:"position"`
+		Note         string   `json:"note"`
+		Quantity     *float64 `json:"quantity"`
+		UnitID       string   `json:"unit_id"`
+		FoodID       string   `json:"food_id"`
+		OriginalText string   `json:"original_text"`
+	} `json:"recipes_ingredients"`
+	RecipeInstructions []struct {
+		RecipeID string `json:"recipe_id"`
+		Position int    `json:"position"`
+		Text     string `json:"text"`
+	} `json:"recipe_instructions"`
+	IngredientUnits     []mealieNamed `json:"ingredient_units"`
+	IngredientFoods     []mealieNamed `json:"ingredient_foods"`
+	Categories          []mealieNamed `json:"categories"`
+	RecipesToCategories []struct {
+		RecipeID   string `json:"recipe_id"`
+		CategoryID string `json:"category_id"`
+	} `json:"recipes_to_categories"`
+}
+
+type mealieNamed struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// ReadMealie reads a Mealie backup. Supported are zip backups containing a database.json (v1) or
+// one JSON file per recipe (v0), as well as plain JSON files with one or more recipes.
+func ReadMealie(filePath string) ([]Record, []Failure, error) {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error while reading mealie backup: %v", err)
+	}
+
+	if !bytes.HasPrefix(data, []byte("PK")) {
+		return readMealieJSON(filePath, data)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, nil, fmt.Errorf("error while opening mealie backup: %v", err)
+	}
+
+	var records []Record
+	var failures []Failure
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() || path.Ext(file.Name) != ".json" {
+			continue
+		}
+
+		origin := filePath + ":" + file.Name
+		reader, err := file.Open()
+		if err != nil {
+			failures = append(failures, Failure{Origin: origin, Err: err})
+			continue
+		}
+		fileData, err := ioutil.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			failures = append(failures, Failure{Origin: origin, Err: err})
+			continue
+		}
+
+		fileRecords, fileFailures, err := readMealieJSON(origin, fileData)
+		if err != nil {
+			failures = append(failures, Failure{Origin: origin, Err: err})
+			continue
+		}
+		records = append(records, fileRecords...)
+		failures = append(failures, fileFailures...)
+	}
+
+	return records, failures, nil
+}
+
+func readMealieJSON(origin string, data []byte) ([]Record, []Failure, error) {
+	trimmed := bytes.TrimSpace(data)
+
+	if bytes.HasPrefix(trimmed, []byte("[")) {
+		var recipes []json.RawMessage
+		if err := json.Unmarshal(trimmed, &recipes); err != nil {
+			return nil, nil, fmt.Errorf("error while decoding mealie recipes: %v", err)
+		}
+
+		var records []Record
+		var failures []Failure
+		for i, data := range recipes {
+			recipeOrigin := fmt.Sprintf("%s[%d]", origin, i)
+			var recipe mealieRecipe
+			if err := json.Unmarshal(data, &recipe); err != nil {
+				failures = append(failures, Failure{Origin: recipeOrigin, Err: err})
+				continue
+			}
+			records = append(records, newMealieRecord(recipeOrigin, recipe))
+		}
+		return records, failures, nil
+	}
+
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(trimmed, &probe); err != nil {
+		return nil, nil, fmt.Errorf("error while decoding mealie backup: %v", err)
+	}
+
+	if _, ok := probe["recipes_ingredients"]; ok {
+		var database mealieDatabase
+		if err := json.Unmarshal(trimmed, &database); err != nil {
+			return nil, nil, fmt.Errorf("error while decoding mealie database: %v", err)
+		}
+		return newMealieDatabaseRecords(origin, database), nil, nil
+	}
+
+	if _, ok := probe["name"]; ok {
+		var recipe mealieRecipe
+		if err := json.Unmarshal(trimmed, &recipe); err != nil {
+			return nil, nil, fmt.Errorf("error while decoding mealie recipe: %v", err)
+		}
+		return []Record{newMealieRecord(origin, recipe)}, nil, nil
+	}
+
+	// other tables of a backup like users or groups
+	return nil, nil, nil
+}
+
+func newMealieRecord(origin string, mealie mealieRecipe) Record {
+	var ingredients []core.Ingredient
+	for _, data := range mealie.RecipeIngredient {
+		if ingredient, ok := parseMealieIngredient(data); ok {
+			ingredients = append(ingredients, ingredient)
+		}
+	}
+
+	var steps []core.Step
+	for _, instruction := range mealie.RecipeInstructions {
+		if len(strings.TrimSpace(instruction.Text)) > 0 {
+			steps = append(steps, core.NewStep(instruction.Text, ingredients))
+		}
+	}
+
+	recipe := core.Recipe{
+		Title:       strings.TrimSpace(mealie.Name),
+		Servings:    parseServings(firstNonEmpty(mealie.RecipeYield, mealie.RecipeYieldV1)),
+		Ingredients: ingredients,
+		Steps:       steps,
+	}
+	if len(mealie.RecipeCategory) > 0 {
+		recipe.Category = parseMealieName(mealie.RecipeCategory[0])
+	}
+
+	return Record{
+		Origin: origin,
+		Recipe: recipe,
+		Source: newSource(firstNonEmpty(mealie.OrgURL, mealie.OrgURLV1)),
+	}
+}
+
+// parseMealieIngredient accepts plain strings as well as structured ingredients.
+func parseMealieIngredient(data json.RawMessage) (core.Ingredient, bool) {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		ingredient := core.ParseIngredient(text)
+		return ingredient, len(ingredient.Name) > 0
+	}
+
+	var mealie mealieIngredient
+	if err := json.Unmarshal(data, &mealie); err != nil {
+		return core.Ingredient{}, false
+	}
+
+	if mealie.Food == nil || len(mealie.Food.Name) == 0 {
+		ingredient := core.ParseIngredient(firstNonEmpty(mealie.OriginalText, mealie.Note))
+		return ingredient, len(ingredient.Name) > 0
+	}
+
+	ingredient := core.Ingredient{Name: mealie.Food.Name}
+	if mealie.Quantity != nil {
+		ingredient.Quantity = *mealie.Quantity
+	}
+	if mealie.Unit != nil {
+		ingredient.Unit = mealie.Unit.Name
+	}
+
+	return ingredient, true
+}
+
+func parseMealieName(data json.RawMessage) string {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		return name
+	}
+
+	var named mealieNamed
+	if err := json.Unmarshal(data, &named); err == nil {
+		return named.Name
+	}
+
+	return ""
+}
+
+func newMealieDatabaseRecords(origin string, database mealieDatabase) []Record {
+	units := namesByID(database.IngredientUnits)
+	foods := namesByID(database.IngredientFoods)
+	categories := namesByID(database.Categories)
+
+	sort.SliceStable(database.RecipesIngredients, func(i, j int) bool {
+		return database.RecipesIngredients[i].Position < database.RecipesIngredients[j].Position
+	})
+	sort.SliceStable(database.RecipeInstructions, func(i, j int) bool {
+		return database.RecipeInstructions[i].Position < database.RecipeInstructions[j].Position
+	})
+
+	var records []Record
+	for _, mealie := range database.Recipes {
+		var ingredients []core.Ingredient
+		for _, row := range database.RecipesIngredients {
+			if row.RecipeID != mealie.ID {
+				continue
+			}
+
+			food := foods[row.FoodID]
+			if len(food) == 0 {
+				ingredient := core.ParseIngredient(firstNonEmpty(row.OriginalText, row.Note))
+				if len(ingredient.Name) > 0 {
+					ingredients = append(ingredients, ingredient)
+				}
+				continue
+			}
+
+			ingredient := core.Ingredient{Name: food, Unit: units[row.UnitID]}
+			if row.Quantity != nil {
+				ingredient.Quantity = *row.Quantity
+			}
+			ingredients = append(ingredients, ingredient)
+		}
+
+		var steps []core.Step
+		for _, row := range database.RecipeInstructions {
+			if row.RecipeID == mealie.ID && len(strings.TrimSpace(row.Text)) > 0 {
+				steps = append(steps, core.NewStep(row.Text, ingredients))
+			}
+		}
+
+		recipe := core.Recipe{
+			Title:       strings.TrimSpace(mealie.Name),
+			Servings:    parseServings(mealie.RecipeYield),
+			Ingredients: ingredients,
+			Steps:       steps,
+		}
+		for _, row := range database.RecipesToCategories {
+			if row.RecipeID == mealie.ID {
+				recipe.Category = categories[row.CategoryID]
+				break
+			}
+		}
+
+		records = append(records, Record{
+			Origin: origin + ":" + strconv.Quote(mealie.Name),
+			Recipe: recipe,
+			Source: newSource(mealie.OrgURL),
+		})
+	}
+
+	return records
+}
+
+func namesByID(rows []mealieNamed) map[string]string {
+	names := make(map[string]string, len(rows))
+	for _, row := range rows {
+		names[row.ID] = row.Name
+	}
+
+	return names
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if len(strings.TrimSpace(value)) > 0 {
+			return value
+		}
+	}
+
+	return ""
+}
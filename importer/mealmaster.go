@@ -0,0 +1,242 @@
+package importer
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/phlashdev/recipe-keeper-api/core"
+)
+
+var (
+	mealMasterStart      = regexp.MustCompile(`(?i)^(MMMMM|-----).*meal-master`)
+	mealMasterEnd        = regexp.MustCompile(`^(MMMMM|-----)\s*$`)
+	mealMasterQuantity   = regexp.MustCompile(`^[0-9 ./]*$`)
+	mealMasterSeparator  = regexp.MustCompile(`^(MMMMM|-----)`)
+	mealMasterHeaderLine = regexp.MustCompile(`^\s*(Title|Categories|Yield|Servings)\s*:\s*(.*)$`)
+)
+
+var mealMasterUnits = map[string]string{
+	"":   "",
+	"x":  "",
+	"ea": "",
+	"sm": "small",
+	"md": "medium",
+	"lg": "large",
+	"cn": "can",
+	"pk": "package",
+	"pn": "pinch",
+	"dr": "drop",
+	"ds": "dash",
+	"ct": "carton",
+	"bn": "bunch",
+	"sl": "slice",
+	"t":  "tsp",
+	"ts": "tsp",
+	"T":  "tbsp",
+	"tb": "tbsp",
+	"fl": "fl oz",
+	"c":  "cup",
+	"pt": "pint",
+	"qt": "quart",
+	"ga": "gallon",
+	"oz": "oz",
+	"lb": "lb",
+	"ml": "ml",
+	"cb": "cubic cm",
+	"cl": "cl",
+	"dl": "dl",
+	"l":  "l",
+	"mg": "mg",
+	"cg": "cg",
+	"dg": "dg",
+	"g":  "g",
+	"kg": "kg",
+}
+
+// ReadMealMaster reads a MealMaster text file which may contain any number of recipes.
+func ReadMealMaster(path string) ([]Record, []Failure, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error while opening mealmaster file: %v", err)
+	}
+	defer file.Close()
+
+	var records []Record
+	var failures []Failure
+	var lines []string
+	inRecipe := false
+	startLine := 0
+
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+
+		switch {
+		case mealMasterStart.MatchString(line):
+			if inRecipe {
+				failures = append(failures, Failure{
+					Origin: fmt.Sprintf("%s:%d", path, startLine),
+					Err:    fmt.Errorf("recipe has no end marker"),
+				})
+			}
+			inRecipe = true
+			startLine = lineNumber
+			lines = nil
+		case inRecipe && mealMasterEnd.MatchString(line):
+			origin := fmt.Sprintf("%s:%d", path, startLine)
+			recipe, err := parseMealMasterRecipe(lines)
+			if err != nil {
+				failures = append(failures, Failure{Origin: origin, Err: err})
+			} else {
+				records = append(records, Record{Origin: origin, Recipe: recipe})
+			}
+			inRecipe = false
+		case inRecipe:
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("error while reading mealmaster file: %v", err)
+	}
+	if inRecipe {
+		failures = append(failures, Failure{
+			Origin: fmt.Sprintf("%s:%d", path, startLine),
+			Err:    fmt.Errorf("recipe has no end marker"),
+		})
+	}
+
+	return records, failures, nil
+}
+
+func parseMealMasterRecipe(lines []string) (core.Recipe, error) {
+	var recipe core.Recipe
+	var directions []string
+	var paragraph []string
+
+	flush := func() {
+		if len(paragraph) > 0 {
+			directions = append(directions, strings.Join(paragraph, " "))
+			paragraph = nil
+		}
+	}
+
+	for _, line := range lines {
+		if match := mealMasterHeaderLine.FindStringSubmatch(line); match != nil && len(recipe.Ingredients) == 0 && len(directions) == 0 {
+			switch match[1] {
+			case "Title":
+				recipe.Title = strings.TrimSpace(match[2])
+			case "Categories":
+				recipe.Category = strings.TrimSpace(strings.Split(match[2], ",")[0])
+			case "Yield", "Servings":
+				recipe.Servings = parseServings(match[2])
+			}
+			continue
+		}
+
+		if len(strings.TrimSpace(line)) == 0 {
+			flush()
+			continue
+		}
+
+		// sub headings like "MMMMM-----FILLING-----"
+		if mealMasterSeparator.MatchString(line) {
+			flush()
+			continue
+		}
+
+		if ingredients, ok := parseMealMasterIngredientLine(line); ok && len(directions) == 0 && len(paragraph) == 0 {
+			for _, ingredient := range ingredients {
+				if strings.HasPrefix(ingredient.Name, "-") && len(recipe.Ingredients) > 0 {
+					last := &recipe.Ingredients[len(recipe.Ingredients)-1]
+					last.Name = last.Name + ", " + strings.TrimSpace(strings.TrimPrefix(ingredient.Name, "-"))
+					continue
+				}
+				recipe.Ingredients = append(recipe.Ingredients, ingredient)
+			}
+			continue
+		}
+
+		paragraph = append(paragraph, strings.TrimSpace(line))
+	}
+	flush()
+
+	if len(recipe.Title) == 0 {
+		return core.Recipe{}, fmt.Errorf("recipe has no title")
+	}
+
+	for _, direction := range directions {
+		recipe.Steps = append(recipe.Steps, core.NewStep(direction, recipe.Ingredients))
+	}
+
+	return recipe, nil
+}
+
+// parseMealMasterIngredientLine parses the fixed column layout of ingredients: quantity in columns 1-7,
+// unit in columns 9-10 and the name from column 12. Two ingredients may share one line, the second
+// one starting at column 42.
+func parseMealMasterIngredientLine(line string) ([]core.Ingredient, bool) {
+	first, ok := parseMealMasterIngredient(line)
+	if !ok {
+		return nil, false
+	}
+
+	if len(line) > 41 && line[40] == ' ' {
+		if second, ok := parseMealMasterIngredient(line[41:]); ok {
+			first, _ = parseMealMasterIngredient(line[:41])
+			return []core.Ingredient{first, second}, true
+		}
+	}
+
+	return []core.Ingredient{first}, true
+}
+
+func parseMealMasterIngredient(column string) (core.Ingredient, bool) {
+	if len(column) < 12 || column[7] != ' ' || column[10] != ' ' {
+		return core.Ingredient{}, false
+	}
+
+	quantity := column[:7]
+	if !mealMasterQuantity.MatchString(quantity) {
+		return core.Ingredient{}, false
+	}
+
+	unit, ok := mealMasterUnits[strings.TrimSpace(column[8:10])]
+	if !ok {
+		return core.Ingredient{}, false
+	}
+
+	name := strings.TrimSpace(column[11:])
+	if len(name) == 0 {
+		return core.Ingredient{}, false
+	}
+
+	return core.Ingredient{
+		Name:     name,
+		Quantity: parseMealMasterQuantity(quantity),
+		Unit:     unit,
+	}, true
+}
+
+func parseMealMasterQuantity(text string) float64 {
+	var quantity float64
+	for _, field := range strings.Fields(text) {
+		if parts := strings.SplitN(field, "/", 2); len(parts) == 2 {
+			numerator, err1 := strconv.ParseFloat(parts[0], 64)
+			denominator, err2 := strconv.ParseFloat(parts[1], 64)
+			if err1 == nil && err2 == nil && denominator != 0 {
+				quantity += numerator / denominator
+			}
+			continue
+		}
+
+		if number, err := strconv.ParseFloat(field, 64); err == nil {
+			quantity += number
+		}
+	}
+
+	return quantity
+}
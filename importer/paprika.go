@@ -0,0 +1,94 @@
+package importer
+
+import (
+	"archive/zip"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/phlashdev/recipe-keeper-api/core"
+)
+
+type paprikaRecipe struct {
+	Name        string   `json:"name"`
+	Ingredients string   `json:"ingredients"`
+	Directions  string   `json:"directions"`
+	Servings    string   `json:"servings"`
+	Source      string   `json:"source"`
+	SourceURL   string   `json:"source_url"`
+	Categories  []string `json:"categories"`
+}
+
+// ReadPaprika reads a .paprikarecipes export, a zip archive containing one gzipped JSON file per recipe.
+func ReadPaprika(path string) ([]Record, []Failure, error) {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error while opening paprika export: %v", err)
+	}
+	defer archive.Close()
+
+	var records []Record
+	var failures []Failure
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+
+		origin := path + ":" + file.Name
+		recipe, err := readPaprikaRecipe(file)
+		if err != nil {
+			failures = append(failures, Failure{Origin: origin, Err: err})
+			continue
+		}
+		records = append(records, newPaprikaRecord(origin, recipe))
+	}
+
+	return records, failures, nil
+}
+
+func readPaprikaRecipe(file *zip.File) (paprikaRecipe, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return paprikaRecipe{}, err
+	}
+	defer reader.Close()
+
+	gzipReader, err := gzip.NewReader(reader)
+	if err != nil {
+		return paprikaRecipe{}, fmt.Errorf("error while decompressing recipe: %v", err)
+	}
+	defer gzipReader.Close()
+
+	var recipe paprikaRecipe
+	if err := json.NewDecoder(io.Reader(gzipReader)).Decode(&recipe); err != nil {
+		return paprikaRecipe{}, fmt.Errorf("error while decoding recipe: %v", err)
+	}
+
+	return recipe, nil
+}
+
+func newPaprikaRecord(origin string, paprika paprikaRecipe) Record {
+	ingredients := parseIngredients(paprika.Ingredients)
+	recipe := core.Recipe{
+		Title:       strings.TrimSpace(paprika.Name),
+		Servings:    parseServings(paprika.Servings),
+		Ingredients: ingredients,
+		Steps:       parseSteps(paprika.Directions, ingredients),
+	}
+	if len(paprika.Categories) > 0 {
+		recipe.Category = paprika.Categories[0]
+	}
+
+	source := newSource(paprika.SourceURL)
+	if source == nil {
+		source = newSource(paprika.Source)
+	}
+
+	return Record{
+		Origin: origin,
+		Recipe: recipe,
+		Source: source,
+	}
+}
@@ -0,0 +1,243 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/phlashdev/recipe-keeper-api/core"
+)
+
+const (
+	ColumnTitle            = "title"
+	ColumnSource           = "source"
+	ColumnSourceType       = "sourceType"
+	ColumnSourceAnnotation = "sourceAnnotation"
+	ColumnCategory         = "category"
+	ColumnAllergens        = "allergens"
+	ColumnTags             = "tags"
+	ColumnServings         = "servings"
+)
+
+var columns = []string{ColumnTitle, ColumnSource, ColumnSourceType, ColumnSourceAnnotation, ColumnCategory, ColumnAllergens, ColumnTags, ColumnServings}
+
+// columnAliases are header names recognized without explicit mapping besides the column names themselves.
+var columnAliases = map[string][]string{
+	ColumnSource:           {"book"},
+	ColumnSourceAnnotation: {"page"},
+}
+
+// ColumnMapping maps recipe columns like "title" to the header name used in the spreadsheet.
+type ColumnMapping map[string]string
+
+type ColumnNotValidError struct {
+	Column string
+}
+
+func (err *ColumnNotValidError) Error() string {
+	return fmt.Sprintf("column '%s' not valid, valid columns are %s", err.Column, strings.Join(columns, ", "))
+}
+
+// ParseColumnMapping parses a mapping like "title=Titel,source=Buch".
+func ParseColumnMapping(text string) (ColumnMapping, error) {
+	mapping := ColumnMapping{}
+	if len(strings.TrimSpace(text)) == 0 {
+		return mapping, nil
+	}
+
+	for _, pair := range strings.Split(text, ",") {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("column mapping '%s' not valid", pair)
+		}
+
+		column := strings.TrimSpace(parts[0])
+		if !isColumn(column) {
+			return nil, &ColumnNotValidError{Column: column}
+		}
+		mapping[column] = strings.TrimSpace(parts[1])
+	}
+
+	return mapping, nil
+}
+
+func isColumn(column string) bool {
+	for _, c := range columns {
+		if c == column {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ReadCSV reads a CSV file with a header row, separated by commas or semicolons.
+func ReadCSV(path string, options Options) ([]Record, []Failure, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error while reading csv: %v", err)
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	firstLine := data
+	if index := bytes.IndexByte(data, '\n'); index >= 0 {
+		firstLine = data[:index]
+	}
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		reader.Comma = ';'
+	}
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error while reading csv: %v", err)
+	}
+
+	return readRows(path, rows, options)
+}
+
+// ReadXLSX reads the first worksheet of an Excel workbook with a header row.
+func ReadXLSX(path string, options Options) ([]Record, []Failure, error) {
+	rows, err := readWorksheet(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return readRows(path, rows, options)
+}
+
+func readRows(path string, rows [][]string, options Options) ([]Record, []Failure, error) {
+	if len(rows) == 0 {
+		return nil, nil, fmt.Errorf("spreadsheet has no header row")
+	}
+
+	indexes, err := columnIndexes(rows[0], options.Columns)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var records []Record
+	var failures []Failure
+	for i, row := range rows[1:] {
+		origin := fmt.Sprintf("%s:%d", path, i+2)
+		if isEmptyRow(row) {
+			continue
+		}
+
+		record, err := newRowRecord(origin, row, indexes, options)
+		if err != nil {
+			failures = append(failures, Failure{Origin: origin, Err: err})
+			continue
+		}
+		records = append(records, record)
+	}
+
+	return records, failures, nil
+}
+
+func columnIndexes(header []string, mapping ColumnMapping) (map[string]int, error) {
+	indexes := map[string]int{}
+	for _, column := range columns {
+		names := []string{column}
+		if name, ok := mapping[column]; ok {
+			names = []string{name}
+		} else {
+			names = append(names, columnAliases[column]...)
+		}
+
+		for i, cell := range header {
+			if containsString(names, strings.TrimSpace(cell)) {
+				indexes[column] = i
+				break
+			}
+		}
+
+		if _, found := indexes[column]; !found && len(mapping[column]) > 0 {
+			return nil, fmt.Errorf("column '%s' mapped to '%s' not found in header", column, mapping[column])
+		}
+	}
+
+	if _, ok := indexes[ColumnTitle]; !ok {
+		return nil, fmt.Errorf("header has no title column")
+	}
+
+	return indexes, nil
+}
+
+func newRowRecord(origin string, row []string, indexes map[string]int, options Options) (Record, error) {
+	cell := func(column string) string {
+		index, ok := indexes[column]
+		if !ok || index >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[index])
+	}
+
+	recipe := core.Recipe{
+		Title:            cell(ColumnTitle),
+		SourceAnnotation: cell(ColumnSourceAnnotation),
+		Category:         cell(ColumnCategory),
+		Servings:         parseServings(cell(ColumnServings)),
+	}
+	if len(recipe.Title) == 0 {
+		return Record{}, fmt.Errorf("row has no title")
+	}
+
+	recipe.Allergens = splitList(cell(ColumnAllergens))
+	recipe.Tags = splitList(cell(ColumnTags))
+
+	var source *core.Source
+	if sourceTitle := cell(ColumnSource); len(sourceTitle) > 0 {
+		sourceType := cell(ColumnSourceType)
+		if len(sourceType) == 0 {
+			sourceType = options.SourceType
+		}
+		if len(sourceType) == 0 {
+			sourceType = core.SourceTypeBook
+		}
+		if !core.IsSourceTypeValid(sourceType) {
+			return Record{}, &core.SourceTypeNotValidError{SourceType: sourceType}
+		}
+		source = &core.Source{Type: sourceType, Title: sourceTitle}
+	}
+
+	return Record{
+		Origin: origin,
+		Recipe: recipe,
+		Source: source,
+	}, nil
+}
+
+func splitList(text string) []string {
+	var values []string
+	for _, value := range strings.Split(text, ",") {
+		if value = strings.TrimSpace(value); len(value) > 0 {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
+
+func isEmptyRow(row []string) bool {
+	for _, cell := range row {
+		if len(strings.TrimSpace(cell)) > 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,154 @@
+package importer
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type xlsxSharedStrings struct {
+	Items []xlsxStringItem `xml:"si"`
+}
+
+type xlsxStringItem struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (item xlsxStringItem) String() string {
+	if len(item.Runs) == 0 {
+		return item.Text
+	}
+
+	var text strings.Builder
+	for _, run := range item.Runs {
+		text.WriteString(run.Text)
+	}
+	return text.String()
+}
+
+type xlsxWorksheet struct {
+	Rows []struct {
+		Cells []struct {
+			Reference string         `xml:"r,attr"`
+			Type      string         `xml:"t,attr"`
+			Value     string         `xml:"v"`
+			Inline    xlsxStringItem `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// readWorksheet reads the cell texts of the first worksheet. Only what is needed for importing
+// text tables is supported, formulas are read with their cached values.
+func readWorksheet(filePath string) ([][]string, error) {
+	archive, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("error while opening xlsx: %v", err)
+	}
+	defer archive.Close()
+
+	files := map[string]*zip.File{}
+	var worksheets []string
+	for _, file := range archive.File {
+		files[file.Name] = file
+		if path.Dir(file.Name) == "xl/worksheets" && path.Ext(file.Name) == ".xml" {
+			worksheets = append(worksheets, file.Name)
+		}
+	}
+	if len(worksheets) == 0 {
+		return nil, fmt.Errorf("xlsx has no worksheet")
+	}
+	sort.Slice(worksheets, func(i, j int) bool {
+		return worksheetNumber(worksheets[i]) < worksheetNumber(worksheets[j])
+	})
+
+	var sharedStrings xlsxSharedStrings
+	if file, ok := files["xl/sharedStrings.xml"]; ok {
+		if err := decodeXMLFile(file, &sharedStrings); err != nil {
+			return nil, err
+		}
+	}
+
+	var worksheet xlsxWorksheet
+	if err := decodeXMLFile(files[worksheets[0]], &worksheet); err != nil {
+		return nil, err
+	}
+
+	var rows [][]string
+	for _, xmlRow := range worksheet.Rows {
+		var row []string
+		for i, cell := range xmlRow.Cells {
+			column := i
+			if len(cell.Reference) > 0 {
+				column = columnIndex(cell.Reference)
+			}
+			for len(row) <= column {
+				row = append(row, "")
+			}
+
+			switch cell.Type {
+			case "s":
+				index, err := strconv.Atoi(cell.Value)
+				if err != nil || index < 0 || index >= len(sharedStrings.Items) {
+					return nil, fmt.Errorf("xlsx cell %s references unknown string", cell.Reference)
+				}
+				row[column] = sharedStrings.Items[index].String()
+			case "inlineStr":
+				row[column] = cell.Inline.String()
+			default:
+				row[column] = cell.Value
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+func decodeXMLFile(file *zip.File, content interface{}) error {
+	reader, err := file.Open()
+	if err != nil {
+		return fmt.Errorf("error while reading xlsx: %v", err)
+	}
+	defer reader.Close()
+
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return fmt.Errorf("error while reading xlsx: %v", err)
+	}
+
+	if err := xml.Unmarshal(data, content); err != nil {
+		return fmt.Errorf("error while decoding %s: %v", file.Name, err)
+	}
+
+	return nil
+}
+
+func worksheetNumber(name string) int {
+	number, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(path.Base(name), "sheet"), ".xml"))
+	if err != nil {
+		return int(^uint(0) >> 1)
+	}
+
+	return number
+}
+
+// columnIndex converts the column letters of a cell reference like "AB12" to a zero based index.
+func columnIndex(reference string) int {
+	index := 0
+	for _, r := range reference {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		index = index*26 + int(r-'A') + 1
+	}
+
+	return index - 1
+}
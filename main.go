@@ -10,20 +10,31 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/phlashdev/recipe-keeper-api/api"
+	"github.com/phlashdev/recipe-keeper-api/auth"
+	"github.com/phlashdev/recipe-keeper-api/cooking"
 	mongodb "github.com/phlashdev/recipe-keeper-api/mongo"
+	"github.com/phlashdev/recipe-keeper-api/web"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
 )
 
 const (
-	DatabaseName         = "recipe-keeper"
-	RecipeCollectionName = "recipes"
-	SourceCollectionName = "sources"
+	DatabaseName               = "recipe-keeper"
+	RecipeCollectionName       = "recipes"
+	SourceCollectionName       = "sources"
+	MealPlanCollectionName     = "mealplans"
+	ShoppingListCollectionName = "shoppinglists"
+	PantryCollectionName       = "pantry"
+	RevisionCollectionName     = "revisions"
+	IdempotencyCollectionName  = "idempotencykeys"
+	UserCollectionName         = "users"
+	SessionCollectionName      = "sessions"
 )
 
 const (
-	MongoDbConStrEnv = "RECIPEKEEPER_MONGODB_CONSTR"
+	MongoDbConStrEnv  = "RECIPEKEEPER_MONGODB_CONSTR"
+	TrashRetentionEnv = "RECIPEKEEPER_TRASH_RETENTION"
 )
 
 func main() {
@@ -56,23 +67,126 @@ func main() {
 	sourcesCollection := dbClient.Database(DatabaseName).Collection(SourceCollectionName)
 	sourceRepository := mongodb.NewMongoSourceRepository(sourcesCollection)
 
+	mealPlansCollection := dbClient.Database(DatabaseName).Collection(MealPlanCollectionName)
+	mealPlanRepository := mongodb.NewMongoMealPlanRepository(mealPlansCollection)
+
+	shoppingListsCollection := dbClient.Database(DatabaseName).Collection(ShoppingListCollectionName)
+	shoppingListRepository := mongodb.NewMongoShoppingListRepository(shoppingListsCollection)
+	if err := shoppingListRepository.EnsureItemIDs(ctx); err != nil {
+		log.Fatal(err)
+	}
+
+	pantryCollection := dbClient.Database(DatabaseName).Collection(PantryCollectionName)
+	pantryRepository := mongodb.NewMongoPantryRepository(pantryCollection)
+
+	revisionsCollection := dbClient.Database(DatabaseName).Collection(RevisionCollectionName)
+	revisionRepository := mongodb.NewMongoRevisionRepository(revisionsCollection)
+	if err := revisionRepository.EnsureIndexes(ctx); err != nil {
+		log.Fatal(err)
+	}
+
+	idempotencyCollection := dbClient.Database(DatabaseName).Collection(IdempotencyCollectionName)
+	idempotencyRepository := mongodb.NewMongoIdempotencyRepository(idempotencyCollection)
+	if err := idempotencyRepository.EnsureIndexes(ctx); err != nil {
+		log.Fatal(err)
+	}
+
+	usersCollection := dbClient.Database(DatabaseName).Collection(UserCollectionName)
+	userRepository := mongodb.NewMongoUserRepository(usersCollection)
+	if err := userRepository.EnsureIndexes(ctx); err != nil {
+		log.Fatal(err)
+	}
+
+	sessionsCollection := dbClient.Database(DatabaseName).Collection(SessionCollectionName)
+	sessionRepository := mongodb.NewMongoSessionRepository(sessionsCollection)
+	if err := sessionRepository.EnsureIndexes(ctx); err != nil {
+		log.Fatal(err)
+	}
+
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "import":
+			err = importRecipes(os.Args[2:], recipeRepository, sourceRepository)
+		case "backup":
+			err = backupDatabase(os.Args[2:], recipeRepository, sourceRepository)
+		case "restore":
+			err = restoreDatabase(os.Args[2:], recipeRepository, sourceRepository)
+		case "user":
+			err = manageUsers(os.Args[2:], userRepository, sessionRepository)
+		default:
+			err = fmt.Errorf("unknown command %q", os.Args[1])
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	retention, err := trashRetention(os.Getenv(TrashRetentionEnv))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if retention > 0 {
+		go purgeTrashPeriodically(retention, recipeRepository, sourceRepository)
+	}
+
+	oidcProvider, err := oidcProvider()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	users, err := userRepository.GetUsers(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// users of an identity provider are added on their first login
+	if len(users) == 0 && oidcProvider == nil {
+		log.Print("No users yet, add one with: recipe-keeper user add -role admin <username>")
+	}
+
+	authenticator := auth.NewAuthenticator(userRepository, sessionRepository, auth.DefaultSessionLifetime)
+
+	// finished timers stay visible for a while, so every device in the kitchen notices them
+	cookingTimers := cooking.NewTimers(10 * time.Minute)
+
 	router := mux.NewRouter()
+	router.Use(api.NewAuthMiddleware(authenticator, "/login",
+		"/login", "/login/", "/logout", "/api/login", "/api/logout", "/api/openapi.json", "/api/docs", "/static/").Middleware)
 
-	recipesSubrouter := router.PathPrefix("/api/recipes").Subrouter()
-	recipesSubrouter.Handle("/{id}", api.NewGetRecipeHandler(recipeRepository)).Methods(http.MethodGet)
-	recipesSubrouter.Handle("/{id}", api.NewUpdateRecipeHandler(recipeRepository)).Methods(http.MethodPut)
-	recipesSubrouter.Handle("/{id}", api.NewDeleteRecipeHandler(recipeRepository)).Methods(http.MethodDelete)
-	recipesSubrouter.Handle("/", api.NewGetRecipesHandler(recipeRepository)).Methods(http.MethodGet)
-	recipesSubrouter.Handle("", api.NewGetRecipesHandler(recipeRepository)).Methods(http.MethodGet)
-	recipesSubrouter.Handle("", api.NewAddRecipeHandler(recipeRepository)).Methods(http.MethodPost)
-
-	sourcesSubrouter := router.PathPrefix("/api/sources").Subrouter()
-	sourcesSubrouter.Handle("/{id}", api.NewGetSourceHandler(sourceRepository)).Methods(http.MethodGet)
-	sourcesSubrouter.Handle("/{id}", api.NewUpdateSourceHandler(sourceRepository)).Methods(http.MethodPut)
-	sourcesSubrouter.Handle("/{id}", api.NewDeleteSourceHandler(sourceRepository)).Methods(http.MethodDelete)
-	sourcesSubrouter.Handle("/", api.NewGetSourcesHandler(sourceRepository)).Methods(http.MethodGet)
-	sourcesSubrouter.Handle("", api.NewGetSourcesHandler(sourceRepository)).Methods(http.MethodGet)
-	sourcesSubrouter.Handle("", api.NewAddSourceHandler(sourceRepository)).Methods(http.MethodPost)
+	api.RegisterRoutes(router, api.Repositories{
+		Recipes:       recipeRepository,
+		Sources:       sourceRepository,
+		MealPlans:     mealPlanRepository,
+		ShoppingLists: shoppingListRepository,
+		Pantry:        pantryRepository,
+		Revisions:     revisionRepository,
+		Idempotency:   idempotencyRepository,
+	}, authenticator, cookingTimers)
+
+	router.PathPrefix("/static/").Handler(web.NewStaticHandler("/static/")).Methods(http.MethodGet)
+	router.Handle("/login", web.NewLoginPageHandler(oidcProvider)).Methods(http.MethodGet)
+	router.Handle("/login", web.NewLoginHandler(authenticator, oidcProvider)).Methods(http.MethodPost)
+	if oidcProvider != nil {
+		router.Handle("/login/oidc", web.NewOIDCLoginHandler(oidcProvider)).Methods(http.MethodGet)
+		router.Handle("/login/oidc/callback", web.NewOIDCCallbackHandler(oidcProvider, authenticator)).Methods(http.MethodGet)
+	}
+	router.Handle("/logout", web.NewLogoutHandler(authenticator)).Methods(http.MethodPost)
+	router.Handle("/", http.RedirectHandler("/recipes", http.StatusFound)).Methods(http.MethodGet)
+	router.Handle("/recipes", web.NewRecipesHandler(recipeRepository, sourceRepository)).Methods(http.MethodGet)
+	router.Handle("/recipes/new", web.NewRecipeFormHandler(recipeRepository, sourceRepository)).Methods(http.MethodGet)
+	router.Handle("/recipes/new", web.NewSaveRecipeHandler(recipeRepository, sourceRepository, revisionRepository)).Methods(http.MethodPost)
+	router.Handle("/recipes/{id}", web.NewRecipeHandler(recipeRepository, sourceRepository)).Methods(http.MethodGet)
+	router.Handle("/recipes/{id}/edit", web.NewRecipeFormHandler(recipeRepository, sourceRepository)).Methods(http.MethodGet)
+	router.Handle("/recipes/{id}/edit", web.NewSaveRecipeHandler(recipeRepository, sourceRepository, revisionRepository)).Methods(http.MethodPost)
+	router.Handle("/recipes/{id}/cook", web.NewCookHandler(recipeRepository, cookingTimers)).Methods(http.MethodGet)
+	router.Handle("/recipes/{id}/cook/timers", web.NewStartCookTimerHandler(recipeRepository, cookingTimers)).Methods(http.MethodPost)
+	router.Handle("/recipes/{id}/cook/timers/{timerId}/remove", web.NewRemoveCookTimerHandler(cookingTimers)).Methods(http.MethodPost)
+	router.Handle("/recipes/{id}/delete", web.NewDeleteRecipeHandler(recipeRepository)).Methods(http.MethodPost)
+	router.Handle("/sources", web.NewSourcesHandler(sourceRepository)).Methods(http.MethodGet)
+	router.Handle("/sources", web.NewSaveSourceHandler(sourceRepository)).Methods(http.MethodPost)
+	router.Handle("/sources/{id}/edit", web.NewSourceFormHandler(sourceRepository)).Methods(http.MethodGet)
+	router.Handle("/sources/{id}/edit", web.NewSaveSourceHandler(sourceRepository)).Methods(http.MethodPost)
+	router.Handle("/sources/{id}/delete", web.NewDeleteSourceHandler(sourceRepository)).Methods(http.MethodPost)
 
 	log.Print("Starting web server")
 	log.Fatal(http.ListenAndServe(":5000", router))
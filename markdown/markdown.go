@@ -0,0 +1,254 @@
+package markdown
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/phlashdev/recipe-keeper-api/core"
+	"gopkg.in/yaml.v3"
+)
+
+const frontMatterDelimiter = "---"
+
+const (
+	sectionIngredients = "Ingredients"
+	sectionCookware    = "Cookware"
+	sectionSteps       = "Steps"
+)
+
+var sectionAliases = map[string]string{
+	"ingredients":  sectionIngredients,
+	"zutaten":      sectionIngredients,
+	"cookware":     sectionCookware,
+	"equipment":    sectionCookware,
+	"steps":        sectionSteps,
+	"instructions": sectionSteps,
+	"directions":   sectionSteps,
+	"zubereitung":  sectionSteps,
+}
+
+// timerLabel starts the nested list items holding the timers of a step.
+const timerLabel = "Timer:"
+
+var (
+	listItem        = regexp.MustCompile(`^\s*(?:[-*+]|\d+[.)])\s+(.*)$`)
+	continuationRow = regexp.MustCompile(`^\s{2,}\S`)
+	timerItem       = regexp.MustCompile(`^\s{2,}[-*+]\s+` + timerLabel + `\s*(.*)$`)
+)
+
+type frontMatter struct {
+	Title            string   `yaml:"title,omitempty"`
+	Category         string   `yaml:"category,omitempty"`
+	Allergens        []string `yaml:"allergens,omitempty,flow"`
+	Tags             []string `yaml:"tags,omitempty,flow"`
+	Servings         int      `yaml:"servings,omitempty"`
+	SourceID         string   `yaml:"sourceId,omitempty"`
+	SourceAnnotation string   `yaml:"sourceAnnotation,omitempty"`
+}
+
+// Document is a parsed Markdown recipe. The source is kept as given, validating it is left to the caller.
+type Document struct {
+	Recipe   core.Recipe
+	SourceID string
+}
+
+type ParseError struct {
+	Line    int
+	Message string
+}
+
+func (err *ParseError) Error() string {
+	return fmt.Sprintf("markdown line %d: %s", err.Line, err.Message)
+}
+
+// Format writes the recipe with its metadata as YAML front matter followed by headed sections
+// for ingredients, cookware and steps. The timers of a step are nested list items like
+// "- Timer: Bake 25m" below it.
+func Format(w io.Writer, recipe core.Recipe) error {
+	sourceID := ""
+	if !recipe.Source.IsZero() {
+		sourceID = recipe.Source.Hex()
+	}
+
+	metadata, err := yaml.Marshal(frontMatter{
+		Title:            recipe.Title,
+		Category:         recipe.Category,
+		Allergens:        recipe.Allergens,
+		Tags:             recipe.Tags,
+		Servings:         recipe.Servings,
+		SourceID:         sourceID,
+		SourceAnnotation: recipe.SourceAnnotation,
+	})
+	if err != nil {
+		return fmt.Errorf("error while writing front matter: %v", err)
+	}
+
+	writer := bufio.NewWriter(w)
+	fmt.Fprintf(writer, "%s\n%s%s\n\n# %s\n", frontMatterDelimiter, metadata, frontMatterDelimiter, recipe.Title)
+
+	if len(recipe.Ingredients) > 0 {
+		fmt.Fprintf(writer, "\n## %s\n\n", sectionIngredients)
+		for _, ingredient := range recipe.Ingredients {
+			fmt.Fprintf(writer, "- %s\n", ingredient)
+		}
+	}
+
+	if len(recipe.Cookware) > 0 {
+		fmt.Fprintf(writer, "\n## %s\n\n", sectionCookware)
+		for _, cookware := range recipe.Cookware {
+			fmt.Fprintf(writer, "- %s\n", cookware)
+		}
+	}
+
+	if len(recipe.Steps) > 0 {
+		fmt.Fprintf(writer, "\n## %s\n\n", sectionSteps)
+		for i, step := range recipe.Steps {
+			fmt.Fprintf(writer, "%d. %s\n", i+1, step.Text)
+			for _, timer := range step.Timers {
+				fmt.Fprintf(writer, "   - %s %s\n", timerLabel, strings.TrimSpace(timer.Name+" "+formatDuration(timer.Duration)))
+			}
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("error while writing markdown: %v", err)
+	}
+
+	return nil
+}
+
+// Parse reads a recipe in the format written by Format. The title is taken from the front matter
+// or else from the first level one heading. Unknown sections are ignored.
+func Parse(r io.Reader) (Document, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Document{}, fmt.Errorf("error while reading markdown: %v", err)
+	}
+
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+
+	var metadata frontMatter
+	lineNumber := 0
+	if len(lines) > 0 && strings.TrimSpace(lines[0]) == frontMatterDelimiter {
+		end := -1
+		for i := 1; i < len(lines); i++ {
+			if strings.TrimSpace(lines[i]) == frontMatterDelimiter {
+				end = i
+				break
+			}
+		}
+		if end < 0 {
+			return Document{}, &ParseError{Line: 1, Message: "front matter not terminated"}
+		}
+
+		decoder := yaml.NewDecoder(bytes.NewReader([]byte(strings.Join(lines[1:end], "\n"))))
+		if err := decoder.Decode(&metadata); err != nil && err != io.EOF {
+			return Document{}, &ParseError{Line: 2, Message: fmt.Sprintf("front matter not valid: %v", err)}
+		}
+		lineNumber = end + 1
+	}
+
+	recipe := core.Recipe{
+		Title:            metadata.Title,
+		Category:         metadata.Category,
+		Allergens:        metadata.Allergens,
+		Tags:             metadata.Tags,
+		Servings:         metadata.Servings,
+		SourceAnnotation: metadata.SourceAnnotation,
+	}
+
+	section := ""
+	var stepTexts []string
+	var stepTimers [][]core.Timer
+	for ; lineNumber < len(lines); lineNumber++ {
+		line := lines[lineNumber]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(trimmed, "# "):
+			if len(recipe.Title) == 0 {
+				recipe.Title = strings.TrimSpace(strings.TrimPrefix(trimmed, "# "))
+			}
+			section = ""
+		case strings.HasPrefix(trimmed, "## "):
+			section = sectionAliases[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(trimmed, "## ")))]
+		case len(section) == 0 || len(trimmed) == 0:
+		case timerItem.MatchString(line) && section == sectionSteps && len(stepTexts) > 0:
+			timer, err := parseTimer(timerItem.FindStringSubmatch(line)[1])
+			if err != nil {
+				return Document{}, &ParseError{Line: lineNumber + 1, Message: err.Error()}
+			}
+			stepTimers[len(stepTimers)-1] = append(stepTimers[len(stepTimers)-1], timer)
+		case continuationRow.MatchString(line) && !listItem.MatchString(line) && section == sectionSteps && len(stepTexts) > 0:
+			stepTexts[len(stepTexts)-1] += " " + trimmed
+		default:
+			match := listItem.FindStringSubmatch(line)
+			if match == nil {
+				if section == sectionSteps {
+					stepTexts = append(stepTexts, trimmed)
+					stepTimers = append(stepTimers, nil)
+					continue
+				}
+				return Document{}, &ParseError{Line: lineNumber + 1, Message: fmt.Sprintf("expected list item in section %s", section)}
+			}
+
+			item := strings.TrimSpace(match[1])
+			switch section {
+			case sectionIngredients:
+				recipe.Ingredients = append(recipe.Ingredients, core.ParseIngredient(item))
+			case sectionCookware:
+				recipe.Cookware = append(recipe.Cookware, item)
+			case sectionSteps:
+				stepTexts = append(stepTexts, item)
+				stepTimers = append(stepTimers, nil)
+			}
+		}
+	}
+
+	for i, text := range stepTexts {
+		step := core.NewStep(text, recipe.Ingredients)
+		step.Timers = stepTimers[i]
+		recipe.Steps = append(recipe.Steps, step)
+	}
+
+	return Document{
+		Recipe:   recipe,
+		SourceID: metadata.SourceID,
+	}, nil
+}
+
+// parseTimer reads a timer written by Format, the name followed by a duration like 25m or 1h30m.
+func parseTimer(text string) (core.Timer, error) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return core.Timer{}, fmt.Errorf("timer has no duration")
+	}
+
+	duration, err := time.ParseDuration(fields[len(fields)-1])
+	if err != nil || duration <= 0 {
+		return core.Timer{}, fmt.Errorf("timer '%s' does not end with a duration like 10m or 1h30m", text)
+	}
+
+	return core.Timer{
+		Name:     strings.Join(fields[:len(fields)-1], " "),
+		Duration: duration,
+	}, nil
+}
+
+// formatDuration writes durations without zero seconds or minutes, 25m instead of 25m0s.
+func formatDuration(duration time.Duration) string {
+	text := duration.String()
+	if strings.HasSuffix(text, "m0s") {
+		text = strings.TrimSuffix(text, "0s")
+	}
+	if strings.HasSuffix(text, "h0m") {
+		text = strings.TrimSuffix(text, "0m")
+	}
+
+	return text
+}
@@ -0,0 +1,57 @@
+package markdown
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/phlashdev/recipe-keeper-api/core"
+)
+
+func TestFormatParseRoundTrip(t *testing.T) {
+	recipe := core.Recipe{
+		Title:    "Bread",
+		Category: "Baking",
+		Tags:     []string{"yeast"},
+		Servings: 4,
+		Ingredients: []core.Ingredient{
+			{Name: "flour", Quantity: 500, Unit: "g"},
+			{Name: "water", Quantity: 350, Unit: "ml"},
+		},
+		Cookware: []string{"oven"},
+		Steps: []core.Step{
+			{Text: "Mix the flour and the water.", Ingredients: []string{"flour", "water"}},
+			{Text: "Let it rise, then bake.", Timers: []core.Timer{
+				{Name: "Rise", Duration: 90 * time.Minute},
+				{Duration: 40 * time.Minute},
+			}},
+		},
+	}
+
+	var buffer bytes.Buffer
+	if err := Format(&buffer, recipe); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buffer.String(), "   - Timer: Rise 1h30m\n") {
+		t.Errorf("markdown misses the timer:\n%s", buffer.String())
+	}
+
+	document, err := Parse(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(document.Recipe, recipe) {
+		t.Errorf("parsed recipe = %+v\nwant %+v", document.Recipe, recipe)
+	}
+}
+
+func TestParseTimerNotValid(t *testing.T) {
+	input := "# Bread\n\n## Steps\n\n1. Bake.\n   - Timer: Bake soon\n"
+
+	_, err := Parse(strings.NewReader(input))
+	if parseErr, ok := err.(*ParseError); !ok || parseErr.Line != 6 {
+		t.Errorf("err = %v, want ParseError in line 6", err)
+	}
+}
@@ -0,0 +1,89 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/phlashdev/recipe-keeper-api/core"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// errBatchFailed aborts the transaction of a batch with a failed operation.
+var errBatchFailed = errors.New("batch operation failed")
+
+// runBatch applies count operations in a transaction. Transactions need a replica set or sharded
+// cluster, on a standalone server every operation is applied on its own and the result is not
+// atomic.
+func runBatch(ctx context.Context, client *mongo.Client, count int, apply func(ctx context.Context, index int) core.BatchItemResult) (core.BatchResult, error) {
+	supported, err := supportsTransactions(ctx, client)
+	if err != nil {
+		return core.BatchResult{}, err
+	}
+	if !supported {
+		return core.BatchResult{Items: applyBatch(ctx, count, apply, false)}, nil
+	}
+
+	session, err := client.StartSession()
+	if err != nil {
+		return core.BatchResult{}, fmt.Errorf("error while starting session: %v", err)
+	}
+	defer session.EndSession(ctx)
+
+	var items []core.BatchItemResult
+	_, err = session.WithTransaction(ctx, func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		// a transient error runs the function again, so results of an earlier attempt are replaced
+		items = applyBatch(sessionCtx, count, apply, true)
+		if (core.BatchResult{Items: items}).Failed() {
+			return nil, errBatchFailed
+		}
+		return nil, nil
+	})
+	if errors.Is(err, errBatchFailed) {
+		errs := make([]error, len(items))
+		for i, item := range items {
+			var abortedErr *core.BatchAbortedError
+			if !errors.As(item.Err, &abortedErr) {
+				errs[i] = item.Err
+			}
+		}
+		return core.AbortBatch(errs), nil
+	}
+	if err != nil {
+		return core.BatchResult{}, fmt.Errorf("error while executing batch: %v", err)
+	}
+
+	return core.BatchResult{Atomic: true, Items: items}, nil
+}
+
+// applyBatch applies the operations in order. In a transaction it stops at the first failure,
+// the server aborts a transaction after a failed write anyway.
+func applyBatch(ctx context.Context, count int, apply func(ctx context.Context, index int) core.BatchItemResult, stopOnFailure bool) []core.BatchItemResult {
+	items := make([]core.BatchItemResult, count)
+	failed := false
+	for i := range items {
+		if failed && stopOnFailure {
+			items[i] = core.BatchItemResult{Err: &core.BatchAbortedError{}}
+			continue
+		}
+
+		items[i] = apply(ctx, i)
+		failed = failed || items[i].Err != nil
+	}
+
+	return items
+}
+
+func supportsTransactions(ctx context.Context, client *mongo.Client) (bool, error) {
+	var reply struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	err := client.Database("admin").RunCommand(ctx, bson.D{{Key: "isMaster", Value: 1}}).Decode(&reply)
+	if err != nil {
+		return false, fmt.Errorf("error while checking for transaction support: %v", err)
+	}
+
+	return len(reply.SetName) > 0 || reply.Msg == "isdbgrid", nil
+}
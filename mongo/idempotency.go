@@ -0,0 +1,91 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/phlashdev/recipe-keeper-api/core"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// idempotencyExpiry is how long responses are kept for retries.
+const idempotencyExpiry = 24 * time.Hour
+
+// MongoIdempotencyRepository relies on the unique _id to reserve a key, so of two concurrent
+// requests with the same key only one is processed.
+type MongoIdempotencyRepository struct {
+	idempotencyCollection *mongo.Collection
+}
+
+func NewMongoIdempotencyRepository(idempotencyCollection *mongo.Collection) *MongoIdempotencyRepository {
+	return &MongoIdempotencyRepository{
+		idempotencyCollection: idempotencyCollection,
+	}
+}
+
+// EnsureIndexes creates the TTL index removing expired records.
+func (repo *MongoIdempotencyRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := repo.idempotencyCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "createdAt", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(int32(idempotencyExpiry.Seconds())),
+	})
+	if err != nil {
+		return fmt.Errorf("error while creating index: %v", err)
+	}
+
+	return nil
+}
+
+func (repo *MongoIdempotencyRepository) GetIdempotencyRecord(ctx context.Context, key string) (core.IdempotencyRecord, error) {
+	var record core.IdempotencyRecord
+
+	filter := bson.M{"_id": key}
+	if err := repo.idempotencyCollection.FindOne(ctx, filter).Decode(&record); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return core.IdempotencyRecord{}, &core.IdempotencyRecordNotFoundError{
+				Key: key,
+			}
+		}
+		return core.IdempotencyRecord{}, fmt.Errorf("error while executing query: %v", err)
+	}
+
+	return record, nil
+}
+
+func (repo *MongoIdempotencyRepository) AddIdempotencyRecord(ctx context.Context, record core.IdempotencyRecord) error {
+	_, err := repo.idempotencyCollection.InsertOne(ctx, record)
+	if mongo.IsDuplicateKeyError(err) {
+		return &core.IdempotencyKeyExistsError{
+			Key: record.Key,
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("error while executing insert: %v", err)
+	}
+
+	return nil
+}
+
+func (repo *MongoIdempotencyRepository) CompleteIdempotencyRecord(ctx context.Context, record core.IdempotencyRecord) error {
+	filter := bson.M{"_id": record.Key}
+	_, err := repo.idempotencyCollection.ReplaceOne(ctx, filter, record)
+	if err != nil {
+		return fmt.Errorf("error while executing update: %v", err)
+	}
+
+	return nil
+}
+
+func (repo *MongoIdempotencyRepository) DeleteIdempotencyRecord(ctx context.Context, key string) error {
+	filter := bson.M{"_id": key}
+	_, err := repo.idempotencyCollection.DeleteOne(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("error while executing delete: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/phlashdev/recipe-keeper-api/core"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type MongoMealPlanRepository struct {
+	mealPlansCollection *mongo.Collection
+}
+
+func NewMongoMealPlanRepository(mealPlansCollection *mongo.Collection) *MongoMealPlanRepository {
+	return &MongoMealPlanRepository{
+		mealPlansCollection: mealPlansCollection,
+	}
+}
+
+func (repo *MongoMealPlanRepository) GetMealPlans(ctx context.Context) ([]core.MealPlan, error) {
+	var mealPlans []core.MealPlan
+	cursor, err := repo.mealPlansCollection.Find(ctx, bson.M{})
+	if err != nil {
+		return []core.MealPlan{}, fmt.Errorf("error while executing query: %v", err)
+	}
+
+	if err = cursor.All(ctx, &mealPlans); err != nil {
+		return []core.MealPlan{}, fmt.Errorf("error while iterating cursor: %v", err)
+	}
+
+	// cursor.All returns nil if collection is empty
+	if mealPlans == nil {
+		return []core.MealPlan{}, nil
+	}
+
+	return mealPlans, nil
+}
+
+func (repo *MongoMealPlanRepository) GetMealPlanByID(ctx context.Context, id string) (core.MealPlan, error) {
+	var mealPlan core.MealPlan
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return core.MealPlan{}, &core.MealPlanIDNotValidError{
+			ID: id,
+		}
+	}
+
+	filter := bson.M{"_id": objectID}
+	if err := repo.mealPlansCollection.FindOne(ctx, filter).Decode(&mealPlan); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return core.MealPlan{}, &core.MealPlanNotFoundError{
+				ID: id,
+			}
+		}
+		return core.MealPlan{}, fmt.Errorf("error while executing query: %v", err)
+	}
+
+	return mealPlan, nil
+}
+
+func (repo *MongoMealPlanRepository) AddMealPlan(ctx context.Context, mealPlan *core.MealPlan) error {
+	if err := validateMealSlots(*mealPlan); err != nil {
+		return err
+	}
+
+	mealPlan.ID = primitive.NewObjectID()
+
+	_, err := repo.mealPlansCollection.InsertOne(ctx, mealPlan)
+	if err != nil {
+		return fmt.Errorf("error while executing insert: %v", err)
+	}
+
+	return nil
+}
+
+func (repo *MongoMealPlanRepository) UpdateMealPlan(ctx context.Context, mealPlan core.MealPlan) error {
+	if err := validateMealSlots(mealPlan); err != nil {
+		return err
+	}
+
+	filter := bson.M{"_id": mealPlan.ID}
+	_, err := repo.mealPlansCollection.ReplaceOne(ctx, filter, mealPlan)
+	if err != nil {
+		return fmt.Errorf("error while executing update: %v", err)
+	}
+
+	return nil
+}
+
+func (repo *MongoMealPlanRepository) DeleteMealPlan(ctx context.Context, mealPlan core.MealPlan) error {
+	filter := bson.M{"_id": mealPlan.ID}
+	_, err := repo.mealPlansCollection.DeleteOne(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("error while executing delete: %v", err)
+	}
+
+	return nil
+}
+
+func validateMealSlots(mealPlan core.MealPlan) error {
+	for _, entry := range mealPlan.Entries {
+		if !core.IsMealSlotValid(entry.Slot) {
+			return &core.MealSlotNotValidError{
+				Slot: entry.Slot,
+			}
+		}
+	}
+
+	return nil
+}
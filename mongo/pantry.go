@@ -0,0 +1,145 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/phlashdev/recipe-keeper-api/core"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type MongoPantryRepository struct {
+	pantryCollection *mongo.Collection
+}
+
+func NewMongoPantryRepository(pantryCollection *mongo.Collection) *MongoPantryRepository {
+	return &MongoPantryRepository{
+		pantryCollection: pantryCollection,
+	}
+}
+
+func (repo *MongoPantryRepository) GetPantryItems(ctx context.Context) ([]core.PantryItem, error) {
+	var pantryItems []core.PantryItem
+	cursor, err := repo.pantryCollection.Find(ctx, bson.M{})
+	if err != nil {
+		return []core.PantryItem{}, fmt.Errorf("error while executing query: %v", err)
+	}
+
+	if err = cursor.All(ctx, &pantryItems); err != nil {
+		return []core.PantryItem{}, fmt.Errorf("error while iterating cursor: %v", err)
+	}
+
+	// cursor.All returns nil if collection is empty
+	if pantryItems == nil {
+		return []core.PantryItem{}, nil
+	}
+
+	return pantryItems, nil
+}
+
+func (repo *MongoPantryRepository) GetPantryItemByID(ctx context.Context, id string) (core.PantryItem, error) {
+	var pantryItem core.PantryItem
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return core.PantryItem{}, &core.PantryItemIDNotValidError{
+			ID: id,
+		}
+	}
+
+	filter := bson.M{"_id": objectID}
+	if err := repo.pantryCollection.FindOne(ctx, filter).Decode(&pantryItem); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return core.PantryItem{}, &core.PantryItemNotFoundError{
+				ID: id,
+			}
+		}
+		return core.PantryItem{}, fmt.Errorf("error while executing query: %v", err)
+	}
+
+	return pantryItem, nil
+}
+
+func (repo *MongoPantryRepository) AddPantryItem(ctx context.Context, pantryItem *core.PantryItem) error {
+	pantryItem.ID = primitive.NewObjectID()
+
+	_, err := repo.pantryCollection.InsertOne(ctx, pantryItem)
+	if err != nil {
+		return fmt.Errorf("error while executing insert: %v", err)
+	}
+
+	return nil
+}
+
+func (repo *MongoPantryRepository) UpdatePantryItem(ctx context.Context, pantryItem core.PantryItem) error {
+	filter := bson.M{"_id": pantryItem.ID}
+	_, err := repo.pantryCollection.ReplaceOne(ctx, filter, pantryItem)
+	if err != nil {
+		return fmt.Errorf("error while executing update: %v", err)
+	}
+
+	return nil
+}
+
+func (repo *MongoPantryRepository) DeletePantryItem(ctx context.Context, pantryItem core.PantryItem) error {
+	filter := bson.M{"_id": pantryItem.ID}
+	_, err := repo.pantryCollection.DeleteOne(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("error while executing delete: %v", err)
+	}
+
+	return nil
+}
+
+// RestockPantry adds the checked shopping list items to the pantry. Stored items are increased
+// with $inc, so changes made in the meantime are kept. An item deleted or given another unit in
+// the meantime is added again.
+func (repo *MongoPantryRepository) RestockPantry(ctx context.Context, items []core.ShoppingListItem) error {
+	pantryItems, err := repo.GetPantryItems(ctx)
+	if err != nil {
+		return err
+	}
+
+	updated, added := core.RestockPantryItems(pantryItems, items)
+	for _, increment := range updated {
+		filter := bson.M{"_id": increment.ID, "unit": unitFilter(increment.Unit)}
+		result, err := repo.pantryCollection.UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"quantity": increment.Quantity}})
+		if err != nil {
+			return fmt.Errorf("error while executing restock: %v", err)
+		}
+		if result.MatchedCount == 0 {
+			added = append(added, core.PantryItem{
+				Name:     increment.Name,
+				Quantity: increment.Quantity,
+				Unit:     increment.Unit,
+			})
+		}
+	}
+	if len(added) == 0 {
+		return nil
+	}
+
+	documents := make([]interface{}, 0, len(added))
+	for _, pantryItem := range added {
+		pantryItem.ID = primitive.NewObjectID()
+		documents = append(documents, pantryItem)
+	}
+	_, err = repo.pantryCollection.InsertMany(ctx, documents)
+	if err != nil {
+		return fmt.Errorf("error while executing restock: %v", err)
+	}
+
+	return nil
+}
+
+// unitFilter matches the unit, an empty unit is not stored.
+func unitFilter(unit string) interface{} {
+	if len(unit) == 0 {
+		return bson.M{"$in": bson.A{"", nil}}
+	}
+
+	return unit
+}
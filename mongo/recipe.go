@@ -4,11 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"regexp"
+	"time"
 
 	"github.com/phlashdev/recipe-keeper-api/core"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type MongoRecipeRepository struct {
@@ -21,9 +24,34 @@ func NewMongoRecipeRepository(recipesCollection *mongo.Collection) *MongoRecipeR
 	}
 }
 
-func (repo *MongoRecipeRepository) GetRecipes(ctx context.Context) ([]core.Recipe, error) {
+func (repo *MongoRecipeRepository) GetRecipes(ctx context.Context, filter core.RecipeFilter) ([]core.Recipe, error) {
+	query := bson.M{deletedAtField: notDeleted()}
+	if len(filter.Title) > 0 {
+		query["title"] = primitive.Regex{Pattern: regexp.QuoteMeta(filter.Title), Options: "i"}
+	}
+	if len(filter.Category) > 0 {
+		query["category"] = filter.Category
+	}
+	if len(filter.Tag) > 0 {
+		query["tags"] = filter.Tag
+	}
+	if len(filter.SourceID) > 0 {
+		sourceID, err := primitive.ObjectIDFromHex(filter.SourceID)
+		if err != nil {
+			return []core.Recipe{}, &core.SourceIDNotValidError{
+				ID: filter.SourceID,
+			}
+		}
+		query["source"] = sourceID
+	}
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetSkip(int64(filter.Offset))
+	if filter.Limit > 0 {
+		findOptions.SetLimit(int64(filter.Limit))
+	}
+
 	var recipes []core.Recipe
-	cursor, err := repo.recipesCollection.Find(ctx, bson.M{})
+	cursor, err := repo.recipesCollection.Find(ctx, query, findOptions)
 	if err != nil {
 		return []core.Recipe{}, fmt.Errorf("error while executing query: %v", err)
 	}
@@ -51,7 +79,7 @@ func (repo *MongoRecipeRepository) GetRecipeByID(ctx context.Context, id string)
 		}
 	}
 
-	filter := bson.M{"_id": objectID}
+	filter := bson.M{"_id": objectID, deletedAtField: notDeleted()}
 	if err := repo.recipesCollection.FindOne(ctx, filter).Decode(&recipe); err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
 			return core.Recipe{}, &core.RecipeNotFoundError{
@@ -65,7 +93,12 @@ func (repo *MongoRecipeRepository) GetRecipeByID(ctx context.Context, id string)
 }
 
 func (repo *MongoRecipeRepository) AddRecipe(ctx context.Context, recipe *core.Recipe) error {
+	if err := core.ValidateRecipe(*recipe); err != nil {
+		return err
+	}
+
 	recipe.ID = primitive.NewObjectID()
+	recipe.Version = 1
 
 	_, err := repo.recipesCollection.InsertOne(ctx, recipe)
 	if err != nil {
@@ -75,22 +108,204 @@ func (repo *MongoRecipeRepository) AddRecipe(ctx context.Context, recipe *core.R
 	return nil
 }
 
+// UpdateRecipe replaces the recipe if its version is still the stored one and increments the version.
 func (repo *MongoRecipeRepository) UpdateRecipe(ctx context.Context, recipe core.Recipe) error {
-	filter := bson.M{"_id": recipe.ID}
-	_, err := repo.recipesCollection.ReplaceOne(ctx, filter, recipe)
+	if err := core.ValidateRecipe(recipe); err != nil {
+		return err
+	}
+
+	filter := bson.M{"_id": recipe.ID, versionField: versionFilter(recipe.Version), deletedAtField: notDeleted()}
+	recipe.Version++
+	result, err := repo.recipesCollection.ReplaceOne(ctx, filter, recipe)
 	if err != nil {
 		return fmt.Errorf("error while executing update: %v", err)
 	}
+	if result.MatchedCount == 0 {
+		return &core.VersionConflictError{
+			ID:      recipe.ID.Hex(),
+			Version: recipe.Version - 1,
+		}
+	}
 
 	return nil
 }
 
+// DeleteRecipe moves the recipe to the trash if its version is still the stored one. It can be
+// restored until it is purged.
 func (repo *MongoRecipeRepository) DeleteRecipe(ctx context.Context, recipe core.Recipe) error {
-	filter := bson.M{"_id": recipe.ID}
-	_, err := repo.recipesCollection.DeleteOne(ctx, filter)
+	filter := bson.M{"_id": recipe.ID, versionField: versionFilter(recipe.Version), deletedAtField: notDeleted()}
+	result, err := repo.recipesCollection.UpdateOne(ctx, filter, moveToTrash())
 	if err != nil {
 		return fmt.Errorf("error while executing delete: %v", err)
 	}
+	if result.MatchedCount == 0 {
+		return &core.VersionConflictError{
+			ID:      recipe.ID.Hex(),
+			Version: recipe.Version,
+		}
+	}
+
+	return nil
+}
+
+// ImportRecipes stores the recipes as they are, including their id. A recipe replacing a stored one
+// gets a version above the stored one, see importVersions. They are not validated as a backup has
+// to be restored even if it was taken before a validation rule was added.
+func (repo *MongoRecipeRepository) ImportRecipes(ctx context.Context, recipes []core.Recipe) error {
+	if len(recipes) == 0 {
+		return nil
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(recipes))
+	versions := make([]int, 0, len(recipes))
+	for _, recipe := range recipes {
+		ids = append(ids, recipe.ID)
+		versions = append(versions, recipe.Version)
+	}
+	versions, err := importVersions(ctx, repo.recipesCollection, ids, versions)
+	if err != nil {
+		return err
+	}
+
+	models := make([]mongo.WriteModel, 0, len(recipes))
+	for i, recipe := range recipes {
+		recipe.Version = versions[i]
+		models = append(models, mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"_id": recipe.ID}).
+			SetReplacement(recipe).
+			SetUpsert(true))
+	}
+
+	_, err = repo.recipesCollection.BulkWrite(ctx, models)
+	if err != nil {
+		return fmt.Errorf("error while executing import: %v", err)
+	}
+
+	return nil
+}
+
+func (repo *MongoRecipeRepository) BatchRecipes(ctx context.Context, operations []core.RecipeOperation) (core.BatchResult, error) {
+	return runBatch(ctx, repo.recipesCollection.Database().Client(), len(operations), func(ctx context.Context, index int) core.BatchItemResult {
+		recipe := operations[index].Recipe
+		var err error
+		switch operations[index].Operation {
+		case core.BatchOperationCreate:
+			err = repo.AddRecipe(ctx, &recipe)
+		case core.BatchOperationUpdate:
+			err = repo.UpdateRecipe(ctx, recipe)
+			recipe.Version++
+		case core.BatchOperationDelete:
+			err = repo.DeleteRecipe(ctx, recipe)
+		default:
+			err = &core.BatchOperationNotValidError{Operation: operations[index].Operation}
+		}
+		if err != nil {
+			return core.BatchItemResult{Err: err}
+		}
+
+		return core.BatchItemResult{ID: recipe.ID.Hex(), Version: recipe.Version}
+	})
+}
+
+func (repo *MongoRecipeRepository) GetDeletedRecipes(ctx context.Context) ([]core.Recipe, error) {
+	var recipes []core.Recipe
+	findOptions := options.Find().SetSort(bson.D{{Key: deletedAtField, Value: -1}})
+	cursor, err := repo.recipesCollection.Find(ctx, bson.M{deletedAtField: deleted()}, findOptions)
+	if err != nil {
+		return []core.Recipe{}, fmt.Errorf("error while executing query: %v", err)
+	}
+
+	if err = cursor.All(ctx, &recipes); err != nil {
+		return []core.Recipe{}, fmt.Errorf("error while iterating cursor: %v", err)
+	}
+
+	// cursor.All returns nil if collection is empty
+	if recipes == nil {
+		return []core.Recipe{}, nil
+	}
+
+	return recipes, nil
+}
+
+func (repo *MongoRecipeRepository) RestoreRecipe(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return &core.RecipeIDNotValidError{
+			ID: id,
+		}
+	}
+
+	filter := bson.M{"_id": objectID, deletedAtField: deleted()}
+	result, err := repo.recipesCollection.UpdateOne(ctx, filter, restoreFromTrash())
+	if err != nil {
+		return fmt.Errorf("error while executing restore: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return &core.RecipeNotFoundError{
+			ID: id,
+		}
+	}
 
 	return nil
 }
+
+// PurgeRecipe permanently deletes a recipe from the trash.
+func (repo *MongoRecipeRepository) PurgeRecipe(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return &core.RecipeIDNotValidError{
+			ID: id,
+		}
+	}
+
+	filter := bson.M{"_id": objectID, deletedAtField: deleted()}
+	result, err := repo.recipesCollection.DeleteOne(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("error while executing purge: %v", err)
+	}
+	if result.DeletedCount == 0 {
+		return &core.RecipeNotFoundError{
+			ID: id,
+		}
+	}
+
+	return nil
+}
+
+// PurgeDeletedRecipes permanently deletes the recipes moved to the trash before the given time.
+func (repo *MongoRecipeRepository) PurgeDeletedRecipes(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	filter := bson.M{deletedAtField: bson.M{"$lt": deletedBefore}}
+	result, err := repo.recipesCollection.DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("error while executing purge: %v", err)
+	}
+
+	return result.DeletedCount, nil
+}
+
+// PatchRecipe retries when another write got in between reading and writing the recipe, so the
+// patch is always applied to the latest version.
+func (repo *MongoRecipeRepository) PatchRecipe(ctx context.Context, id string, patch func(recipe *core.Recipe) error) (core.Recipe, error) {
+	for attempt := 1; ; attempt++ {
+		recipe, err := repo.GetRecipeByID(ctx, id)
+		if err != nil {
+			return core.Recipe{}, err
+		}
+
+		if err := patch(&recipe); err != nil {
+			return core.Recipe{}, err
+		}
+
+		err = repo.UpdateRecipe(ctx, recipe)
+		var conflictErr *core.VersionConflictError
+		if errors.As(err, &conflictErr) && attempt < patchAttempts {
+			continue
+		}
+		if err != nil {
+			return core.Recipe{}, err
+		}
+
+		recipe.Version++
+		return recipe, nil
+	}
+}
@@ -0,0 +1,118 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/phlashdev/recipe-keeper-api/core"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoRevisionRepository stores the revisions of all recipes in one collection. A unique index
+// on recipe and number keeps concurrent updates from writing the same revision twice.
+// revisionAttempts limits how often a revision number is taken again after a concurrent write took it.
+const revisionAttempts = 5
+
+type MongoRevisionRepository struct {
+	revisionsCollection *mongo.Collection
+}
+
+func NewMongoRevisionRepository(revisionsCollection *mongo.Collection) *MongoRevisionRepository {
+	return &MongoRevisionRepository{
+		revisionsCollection: revisionsCollection,
+	}
+}
+
+// EnsureIndexes creates the unique index on recipe and revision number.
+func (repo *MongoRevisionRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := repo.revisionsCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "recipe", Value: 1}, {Key: "number", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("error while creating index: %v", err)
+	}
+
+	return nil
+}
+
+func (repo *MongoRevisionRepository) GetRevisions(ctx context.Context, recipeID string) ([]core.Revision, error) {
+	objectID, err := primitive.ObjectIDFromHex(recipeID)
+	if err != nil {
+		return []core.Revision{}, &core.RecipeIDNotValidError{
+			ID: recipeID,
+		}
+	}
+
+	var revisions []core.Revision
+	findOptions := options.Find().SetSort(bson.D{{Key: "number", Value: 1}})
+	cursor, err := repo.revisionsCollection.Find(ctx, bson.M{"recipe": objectID}, findOptions)
+	if err != nil {
+		return []core.Revision{}, fmt.Errorf("error while executing query: %v", err)
+	}
+
+	if err = cursor.All(ctx, &revisions); err != nil {
+		return []core.Revision{}, fmt.Errorf("error while iterating cursor: %v", err)
+	}
+
+	// cursor.All returns nil if collection is empty
+	if revisions == nil {
+		return []core.Revision{}, nil
+	}
+
+	return revisions, nil
+}
+
+func (repo *MongoRevisionRepository) GetRevision(ctx context.Context, recipeID string, number int) (core.Revision, error) {
+	var revision core.Revision
+
+	objectID, err := primitive.ObjectIDFromHex(recipeID)
+	if err != nil {
+		return core.Revision{}, &core.RecipeIDNotValidError{
+			ID: recipeID,
+		}
+	}
+
+	filter := bson.M{"recipe": objectID, "number": number}
+	if err := repo.revisionsCollection.FindOne(ctx, filter).Decode(&revision); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return core.Revision{}, &core.RevisionNotFoundError{
+				RecipeID: recipeID,
+				Number:   number,
+			}
+		}
+		return core.Revision{}, fmt.Errorf("error while executing query: %v", err)
+	}
+
+	return revision, nil
+}
+
+// AddRevision appends the revision with the next number of its recipe. Concurrent writers may
+// pick the same number, the unique index refuses all but one and the others take the next one.
+func (repo *MongoRevisionRepository) AddRevision(ctx context.Context, revision *core.Revision) error {
+	for attempt := 1; ; attempt++ {
+		var latest core.Revision
+		findOptions := options.FindOne().SetSort(bson.D{{Key: "number", Value: -1}})
+		err := repo.revisionsCollection.FindOne(ctx, bson.M{"recipe": revision.Recipe}, findOptions).Decode(&latest)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return fmt.Errorf("error while executing query: %v", err)
+		}
+
+		revision.ID = primitive.NewObjectID()
+		revision.Number = latest.Number + 1
+
+		_, err = repo.revisionsCollection.InsertOne(ctx, revision)
+		if mongo.IsDuplicateKeyError(err) && attempt < revisionAttempts {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("error while executing insert: %v", err)
+		}
+
+		return nil
+	}
+}
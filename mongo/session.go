@@ -0,0 +1,83 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/phlashdev/recipe-keeper-api/core"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoSessionRepository struct {
+	sessionsCollection *mongo.Collection
+}
+
+func NewMongoSessionRepository(sessionsCollection *mongo.Collection) *MongoSessionRepository {
+	return &MongoSessionRepository{
+		sessionsCollection: sessionsCollection,
+	}
+}
+
+// EnsureIndexes creates the TTL index removing expired sessions and the index to find the
+// sessions of a user.
+func (repo *MongoSessionRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := repo.sessionsCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+		{
+			Keys: bson.D{{Key: "user", Value: 1}},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error while creating index: %v", err)
+	}
+
+	return nil
+}
+
+func (repo *MongoSessionRepository) GetSession(ctx context.Context, tokenHash string) (core.Session, error) {
+	var session core.Session
+
+	filter := bson.M{"_id": tokenHash}
+	if err := repo.sessionsCollection.FindOne(ctx, filter).Decode(&session); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return core.Session{}, &core.SessionNotFoundError{}
+		}
+		return core.Session{}, fmt.Errorf("error while executing query: %v", err)
+	}
+
+	return session, nil
+}
+
+func (repo *MongoSessionRepository) AddSession(ctx context.Context, session core.Session) error {
+	_, err := repo.sessionsCollection.InsertOne(ctx, session)
+	if err != nil {
+		return fmt.Errorf("error while executing insert: %v", err)
+	}
+
+	return nil
+}
+
+func (repo *MongoSessionRepository) DeleteSession(ctx context.Context, tokenHash string) error {
+	_, err := repo.sessionsCollection.DeleteOne(ctx, bson.M{"_id": tokenHash})
+	if err != nil {
+		return fmt.Errorf("error while executing delete: %v", err)
+	}
+
+	return nil
+}
+
+func (repo *MongoSessionRepository) DeleteUserSessions(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := repo.sessionsCollection.DeleteMany(ctx, bson.M{"user": userID})
+	if err != nil {
+		return fmt.Errorf("error while executing delete: %v", err)
+	}
+
+	return nil
+}
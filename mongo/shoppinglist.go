@@ -0,0 +1,186 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/phlashdev/recipe-keeper-api/core"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type MongoShoppingListRepository struct {
+	shoppingListsCollection *mongo.Collection
+}
+
+func NewMongoShoppingListRepository(shoppingListsCollection *mongo.Collection) *MongoShoppingListRepository {
+	return &MongoShoppingListRepository{
+		shoppingListsCollection: shoppingListsCollection,
+	}
+}
+
+// EnsureItemIDs gives the items of lists stored before items had an id one, so they can be ticked.
+func (repo *MongoShoppingListRepository) EnsureItemIDs(ctx context.Context) error {
+	var shoppingLists []core.ShoppingList
+	filter := bson.M{"items": bson.M{"$elemMatch": bson.M{"id": bson.M{"$exists": false}}}}
+	cursor, err := repo.shoppingListsCollection.Find(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("error while executing query: %v", err)
+	}
+
+	if err = cursor.All(ctx, &shoppingLists); err != nil {
+		return fmt.Errorf("error while iterating cursor: %v", err)
+	}
+
+	for _, shoppingList := range shoppingLists {
+		// only the ids are set, so ticks made in the meantime are kept
+		for i, item := range shoppingList.Items {
+			if !item.ID.IsZero() {
+				continue
+			}
+
+			filter := bson.M{"_id": shoppingList.ID, fmt.Sprintf("items.%d.id", i): bson.M{"$exists": false}}
+			update := bson.M{"$set": bson.M{fmt.Sprintf("items.%d.id", i): primitive.NewObjectID()}}
+			if _, err := repo.shoppingListsCollection.UpdateOne(ctx, filter, update); err != nil {
+				return fmt.Errorf("error while executing update: %v", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (repo *MongoShoppingListRepository) GetShoppingLists(ctx context.Context) ([]core.ShoppingList, error) {
+	var shoppingLists []core.ShoppingList
+	cursor, err := repo.shoppingListsCollection.Find(ctx, bson.M{})
+	if err != nil {
+		return []core.ShoppingList{}, fmt.Errorf("error while executing query: %v", err)
+	}
+
+	if err = cursor.All(ctx, &shoppingLists); err != nil {
+		return []core.ShoppingList{}, fmt.Errorf("error while iterating cursor: %v", err)
+	}
+
+	// cursor.All returns nil if collection is empty
+	if shoppingLists == nil {
+		return []core.ShoppingList{}, nil
+	}
+
+	return shoppingLists, nil
+}
+
+func (repo *MongoShoppingListRepository) GetShoppingListByID(ctx context.Context, id string) (core.ShoppingList, error) {
+	var shoppingList core.ShoppingList
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return core.ShoppingList{}, &core.ShoppingListIDNotValidError{
+			ID: id,
+		}
+	}
+
+	filter := bson.M{"_id": objectID}
+	if err := repo.shoppingListsCollection.FindOne(ctx, filter).Decode(&shoppingList); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return core.ShoppingList{}, &core.ShoppingListNotFoundError{
+				ID: id,
+			}
+		}
+		return core.ShoppingList{}, fmt.Errorf("error while executing query: %v", err)
+	}
+
+	return shoppingList, nil
+}
+
+func (repo *MongoShoppingListRepository) AddShoppingList(ctx context.Context, shoppingList *core.ShoppingList) error {
+	shoppingList.ID = primitive.NewObjectID()
+
+	_, err := repo.shoppingListsCollection.InsertOne(ctx, shoppingList)
+	if err != nil {
+		return fmt.Errorf("error while executing insert: %v", err)
+	}
+
+	return nil
+}
+
+func (repo *MongoShoppingListRepository) UpdateShoppingList(ctx context.Context, shoppingList core.ShoppingList) error {
+	filter := bson.M{"_id": shoppingList.ID}
+	_, err := repo.shoppingListsCollection.ReplaceOne(ctx, filter, shoppingList)
+	if err != nil {
+		return fmt.Errorf("error while executing update: %v", err)
+	}
+
+	return nil
+}
+
+func (repo *MongoShoppingListRepository) DeleteShoppingList(ctx context.Context, shoppingList core.ShoppingList) error {
+	filter := bson.M{"_id": shoppingList.ID}
+	_, err := repo.shoppingListsCollection.DeleteOne(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("error while executing delete: %v", err)
+	}
+
+	return nil
+}
+
+// CheckShoppingListItem ticks an item off or on without replacing the list, so concurrent
+// shoppers do not overwrite each other. The item is found by its id, its position may have
+// changed since the list was read.
+func (repo *MongoShoppingListRepository) CheckShoppingListItem(ctx context.Context, shoppingList core.ShoppingList, itemID string, checked bool) error {
+	objectID, err := primitive.ObjectIDFromHex(itemID)
+	if err != nil {
+		return &core.ShoppingListItemNotFoundError{
+			ID: itemID,
+		}
+	}
+
+	filter := bson.M{"_id": shoppingList.ID, "items.id": objectID}
+	update := bson.M{"$set": bson.M{"items.$.checked": checked}}
+	result, err := repo.shoppingListsCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("error while executing update: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return &core.ShoppingListItemNotFoundError{
+			ID: itemID,
+		}
+	}
+
+	return nil
+}
+
+// CompleteShoppingList sets the completion time. The list is only updated if it was not
+// completed before, so it cannot be completed twice by concurrent requests.
+func (repo *MongoShoppingListRepository) CompleteShoppingList(ctx context.Context, shoppingList *core.ShoppingList) error {
+	completedAt := time.Now().UTC().Truncate(time.Millisecond)
+
+	filter := bson.M{"_id": shoppingList.ID, "completedAt": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"completedAt": completedAt}}
+	result, err := repo.shoppingListsCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("error while executing update: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return &core.ShoppingListCompletedError{
+			ID: shoppingList.ID.Hex(),
+		}
+	}
+
+	shoppingList.CompletedAt = completedAt
+	return nil
+}
+
+// ReopenShoppingList removes the completion time, but only the one set by the completion it undoes.
+func (repo *MongoShoppingListRepository) ReopenShoppingList(ctx context.Context, shoppingList core.ShoppingList) error {
+	filter := bson.M{"_id": shoppingList.ID, "completedAt": shoppingList.CompletedAt}
+	update := bson.M{"$unset": bson.M{"completedAt": ""}}
+	_, err := repo.shoppingListsCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("error while executing update: %v", err)
+	}
+
+	return nil
+}
@@ -4,11 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/phlashdev/recipe-keeper-api/core"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type MongoSourceRepository struct {
@@ -23,7 +25,7 @@ func NewMongoSourceRepository(sourcesCollection *mongo.Collection) *MongoSourceR
 
 func (repo *MongoSourceRepository) GetSources(ctx context.Context) ([]core.Source, error) {
 	var sources []core.Source
-	cursor, err := repo.sourcesCollection.Find(ctx, bson.M{})
+	cursor, err := repo.sourcesCollection.Find(ctx, bson.M{deletedAtField: notDeleted()})
 	if err != nil {
 		return []core.Source{}, fmt.Errorf("error while executing query: %v", err)
 	}
@@ -51,7 +53,7 @@ func (repo *MongoSourceRepository) GetSourceByID(ctx context.Context, id string)
 		}
 	}
 
-	filter := bson.M{"_id": objectID}
+	filter := bson.M{"_id": objectID, deletedAtField: notDeleted()}
 	if err := repo.sourcesCollection.FindOne(ctx, filter).Decode(&source); err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
 			return core.Source{}, &core.SourceNotFoundError{
@@ -65,14 +67,12 @@ func (repo *MongoSourceRepository) GetSourceByID(ctx context.Context, id string)
 }
 
 func (repo *MongoSourceRepository) AddSource(ctx context.Context, source *core.Source) error {
-	sourceTypeIsValid := source.Type == core.SourceTypeBook || source.Type == core.SourceTypeUrl || source.Type == core.SourceTypeCustom
-	if !sourceTypeIsValid {
-		return &core.SourceTypeNotValidError{
-			SourceType: source.Type,
-		}
+	if err := core.ValidateSource(*source); err != nil {
+		return err
 	}
 
 	source.ID = primitive.NewObjectID()
+	source.Version = 1
 
 	_, err := repo.sourcesCollection.InsertOne(ctx, source)
 	if err != nil {
@@ -82,22 +82,203 @@ func (repo *MongoSourceRepository) AddSource(ctx context.Context, source *core.S
 	return nil
 }
 
+// UpdateSource replaces the source if its version is still the stored one and increments the version.
 func (repo *MongoSourceRepository) UpdateSource(ctx context.Context, source core.Source) error {
-	filter := bson.M{"_id": source.ID}
-	_, err := repo.sourcesCollection.ReplaceOne(ctx, filter, source)
+	if err := core.ValidateSource(source); err != nil {
+		return err
+	}
+
+	filter := bson.M{"_id": source.ID, versionField: versionFilter(source.Version), deletedAtField: notDeleted()}
+	source.Version++
+	result, err := repo.sourcesCollection.ReplaceOne(ctx, filter, source)
 	if err != nil {
 		return fmt.Errorf("error while executing update: %v", err)
 	}
+	if result.MatchedCount == 0 {
+		return &core.VersionConflictError{
+			ID:      source.ID.Hex(),
+			Version: source.Version - 1,
+		}
+	}
 
 	return nil
 }
 
+// DeleteSource moves the source to the trash if its version is still the stored one. It can be
+// restored until it is purged.
 func (repo *MongoSourceRepository) DeleteSource(ctx context.Context, source core.Source) error {
-	filter := bson.M{"_id": source.ID}
-	_, err := repo.sourcesCollection.DeleteOne(ctx, filter)
+	filter := bson.M{"_id": source.ID, versionField: versionFilter(source.Version), deletedAtField: notDeleted()}
+	result, err := repo.sourcesCollection.UpdateOne(ctx, filter, moveToTrash())
 	if err != nil {
 		return fmt.Errorf("error while executing delete: %v", err)
 	}
+	if result.MatchedCount == 0 {
+		return &core.VersionConflictError{
+			ID:      source.ID.Hex(),
+			Version: source.Version,
+		}
+	}
+
+	return nil
+}
+
+// ImportSources stores the sources as they are including their id, the versions are increased
+// like those of ImportRecipes.
+func (repo *MongoSourceRepository) ImportSources(ctx context.Context, sources []core.Source) error {
+	if len(sources) == 0 {
+		return nil
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(sources))
+	versions := make([]int, 0, len(sources))
+	for _, source := range sources {
+		ids = append(ids, source.ID)
+		versions = append(versions, source.Version)
+	}
+	versions, err := importVersions(ctx, repo.sourcesCollection, ids, versions)
+	if err != nil {
+		return err
+	}
+
+	models := make([]mongo.WriteModel, 0, len(sources))
+	for i, source := range sources {
+		source.Version = versions[i]
+		models = append(models, mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"_id": source.ID}).
+			SetReplacement(source).
+			SetUpsert(true))
+	}
+
+	_, err = repo.sourcesCollection.BulkWrite(ctx, models)
+	if err != nil {
+		return fmt.Errorf("error while executing import: %v", err)
+	}
+
+	return nil
+}
+
+func (repo *MongoSourceRepository) BatchSources(ctx context.Context, operations []core.SourceOperation) (core.BatchResult, error) {
+	return runBatch(ctx, repo.sourcesCollection.Database().Client(), len(operations), func(ctx context.Context, index int) core.BatchItemResult {
+		source := operations[index].Source
+		var err error
+		switch operations[index].Operation {
+		case core.BatchOperationCreate:
+			err = repo.AddSource(ctx, &source)
+		case core.BatchOperationUpdate:
+			err = repo.UpdateSource(ctx, source)
+			source.Version++
+		case core.BatchOperationDelete:
+			err = repo.DeleteSource(ctx, source)
+		default:
+			err = &core.BatchOperationNotValidError{Operation: operations[index].Operation}
+		}
+		if err != nil {
+			return core.BatchItemResult{Err: err}
+		}
+
+		return core.BatchItemResult{ID: source.ID.Hex(), Version: source.Version}
+	})
+}
+
+func (repo *MongoSourceRepository) GetDeletedSources(ctx context.Context) ([]core.Source, error) {
+	var sources []core.Source
+	findOptions := options.Find().SetSort(bson.D{{Key: deletedAtField, Value: -1}})
+	cursor, err := repo.sourcesCollection.Find(ctx, bson.M{deletedAtField: deleted()}, findOptions)
+	if err != nil {
+		return []core.Source{}, fmt.Errorf("error while executing query: %v", err)
+	}
+
+	if err = cursor.All(ctx, &sources); err != nil {
+		return []core.Source{}, fmt.Errorf("error while iterating cursor: %v", err)
+	}
+
+	// cursor.All returns nil if collection is empty
+	if sources == nil {
+		return []core.Source{}, nil
+	}
+
+	return sources, nil
+}
+
+func (repo *MongoSourceRepository) RestoreSource(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return &core.SourceIDNotValidError{
+			ID: id,
+		}
+	}
+
+	filter := bson.M{"_id": objectID, deletedAtField: deleted()}
+	result, err := repo.sourcesCollection.UpdateOne(ctx, filter, restoreFromTrash())
+	if err != nil {
+		return fmt.Errorf("error while executing restore: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return &core.SourceNotFoundError{
+			ID: id,
+		}
+	}
+
+	return nil
+}
+
+// PurgeSource permanently deletes a source from the trash.
+func (repo *MongoSourceRepository) PurgeSource(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return &core.SourceIDNotValidError{
+			ID: id,
+		}
+	}
+
+	filter := bson.M{"_id": objectID, deletedAtField: deleted()}
+	result, err := repo.sourcesCollection.DeleteOne(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("error while executing purge: %v", err)
+	}
+	if result.DeletedCount == 0 {
+		return &core.SourceNotFoundError{
+			ID: id,
+		}
+	}
 
 	return nil
 }
+
+// PurgeDeletedSources permanently deletes the sources moved to the trash before the given time.
+func (repo *MongoSourceRepository) PurgeDeletedSources(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	filter := bson.M{deletedAtField: bson.M{"$lt": deletedBefore}}
+	result, err := repo.sourcesCollection.DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("error while executing purge: %v", err)
+	}
+
+	return result.DeletedCount, nil
+}
+
+// PatchSource retries when another write got in between reading and writing the source, so the
+// patch is always applied to the latest version.
+func (repo *MongoSourceRepository) PatchSource(ctx context.Context, id string, patch func(source *core.Source) error) (core.Source, error) {
+	for attempt := 1; ; attempt++ {
+		source, err := repo.GetSourceByID(ctx, id)
+		if err != nil {
+			return core.Source{}, err
+		}
+
+		if err := patch(&source); err != nil {
+			return core.Source{}, err
+		}
+
+		err = repo.UpdateSource(ctx, source)
+		var conflictErr *core.VersionConflictError
+		if errors.As(err, &conflictErr) && attempt < patchAttempts {
+			continue
+		}
+		if err != nil {
+			return core.Source{}, err
+		}
+
+		source.Version++
+		return source, nil
+	}
+}
@@ -0,0 +1,27 @@
+package mongo
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// deletedAtField marks documents moved to the trash. Trashed documents are left out of all
+// queries except the ones for the trash itself.
+const deletedAtField = "deletedAt"
+
+func notDeleted() bson.M {
+	return bson.M{"$exists": false}
+}
+
+func deleted() bson.M {
+	return bson.M{"$exists": true}
+}
+
+func moveToTrash() bson.M {
+	return bson.M{"$set": bson.M{deletedAtField: time.Now().UTC().Truncate(time.Millisecond)}}
+}
+
+func restoreFromTrash() bson.M {
+	return bson.M{"$unset": bson.M{deletedAtField: ""}}
+}
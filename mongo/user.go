@@ -0,0 +1,141 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/phlashdev/recipe-keeper-api/core"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoUserRepository struct {
+	usersCollection *mongo.Collection
+}
+
+func NewMongoUserRepository(usersCollection *mongo.Collection) *MongoUserRepository {
+	return &MongoUserRepository{
+		usersCollection: usersCollection,
+	}
+}
+
+// EnsureIndexes creates the unique indexes on the username and on the account at an OpenID
+// Connect provider, the latter only for users having one.
+func (repo *MongoUserRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := repo.usersCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "username", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "issuer", Value: 1}, {Key: "subject", Value: 1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{
+				"subject": bson.M{"$exists": true},
+			}),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error while creating index: %v", err)
+	}
+
+	return nil
+}
+
+func (repo *MongoUserRepository) GetUsers(ctx context.Context) ([]core.User, error) {
+	var users []core.User
+	opts := options.Find().SetSort(bson.D{{Key: "username", Value: 1}})
+	cursor, err := repo.usersCollection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return []core.User{}, fmt.Errorf("error while executing query: %v", err)
+	}
+
+	if err = cursor.All(ctx, &users); err != nil {
+		return []core.User{}, fmt.Errorf("error while iterating cursor: %v", err)
+	}
+
+	// cursor.All returns nil if collection is empty
+	if users == nil {
+		return []core.User{}, nil
+	}
+
+	return users, nil
+}
+
+func (repo *MongoUserRepository) GetUserByID(ctx context.Context, id string) (core.User, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return core.User{}, &core.UserIDNotValidError{
+			ID: id,
+		}
+	}
+
+	return repo.findUser(ctx, bson.M{"_id": objectID}, id)
+}
+
+func (repo *MongoUserRepository) GetUserByUsername(ctx context.Context, username string) (core.User, error) {
+	return repo.findUser(ctx, bson.M{"username": username}, username)
+}
+
+func (repo *MongoUserRepository) GetUserBySubject(ctx context.Context, issuer string, subject string) (core.User, error) {
+	return repo.findUser(ctx, bson.M{"issuer": issuer, "subject": subject}, subject)
+}
+
+func (repo *MongoUserRepository) findUser(ctx context.Context, filter bson.M, id string) (core.User, error) {
+	var user core.User
+	if err := repo.usersCollection.FindOne(ctx, filter).Decode(&user); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return core.User{}, &core.UserNotFoundError{
+				ID: id,
+			}
+		}
+		return core.User{}, fmt.Errorf("error while executing query: %v", err)
+	}
+
+	return user, nil
+}
+
+func (repo *MongoUserRepository) AddUser(ctx context.Context, user *core.User) error {
+	if err := core.ValidateUser(*user); err != nil {
+		return err
+	}
+
+	user.ID = primitive.NewObjectID()
+
+	_, err := repo.usersCollection.InsertOne(ctx, user)
+	if mongo.IsDuplicateKeyError(err) {
+		return &core.UsernameExistsError{
+			Username: user.Username,
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("error while executing insert: %v", err)
+	}
+
+	return nil
+}
+
+func (repo *MongoUserRepository) UpdateUser(ctx context.Context, user core.User) error {
+	if err := core.ValidateUser(user); err != nil {
+		return err
+	}
+
+	result, err := repo.usersCollection.ReplaceOne(ctx, bson.M{"_id": user.ID}, user)
+	if mongo.IsDuplicateKeyError(err) {
+		return &core.UsernameExistsError{
+			Username: user.Username,
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("error while executing update: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return &core.UserNotFoundError{
+			ID: user.ID.Hex(),
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const versionField = "version"
+
+// patchAttempts limits how often a patch is retried on concurrent writes.
+const patchAttempts = 3
+
+// versionFilter matches the given version. Documents written before versions were introduced
+// have no version field and count as version 0.
+func versionFilter(version int) interface{} {
+	if version == 0 {
+		return bson.M{"$in": bson.A{0, nil}}
+	}
+
+	return version
+}
+
+// importVersions returns the versions of imported documents. A document replacing a stored one
+// gets a version above the stored one, so no version is issued twice for different contents even
+// if an older backup is restored.
+func importVersions(ctx context.Context, collection *mongo.Collection, ids []primitive.ObjectID, versions []int) ([]int, error) {
+	opts := options.Find().SetProjection(bson.M{versionField: 1})
+	cursor, err := collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error while executing query: %v", err)
+	}
+
+	var stored []struct {
+		ID      primitive.ObjectID `bson:"_id"`
+		Version int                `bson:"version"`
+	}
+	if err := cursor.All(ctx, &stored); err != nil {
+		return nil, fmt.Errorf("error while iterating cursor: %v", err)
+	}
+
+	storedVersions := make(map[primitive.ObjectID]int, len(stored))
+	for _, document := range stored {
+		storedVersions[document.ID] = document.Version
+	}
+
+	imported := make([]int, len(ids))
+	for i, id := range ids {
+		imported[i] = versions[i]
+		if version, ok := storedVersions[id]; ok && version >= imported[i] {
+			imported[i] = version + 1
+		}
+	}
+
+	return imported, nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/phlashdev/recipe-keeper-api/auth"
+	"github.com/phlashdev/recipe-keeper-api/core"
+)
+
+const (
+	OIDCIssuerEnv       = "RECIPEKEEPER_OIDC_ISSUER"
+	OIDCClientIDEnv     = "RECIPEKEEPER_OIDC_CLIENT_ID"
+	OIDCClientSecretEnv = "RECIPEKEEPER_OIDC_CLIENT_SECRET"
+	OIDCRedirectURLEnv  = "RECIPEKEEPER_OIDC_REDIRECT_URL"
+	OIDCScopesEnv       = "RECIPEKEEPER_OIDC_SCOPES"
+	OIDCNameEnv         = "RECIPEKEEPER_OIDC_NAME"
+	OIDCRoleClaimEnv    = "RECIPEKEEPER_OIDC_ROLE_CLAIM"
+	OIDCRolesEnv        = "RECIPEKEEPER_OIDC_ROLES"
+	OIDCDefaultRoleEnv  = "RECIPEKEEPER_OIDC_DEFAULT_ROLE"
+)
+
+const defaultOIDCName = "single sign-on"
+
+// oidcProvider reads the OpenID Connect settings, it returns nil if no issuer is set. The roles
+// map values of the role claim to roles like "kitchen-admins=admin,cooks=editor". Without a
+// default role users need a mapped value.
+func oidcProvider() (*auth.OIDCProvider, error) {
+	issuer := strings.TrimSpace(os.Getenv(OIDCIssuerEnv))
+	if len(issuer) == 0 {
+		return nil, nil
+	}
+
+	config := auth.OIDCConfig{
+		Name:         strings.TrimSpace(os.Getenv(OIDCNameEnv)),
+		Issuer:       issuer,
+		ClientID:     strings.TrimSpace(os.Getenv(OIDCClientIDEnv)),
+		ClientSecret: os.Getenv(OIDCClientSecretEnv),
+		RedirectURL:  strings.TrimSpace(os.Getenv(OIDCRedirectURLEnv)),
+		Scopes:       strings.Fields(os.Getenv(OIDCScopesEnv)),
+		RoleClaim:    strings.TrimSpace(os.Getenv(OIDCRoleClaimEnv)),
+		Roles:        map[string]string{},
+		DefaultRole:  strings.TrimSpace(os.Getenv(OIDCDefaultRoleEnv)),
+	}
+	if len(config.Name) == 0 {
+		config.Name = defaultOIDCName
+	}
+	if len(config.ClientID) == 0 || len(config.RedirectURL) == 0 {
+		return nil, fmt.Errorf("environment variables %q and %q are required with %q", OIDCClientIDEnv, OIDCRedirectURLEnv, OIDCIssuerEnv)
+	}
+	if len(config.DefaultRole) > 0 && !core.IsRoleValid(config.DefaultRole) {
+		return nil, fmt.Errorf("default role %q not valid", config.DefaultRole)
+	}
+
+	for _, mapping := range strings.Split(os.Getenv(OIDCRolesEnv), ",") {
+		if len(strings.TrimSpace(mapping)) == 0 {
+			continue
+		}
+		parts := strings.SplitN(mapping, "=", 2)
+		if len(parts) != 2 || !core.IsRoleValid(strings.TrimSpace(parts[1])) {
+			return nil, fmt.Errorf("role mapping %q not valid, use <claim value>=<role>", mapping)
+		}
+		config.Roles[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+
+	return auth.NewOIDCProvider(config, &http.Client{Timeout: 10 * time.Second}), nil
+}
@@ -0,0 +1,389 @@
+// Package patch applies JSON Merge Patch (RFC 7396) and JSON Patch (RFC 6902) documents to
+// JSON values.
+package patch
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	MediaTypeMergePatch = "application/merge-patch+json"
+	MediaTypeJSONPatch  = "application/json-patch+json"
+)
+
+// Error reports a patch that cannot be applied to the document, e.g. because a path does not exist.
+type Error struct {
+	Index   int
+	Message string
+}
+
+func (err *Error) Error() string {
+	return fmt.Sprintf("patch operation %d: %s", err.Index, err.Message)
+}
+
+// TestFailedError reports a JSON Patch test operation whose value did not match.
+type TestFailedError struct {
+	Index int
+	Path  string
+}
+
+func (err *TestFailedError) Error() string {
+	return fmt.Sprintf("patch operation %d: test of '%s' failed", err.Index, err.Path)
+}
+
+// SyntaxError reports a patch document that is not valid JSON or not of the expected shape.
+type SyntaxError struct {
+	Message string
+}
+
+func (err *SyntaxError) Error() string {
+	return fmt.Sprintf("patch not valid: %s", err.Message)
+}
+
+type operation struct {
+	Op   string
+	Path *string
+	From *string
+	// Value is nil if the member is missing, an explicit null is kept as the raw null
+	Value json.RawMessage
+}
+
+// UnmarshalJSON decodes the members one by one, the decoder would turn a null value into a
+// missing one.
+func (op *operation) UnmarshalJSON(data []byte) error {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+
+	if raw, ok := members["op"]; ok {
+		if err := json.Unmarshal(raw, &op.Op); err != nil {
+			return err
+		}
+	}
+	if raw, ok := members["path"]; ok {
+		if err := json.Unmarshal(raw, &op.Path); err != nil {
+			return err
+		}
+	}
+	if raw, ok := members["from"]; ok {
+		if err := json.Unmarshal(raw, &op.From); err != nil {
+			return err
+		}
+	}
+	op.Value = members["value"]
+
+	return nil
+}
+
+// Apply applies a patch of the given media type to the document.
+func Apply(mediaType string, document []byte, patch []byte) ([]byte, error) {
+	switch mediaType {
+	case MediaTypeMergePatch:
+		return MergePatch(document, patch)
+	case MediaTypeJSONPatch:
+		return JSONPatch(document, patch)
+	default:
+		return nil, fmt.Errorf("patch media type '%s' not supported", mediaType)
+	}
+}
+
+// MergePatch applies a JSON Merge Patch: objects are merged recursively, null removes a member
+// and every other value replaces the target.
+func MergePatch(document []byte, patch []byte) ([]byte, error) {
+	var target interface{}
+	if err := json.Unmarshal(document, &target); err != nil {
+		return nil, fmt.Errorf("error while decoding document: %v", err)
+	}
+
+	var mergePatch interface{}
+	if err := json.Unmarshal(patch, &mergePatch); err != nil {
+		return nil, &SyntaxError{Message: err.Error()}
+	}
+
+	return json.Marshal(merge(target, mergePatch))
+}
+
+func merge(target interface{}, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = map[string]interface{}{}
+	}
+	for name, value := range patchObject {
+		if value == nil {
+			delete(targetObject, name)
+		} else {
+			targetObject[name] = merge(targetObject[name], value)
+		}
+	}
+
+	return targetObject
+}
+
+// JSONPatch applies the operations of a JSON Patch in order. If one of them fails, none is applied.
+func JSONPatch(document []byte, patch []byte) ([]byte, error) {
+	var target interface{}
+	if err := json.Unmarshal(document, &target); err != nil {
+		return nil, fmt.Errorf("error while decoding document: %v", err)
+	}
+
+	var operations []operation
+	if err := json.Unmarshal(patch, &operations); err != nil {
+		return nil, &SyntaxError{Message: err.Error()}
+	}
+
+	for i, op := range operations {
+		if op.Path == nil {
+			return nil, &SyntaxError{Message: fmt.Sprintf("operation %d has no path", i)}
+		}
+		path, err := parsePointer(*op.Path)
+		if err != nil {
+			return nil, &SyntaxError{Message: fmt.Sprintf("operation %d: %v", i, err)}
+		}
+
+		var value interface{}
+		if op.Op == "add" || op.Op == "replace" || op.Op == "test" {
+			if op.Value == nil {
+				return nil, &SyntaxError{Message: fmt.Sprintf("operation %d has no value", i)}
+			}
+			if err := json.Unmarshal(op.Value, &value); err != nil {
+				return nil, &SyntaxError{Message: fmt.Sprintf("operation %d: %v", i, err)}
+			}
+		}
+
+		var from []string
+		if op.Op == "move" || op.Op == "copy" {
+			if op.From == nil {
+				return nil, &SyntaxError{Message: fmt.Sprintf("operation %d has no from", i)}
+			}
+			from, err = parsePointer(*op.From)
+			if err != nil {
+				return nil, &SyntaxError{Message: fmt.Sprintf("operation %d: %v", i, err)}
+			}
+		}
+
+		switch op.Op {
+		case "add":
+			target, err = add(target, path, value)
+		case "remove":
+			target, err = remove(target, path)
+		case "replace":
+			target, err = replace(target, path, value)
+		case "move":
+			if *op.Path != *op.From && strings.HasPrefix(*op.Path, *op.From+"/") {
+				err = fmt.Errorf("cannot move '%s' into one of its children", *op.From)
+				break
+			}
+			value, err = get(target, from)
+			if err == nil {
+				target, err = remove(target, from)
+			}
+			if err == nil {
+				target, err = add(target, path, value)
+			}
+		case "copy":
+			value, err = get(target, from)
+			if err == nil {
+				target, err = add(target, path, deepCopy(value))
+			}
+		case "test":
+			var actual interface{}
+			actual, err = get(target, path)
+			if err == nil && !reflect.DeepEqual(actual, value) {
+				return nil, &TestFailedError{Index: i, Path: *op.Path}
+			}
+		default:
+			return nil, &SyntaxError{Message: fmt.Sprintf("operation %d: op '%s' not valid", i, op.Op)}
+		}
+		if err != nil {
+			return nil, &Error{Index: i, Message: err.Error()}
+		}
+	}
+
+	return json.Marshal(target)
+}
+
+// parsePointer splits a JSON Pointer (RFC 6901) into its unescaped reference tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if len(pointer) == 0 {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("path '%s' must start with '/'", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+
+	return tokens, nil
+}
+
+func get(node interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch container := node.(type) {
+		case map[string]interface{}:
+			value, ok := container[token]
+			if !ok {
+				return nil, fmt.Errorf("member '%s' not found", token)
+			}
+			node = value
+		case []interface{}:
+			index, err := arrayIndex(token, len(container)-1)
+			if err != nil {
+				return nil, err
+			}
+			node = container[index]
+		default:
+			return nil, fmt.Errorf("cannot reference '%s' in a scalar value", token)
+		}
+	}
+
+	return node, nil
+}
+
+// modify applies change to the container holding the last token of the path and returns the node
+// with the changed container, arrays change their identity when growing or shrinking.
+func modify(node interface{}, path []string, change func(container interface{}, token string) (interface{}, error)) (interface{}, error) {
+	if len(path) == 1 {
+		return change(node, path[0])
+	}
+
+	child, err := get(node, path[:1])
+	if err != nil {
+		return nil, err
+	}
+	child, err = modify(child, path[1:], change)
+	if err != nil {
+		return nil, err
+	}
+
+	switch container := node.(type) {
+	case map[string]interface{}:
+		container[path[0]] = child
+	case []interface{}:
+		index, _ := arrayIndex(path[0], len(container)-1)
+		container[index] = child
+	}
+
+	return node, nil
+}
+
+func add(node interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	return modify(node, path, func(container interface{}, token string) (interface{}, error) {
+		switch c := container.(type) {
+		case map[string]interface{}:
+			c[token] = value
+			return c, nil
+		case []interface{}:
+			if token == "-" {
+				return append(c, value), nil
+			}
+			index, err := arrayIndex(token, len(c))
+			if err != nil {
+				return nil, err
+			}
+			c = append(c, nil)
+			copy(c[index+1:], c[index:])
+			c[index] = value
+			return c, nil
+		default:
+			return nil, fmt.Errorf("cannot add '%s' to a scalar value", token)
+		}
+	})
+}
+
+func remove(node interface{}, path []string) (interface{}, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("cannot remove the whole document")
+	}
+
+	return modify(node, path, func(container interface{}, token string) (interface{}, error) {
+		switch c := container.(type) {
+		case map[string]interface{}:
+			if _, ok := c[token]; !ok {
+				return nil, fmt.Errorf("member '%s' not found", token)
+			}
+			delete(c, token)
+			return c, nil
+		case []interface{}:
+			index, err := arrayIndex(token, len(c)-1)
+			if err != nil {
+				return nil, err
+			}
+			return append(c[:index], c[index+1:]...), nil
+		default:
+			return nil, fmt.Errorf("cannot remove '%s' from a scalar value", token)
+		}
+	})
+}
+
+func replace(node interface{}, path []string, value interface{}) (interface{}, error) {
+	if _, err := get(node, path); err != nil {
+		return nil, err
+	}
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	return modify(node, path, func(container interface{}, token string) (interface{}, error) {
+		switch c := container.(type) {
+		case map[string]interface{}:
+			c[token] = value
+			return c, nil
+		case []interface{}:
+			index, _ := arrayIndex(token, len(c)-1)
+			c[index] = value
+			return c, nil
+		default:
+			return nil, fmt.Errorf("cannot replace '%s' in a scalar value", token)
+		}
+	})
+}
+
+func arrayIndex(token string, max int) (int, error) {
+	// leading zeros are not allowed by RFC 6901
+	if len(token) > 1 && strings.HasPrefix(token, "0") {
+		return 0, fmt.Errorf("array index '%s' not valid", token)
+	}
+
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || index > max {
+		return 0, fmt.Errorf("array index '%s' out of range", token)
+	}
+
+	return index, nil
+}
+
+func deepCopy(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for name, member := range v {
+			copied[name] = deepCopy(member)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, element := range v {
+			copied[i] = deepCopy(element)
+		}
+		return copied
+	default:
+		return v
+	}
+}
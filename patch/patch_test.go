@@ -0,0 +1,173 @@
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		patch    string
+		want     string
+	}{
+		{"replace member", `{"title":"Bread","servings":2}`, `{"servings":4}`, `{"title":"Bread","servings":4}`},
+		{"add member", `{"title":"Bread"}`, `{"category":"Baking"}`, `{"title":"Bread","category":"Baking"}`},
+		{"null removes member", `{"title":"Bread","notes":"old"}`, `{"notes":null}`, `{"title":"Bread"}`},
+		{"null for missing member", `{"title":"Bread"}`, `{"notes":null}`, `{"title":"Bread"}`},
+		{"nested objects merge", `{"a":{"b":1,"c":2}}`, `{"a":{"c":3,"d":4}}`, `{"a":{"b":1,"c":3,"d":4}}`},
+		{"arrays are replaced", `{"tags":["a","b"]}`, `{"tags":["c"]}`, `{"tags":["c"]}`},
+		{"object replaces scalar", `{"a":1}`, `{"a":{"b":null,"c":2}}`, `{"a":{"c":2}}`},
+		{"non-object patch replaces document", `{"a":1}`, `["x"]`, `["x"]`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := MergePatch([]byte(test.document), []byte(test.patch))
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertJSON(t, result, test.want)
+		})
+	}
+}
+
+func TestMergePatchSyntaxError(t *testing.T) {
+	_, err := MergePatch([]byte(`{}`), []byte(`{"title":`))
+
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Errorf("err = %v, want a SyntaxError", err)
+	}
+}
+
+func TestJSONPatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		patch    string
+		want     string
+	}{
+		{"add member", `{"a":1}`, `[{"op":"add","path":"/b","value":2}]`, `{"a":1,"b":2}`},
+		{"add replaces member", `{"a":1}`, `[{"op":"add","path":"/a","value":2}]`, `{"a":2}`},
+		{"add into array", `{"a":[1,3]}`, `[{"op":"add","path":"/a/1","value":2}]`, `{"a":[1,2,3]}`},
+		{"add to end of array", `{"a":[1,2]}`, `[{"op":"add","path":"/a/-","value":3}]`, `{"a":[1,2,3]}`},
+		{"add to nested array", `{"a":[{"b":[]}]}`, `[{"op":"add","path":"/a/0/b/-","value":"x"}]`, `{"a":[{"b":["x"]}]}`},
+		{"add null", `{"a":1}`, `[{"op":"add","path":"/notes","value":null}]`, `{"a":1,"notes":null}`},
+		{"add whole document", `{"a":1}`, `[{"op":"add","path":"","value":[1]}]`, `[1]`},
+		{"remove member", `{"a":1,"b":2}`, `[{"op":"remove","path":"/b"}]`, `{"a":1}`},
+		{"remove from array", `{"a":[1,2,3]}`, `[{"op":"remove","path":"/a/1"}]`, `{"a":[1,3]}`},
+		{"replace member", `{"a":1}`, `[{"op":"replace","path":"/a","value":"x"}]`, `{"a":"x"}`},
+		{"replace with null", `{"notes":"old"}`, `[{"op":"replace","path":"/notes","value":null}]`, `{"notes":null}`},
+		{"replace in array", `{"a":[1,2]}`, `[{"op":"replace","path":"/a/0","value":0}]`, `{"a":[0,2]}`},
+		{"move member", `{"a":{"b":1},"c":{}}`, `[{"op":"move","from":"/a/b","path":"/c/d"}]`, `{"a":{},"c":{"d":1}}`},
+		{"move within array", `{"a":[1,2,3]}`, `[{"op":"move","from":"/a/0","path":"/a/-"}]`, `{"a":[2,3,1]}`},
+		{"copy member", `{"a":{"b":[1]}}`, `[{"op":"copy","from":"/a","path":"/c"},{"op":"add","path":"/c/b/-","value":2}]`, `{"a":{"b":[1]},"c":{"b":[1,2]}}`},
+		{"test passes", `{"a":{"b":[1,"x"]}}`, `[{"op":"test","path":"/a","value":{"b":[1,"x"]}}]`, `{"a":{"b":[1,"x"]}}`},
+		{"test null", `{"notes":null}`, `[{"op":"test","path":"/notes","value":null}]`, `{"notes":null}`},
+		{"escaped slash", `{"a/b":1}`, `[{"op":"replace","path":"/a~1b","value":2}]`, `{"a/b":2}`},
+		{"escaped tilde", `{"a~b":1}`, `[{"op":"remove","path":"/a~0b"}]`, `{}`},
+		{"tilde escape decoded once", `{"~1":1}`, `[{"op":"replace","path":"/~01","value":2}]`, `{"~1":2}`},
+		{"operations in order", `{"a":1}`, `[{"op":"add","path":"/b","value":1},{"op":"remove","path":"/a"},{"op":"test","path":"/b","value":1}]`, `{"b":1}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := JSONPatch([]byte(test.document), []byte(test.patch))
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertJSON(t, result, test.want)
+		})
+	}
+}
+
+func TestJSONPatchErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		patch    string
+		want     interface{}
+	}{
+		{"test fails", `{"a":1}`, `[{"op":"test","path":"/a","value":2}]`, &TestFailedError{}},
+		{"test of null fails for missing member", `{}`, `[{"op":"test","path":"/a","value":null}]`, &Error{}},
+		{"remove missing member", `{"a":1}`, `[{"op":"remove","path":"/b"}]`, &Error{}},
+		{"replace missing member", `{"a":1}`, `[{"op":"replace","path":"/b","value":1}]`, &Error{}},
+		{"add past end of array", `{"a":[1]}`, `[{"op":"add","path":"/a/2","value":1}]`, &Error{}},
+		{"array index with leading zero", `{"a":[1,2]}`, `[{"op":"replace","path":"/a/01","value":1}]`, &Error{}},
+		{"array end in replace", `{"a":[1]}`, `[{"op":"replace","path":"/a/-","value":1}]`, &Error{}},
+		{"add to missing parent", `{}`, `[{"op":"add","path":"/a/b","value":1}]`, &Error{}},
+		{"move into own child", `{"a":{}}`, `[{"op":"move","from":"/a","path":"/a/b"}]`, &Error{}},
+		{"value missing", `{}`, `[{"op":"add","path":"/a"}]`, &SyntaxError{}},
+		{"path missing", `{}`, `[{"op":"add","value":1}]`, &SyntaxError{}},
+		{"from missing", `{"a":1}`, `[{"op":"copy","path":"/b"}]`, &SyntaxError{}},
+		{"path without slash", `{}`, `[{"op":"add","path":"a","value":1}]`, &SyntaxError{}},
+		{"unknown op", `{}`, `[{"op":"merge","path":"/a","value":1}]`, &SyntaxError{}},
+		{"not a list", `{}`, `{"op":"add","path":"/a","value":1}`, &SyntaxError{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := JSONPatch([]byte(test.document), []byte(test.patch))
+
+			if result != nil || err == nil || reflect.TypeOf(err) != reflect.TypeOf(test.want) {
+				t.Errorf("JSONPatch = %s, %v, want a %T", result, err, test.want)
+			}
+		})
+	}
+}
+
+func TestJSONPatchFailedTestLeavesDocumentUnchanged(t *testing.T) {
+	document := []byte(`{"title":"Bread","tags":["a"]}`)
+	patch := []byte(`[
+		{"op":"replace","path":"/title","value":"Cake"},
+		{"op":"add","path":"/tags/-","value":"b"},
+		{"op":"test","path":"/title","value":"Bread"}
+	]`)
+
+	result, err := JSONPatch(document, patch)
+
+	var testErr *TestFailedError
+	if !errors.As(err, &testErr) || testErr.Index != 2 || testErr.Path != "/title" {
+		t.Fatalf("err = %v, want the test of operation 2 to fail", err)
+	}
+	if result != nil {
+		t.Errorf("result = %s, want none", result)
+	}
+	assertJSON(t, document, `{"title":"Bread","tags":["a"]}`)
+}
+
+func TestApply(t *testing.T) {
+	for mediaType, patch := range map[string]string{
+		MediaTypeMergePatch: `{"notes":null}`,
+		MediaTypeJSONPatch:  `[{"op":"remove","path":"/notes"}]`,
+	} {
+		result, err := Apply(mediaType, []byte(`{"title":"Bread","notes":"x"}`), []byte(patch))
+		if err != nil {
+			t.Fatalf("%s: %v", mediaType, err)
+		}
+		assertJSON(t, result, `{"title":"Bread"}`)
+	}
+
+	if _, err := Apply("application/json", []byte(`{}`), []byte(`{}`)); err == nil {
+		t.Error("Apply accepted application/json")
+	}
+}
+
+func assertJSON(t *testing.T, actual []byte, want string) {
+	t.Helper()
+
+	var actualValue, wantValue interface{}
+	if err := json.Unmarshal(actual, &actualValue); err != nil {
+		t.Fatalf("result %s is not valid JSON: %v", actual, err)
+	}
+	if err := json.Unmarshal([]byte(want), &wantValue); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(actualValue, wantValue) {
+		t.Errorf("result = %s, want %s", actual, want)
+	}
+}
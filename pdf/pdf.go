@@ -0,0 +1,210 @@
+package pdf
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/phlashdev/recipe-keeper-api/core"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	fontFamily = "Helvetica"
+
+	lineHeight      = 6.0
+	tocLineHeight   = 7.0
+	tocLinesPerPage = 34
+)
+
+// document wraps fpdf with the translation of UTF-8 text to the encoding of the core fonts.
+type document struct {
+	pdf       *fpdf.Fpdf
+	translate func(string) string
+}
+
+func newDocument(title string) *document {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetTitle(title, true)
+	pdf.SetCreator("recipe-keeper", true)
+	pdf.SetMargins(20, 20, 20)
+	pdf.SetAutoPageBreak(true, 20)
+
+	doc := &document{
+		pdf:       pdf,
+		translate: pdf.UnicodeTranslatorFromDescriptor(""),
+	}
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-15)
+		pdf.SetFont(fontFamily, "", 9)
+		pdf.CellFormat(0, 10, strconv.Itoa(pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+
+	return doc
+}
+
+// WriteRecipe renders a single recipe.
+func WriteRecipe(w io.Writer, recipe core.Recipe, source *core.Source) error {
+	doc := newDocument(recipe.Title)
+	doc.pdf.AddPage()
+	doc.writeRecipe(recipe, source)
+
+	return doc.output(w)
+}
+
+// WriteCookbook renders the recipes with a title page and a table of contents, every recipe
+// starting on a new page.
+func WriteCookbook(w io.Writer, title string, recipes []core.Recipe, sources []core.Source) error {
+	sourcesByID := make(map[primitive.ObjectID]core.Source, len(sources))
+	for _, source := range sources {
+		sourcesByID[source.ID] = source
+	}
+
+	// render the recipes once to learn on which page each of them starts
+	startPages := make([]int, 0, len(recipes))
+	draft := newDocument(title)
+	for _, recipe := range recipes {
+		draft.pdf.AddPage()
+		startPages = append(startPages, draft.pdf.PageNo())
+		draft.writeRecipe(recipe, lookupSource(sourcesByID, recipe))
+	}
+	if err := draft.pdf.Error(); err != nil {
+		return fmt.Errorf("error while rendering pdf: %v", err)
+	}
+
+	tocPages := (len(recipes) + tocLinesPerPage - 1) / tocLinesPerPage
+	pageOffset := 1 + tocPages
+
+	doc := newDocument(title)
+	doc.writeTitlePage(title, len(recipes))
+
+	links := make([]int, 0, len(recipes))
+	for i, recipe := range recipes {
+		if i%tocLinesPerPage == 0 {
+			doc.pdf.AddPage()
+			doc.heading(1, "Contents")
+		}
+
+		link := doc.pdf.AddLink()
+		links = append(links, link)
+
+		page := strconv.Itoa(startPages[i] + pageOffset)
+		doc.pdf.SetFont(fontFamily, "", 11)
+		pageWidth := doc.pdf.GetStringWidth(page) + 2
+		width, _ := doc.pdf.GetPageSize()
+		left, _, right, _ := doc.pdf.GetMargins()
+		doc.pdf.CellFormat(width-left-right-pageWidth, tocLineHeight, doc.translate(recipe.Title), "", 0, "L", false, link, "")
+		doc.pdf.CellFormat(pageWidth, tocLineHeight, page, "", 1, "R", false, link, "")
+	}
+
+	for i, recipe := range recipes {
+		doc.pdf.AddPage()
+		doc.pdf.SetLink(links[i], 0, -1)
+		doc.writeRecipe(recipe, lookupSource(sourcesByID, recipe))
+	}
+
+	return doc.output(w)
+}
+
+func lookupSource(sourcesByID map[primitive.ObjectID]core.Source, recipe core.Recipe) *core.Source {
+	source, ok := sourcesByID[recipe.Source]
+	if !ok || recipe.Source.IsZero() {
+		return nil
+	}
+
+	return &source
+}
+
+func (doc *document) output(w io.Writer) error {
+	if err := doc.pdf.Output(w); err != nil {
+		return fmt.Errorf("error while rendering pdf: %v", err)
+	}
+
+	return nil
+}
+
+func (doc *document) writeTitlePage(title string, recipeCount int) {
+	doc.pdf.AddPage()
+	doc.pdf.SetY(100)
+	doc.pdf.SetFont(fontFamily, "B", 28)
+	doc.pdf.MultiCell(0, 12, doc.translate(title), "", "C", false)
+	doc.pdf.Ln(4)
+	doc.pdf.SetFont(fontFamily, "", 12)
+	doc.pdf.CellFormat(0, lineHeight, fmt.Sprintf("%d recipes", recipeCount), "", 1, "C", false, 0, "")
+}
+
+func (doc *document) heading(level int, text string) {
+	size := 18.0
+	if level > 1 {
+		size = 13
+		doc.pdf.Ln(3)
+	}
+
+	doc.pdf.SetFont(fontFamily, "B", size)
+	doc.pdf.MultiCell(0, size*0.5, doc.translate(text), "", "L", false)
+	doc.pdf.Ln(2)
+}
+
+func (doc *document) paragraph(style string, text string) {
+	doc.pdf.SetFont(fontFamily, style, 11)
+	doc.pdf.MultiCell(0, lineHeight, doc.translate(text), "", "L", false)
+}
+
+func (doc *document) writeRecipe(recipe core.Recipe, source *core.Source) {
+	doc.heading(1, recipe.Title)
+
+	var details []string
+	if len(recipe.Category) > 0 {
+		details = append(details, recipe.Category)
+	}
+	if recipe.Servings > 0 {
+		details = append(details, fmt.Sprintf("%d servings", recipe.Servings))
+	}
+	if len(recipe.Tags) > 0 {
+		details = append(details, strings.Join(recipe.Tags, ", "))
+	}
+	if len(details) > 0 {
+		doc.paragraph("", strings.Join(details, " | "))
+	}
+	if len(recipe.Allergens) > 0 {
+		doc.paragraph("", "Allergens: "+strings.Join(recipe.Allergens, ", "))
+	}
+
+	if len(recipe.Ingredients) > 0 {
+		doc.heading(2, "Ingredients")
+		for _, ingredient := range recipe.Ingredients {
+			doc.paragraph("", "- "+ingredient.String())
+		}
+	}
+
+	if len(recipe.Steps) > 0 {
+		doc.heading(2, "Steps")
+		for i, step := range recipe.Steps {
+			doc.paragraph("", fmt.Sprintf("%d. %s", i+1, step.Text))
+			doc.pdf.Ln(1)
+		}
+	}
+
+	if citation := formatCitation(recipe, source); len(citation) > 0 {
+		doc.pdf.Ln(4)
+		doc.paragraph("I", "Source: "+citation)
+	}
+}
+
+func formatCitation(recipe core.Recipe, source *core.Source) string {
+	var parts []string
+	if source != nil && len(source.Title) > 0 {
+		parts = append(parts, source.Title)
+	}
+	if len(recipe.SourceAnnotation) > 0 {
+		if source != nil && source.Type == core.SourceTypeBook {
+			parts = append(parts, "p. "+recipe.SourceAnnotation)
+		} else {
+			parts = append(parts, recipe.SourceAnnotation)
+		}
+	}
+
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/phlashdev/recipe-keeper-api/core"
+)
+
+const (
+	defaultTrashRetention = 30 * 24 * time.Hour
+	trashPurgeInterval    = time.Hour
+)
+
+// trashRetention reads how long deleted items are kept. Besides Go durations like "72h" whole
+// days like "30d" are accepted, "0" keeps deleted items forever.
+func trashRetention(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if len(value) == 0 {
+		return defaultTrashRetention, nil
+	}
+
+	if strings.HasSuffix(value, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(value, "d"))
+		if err != nil || days < 0 {
+			return 0, fmt.Errorf("trash retention %q not valid", value)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+
+	retention, err := time.ParseDuration(value)
+	if err != nil || retention < 0 {
+		return 0, fmt.Errorf("trash retention %q not valid", value)
+	}
+
+	return retention, nil
+}
+
+// purgeTrashPeriodically permanently deletes recipes and sources that have been in the trash
+// for longer than the retention period. It runs until the process exits.
+func purgeTrashPeriodically(retention time.Duration, recipeRepository core.RecipeRepository, sourceRepository core.SourceRepository) {
+	for {
+		purgeTrash(retention, recipeRepository, sourceRepository)
+		time.Sleep(trashPurgeInterval)
+	}
+}
+
+func purgeTrash(retention time.Duration, recipeRepository core.RecipeRepository, sourceRepository core.SourceRepository) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	deletedBefore := time.Now().Add(-retention)
+
+	recipeCount, err := recipeRepository.PurgeDeletedRecipes(ctx, deletedBefore)
+	if err != nil {
+		log.Print(err)
+	}
+
+	sourceCount, err := sourceRepository.PurgeDeletedSources(ctx, deletedBefore)
+	if err != nil {
+		log.Print(err)
+	}
+
+	if recipeCount > 0 || sourceCount > 0 {
+		log.Printf("Purged %d recipes and %d sources from the trash", recipeCount, sourceCount)
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/phlashdev/recipe-keeper-api/auth"
+	"github.com/phlashdev/recipe-keeper-api/core"
+)
+
+const userUsage = `Usage:
+  recipe-keeper user list
+  recipe-keeper user add [-role editor] [-name <display name>] <username>
+  recipe-keeper user passwd <username>
+
+The password is read from the first line of the standard input.`
+
+func manageUsers(args []string, userRepository core.UserRepository, sessionRepository core.SessionRepository) error {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, userUsage)
+		return errors.New("missing user command")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	switch args[0] {
+	case "list":
+		return listUsers(ctx, userRepository)
+	case "add":
+		return addUser(ctx, args[1:], userRepository)
+	case "passwd":
+		return changePassword(ctx, args[1:], userRepository, sessionRepository)
+	default:
+		fmt.Fprintln(os.Stderr, userUsage)
+		return fmt.Errorf("unknown user command %q", args[0])
+	}
+}
+
+func listUsers(ctx context.Context, userRepository core.UserRepository) error {
+	users, err := userRepository.GetUsers(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		fmt.Printf("%s\t%s\t%s\n", user.Username, user.Role, user.DisplayName)
+	}
+	return nil
+}
+
+func addUser(ctx context.Context, args []string, userRepository core.UserRepository) error {
+	flags := flag.NewFlagSet("user add", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), userUsage)
+	}
+	role := flags.String("role", core.RoleEditor, "role of the user: viewer, editor or admin")
+	name := flags.String("name", "", "name shown for the user")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("missing username")
+	}
+
+	password, err := readPassword()
+	if err != nil {
+		return err
+	}
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	user := core.User{
+		Username:     flags.Arg(0),
+		DisplayName:  *name,
+		PasswordHash: hash,
+		Role:         *role,
+		CreatedAt:    time.Now().UTC().Truncate(time.Millisecond),
+	}
+	if err := userRepository.AddUser(ctx, &user); err != nil {
+		return err
+	}
+
+	log.Printf("added %s %s", user.Role, user.Username)
+	return nil
+}
+
+// changePassword also ends the sessions of the user, a changed password locks out everyone who
+// logged in with the old one.
+func changePassword(ctx context.Context, args []string, userRepository core.UserRepository, sessionRepository core.SessionRepository) error {
+	flags := flag.NewFlagSet("user passwd", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), userUsage)
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("missing username")
+	}
+
+	user, err := userRepository.GetUserByUsername(ctx, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	password, err := readPassword()
+	if err != nil {
+		return err
+	}
+	user.PasswordHash, err = auth.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	if err := userRepository.UpdateUser(ctx, user); err != nil {
+		return err
+	}
+	if err := sessionRepository.DeleteUserSessions(ctx, user.ID); err != nil {
+		return err
+	}
+
+	log.Printf("changed the password of %s", user.Username)
+	return nil
+}
+
+func readPassword() (string, error) {
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		fmt.Fprint(os.Stderr, "Password: ")
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
@@ -0,0 +1,176 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/phlashdev/recipe-keeper-api/cooking"
+	"github.com/phlashdev/recipe-keeper-api/core"
+)
+
+type cookStepTimer struct {
+	Index int
+	core.Timer
+}
+
+type cookTimer struct {
+	cooking.Timer
+	StepNumber int
+	Remaining  time.Duration
+	Finished   bool
+}
+
+type cookPage struct {
+	Recipe      core.Recipe
+	Number      int
+	StepCount   int
+	Segments    []cooking.Segment
+	Ingredients []core.Ingredient
+	StepTimers  []cookStepTimer
+	Timers      []cookTimer
+	PreviousURL string
+	NextURL     string
+}
+
+// CookHandler shows one step of a recipe with its ingredients and the timers of the recipe. The
+// step is selected by the query parameter step, numbered starting at 1.
+type CookHandler struct {
+	recipeRepository core.RecipeRepository
+	timers           *cooking.Timers
+}
+
+func NewCookHandler(recipeRepository core.RecipeRepository, timers *cooking.Timers) *CookHandler {
+	return &CookHandler{
+		recipeRepository: recipeRepository,
+		timers:           timers,
+	}
+}
+
+func (handler *CookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	vars := mux.Vars(r)
+	recipe, err := handler.recipeRepository.GetRecipeByID(ctx, vars["id"])
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	number, err := strconv.Atoi(r.URL.Query().Get("step"))
+	if err != nil {
+		number = 1
+	}
+
+	data := cookPage{
+		Recipe:    recipe,
+		Number:    number,
+		StepCount: len(recipe.Steps),
+	}
+	if len(recipe.Steps) > 0 {
+		step, err := cooking.Step(recipe, number)
+		if err != nil {
+			renderError(w, r, err)
+			return
+		}
+
+		data.Ingredients = cooking.StepIngredients(recipe, step)
+		data.Segments = cooking.Highlight(step.Text, data.Ingredients)
+		for i, timer := range step.Timers {
+			data.StepTimers = append(data.StepTimers, cookStepTimer{Index: i, Timer: timer})
+		}
+		if number > 1 {
+			data.PreviousURL = cookStepURL(recipe, number-1)
+		}
+		if number < len(recipe.Steps) {
+			data.NextURL = cookStepURL(recipe, number+1)
+		}
+	}
+
+	now := time.Now()
+	for _, timer := range handler.timers.List(recipe.ID.Hex()) {
+		remaining := timer.Remaining(now)
+		data.Timers = append(data.Timers, cookTimer{
+			Timer:      timer,
+			StepNumber: timer.StepIndex + 1,
+			Remaining:  remaining.Round(time.Second),
+			Finished:   remaining == 0,
+		})
+	}
+
+	render(w, r, "cook", http.StatusOK, data)
+}
+
+func cookStepURL(recipe core.Recipe, number int) string {
+	return fmt.Sprintf("/recipes/%s/cook?step=%d", recipe.ID.Hex(), number)
+}
+
+// StartCookTimerHandler starts the timer given by the form values step and timer, both indexes.
+type StartCookTimerHandler struct {
+	recipeRepository core.RecipeRepository
+	timers           *cooking.Timers
+}
+
+func NewStartCookTimerHandler(recipeRepository core.RecipeRepository, timers *cooking.Timers) *StartCookTimerHandler {
+	return &StartCookTimerHandler{
+		recipeRepository: recipeRepository,
+		timers:           timers,
+	}
+}
+
+func (handler *StartCookTimerHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	vars := mux.Vars(r)
+	recipe, err := handler.recipeRepository.GetRecipeByID(ctx, vars["id"])
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	stepIndex, _ := strconv.Atoi(r.PostFormValue("step"))
+	timerIndex, _ := strconv.Atoi(r.PostFormValue("timer"))
+	stepTimer, err := cooking.StepTimer(recipe, stepIndex, timerIndex)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	_, err = handler.timers.Start(recipe.ID.Hex(), stepIndex, stepTimer.Name, stepTimer.Duration)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	seeOther(w, r, cookStepURL(recipe, stepIndex+1))
+}
+
+// RemoveCookTimerHandler cancels or dismisses a timer and returns to the step given by the form value step.
+type RemoveCookTimerHandler struct {
+	timers *cooking.Timers
+}
+
+func NewRemoveCookTimerHandler(timers *cooking.Timers) *RemoveCookTimerHandler {
+	return &RemoveCookTimerHandler{
+		timers: timers,
+	}
+}
+
+func (handler *RemoveCookTimerHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	err := handler.timers.Remove(vars["id"], vars["timerId"])
+	if err != nil && !isNotFoundError(err) {
+		renderError(w, r, err)
+		return
+	}
+
+	// a timer removed on another device in the meantime is no error
+	number, _ := strconv.Atoi(r.PostFormValue("step"))
+	seeOther(w, r, fmt.Sprintf("/recipes/%s/cook?step=%d", url.PathEscape(vars["id"]), number))
+}
@@ -0,0 +1,119 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/phlashdev/recipe-keeper-api/auth"
+)
+
+type loginPage struct {
+	Username string
+	Next     string
+	Error    string
+	// Provider names the OpenID Connect provider, it is empty if there is none.
+	Provider string
+}
+
+func newLoginPage(provider *auth.OIDCProvider, next string) loginPage {
+	page := loginPage{Next: localPath(next)}
+	if provider != nil {
+		page.Provider = provider.Name()
+	}
+
+	return page
+}
+
+// LoginPageHandler shows the login form, the query parameter next is the page to return to. The
+// provider is nil if users sign in with passwords only.
+type LoginPageHandler struct {
+	provider *auth.OIDCProvider
+}
+
+func NewLoginPageHandler(provider *auth.OIDCProvider) *LoginPageHandler {
+	return &LoginPageHandler{
+		provider: provider,
+	}
+}
+
+func (handler *LoginPageHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	render(w, r, "login", http.StatusOK, newLoginPage(handler.provider, r.URL.Query().Get("next")))
+}
+
+// LoginHandler checks the posted username and password and stores the session token in a cookie.
+type LoginHandler struct {
+	authenticator *auth.Authenticator
+	provider      *auth.OIDCProvider
+}
+
+func NewLoginHandler(authenticator *auth.Authenticator, provider *auth.OIDCProvider) *LoginHandler {
+	return &LoginHandler{
+		authenticator: authenticator,
+		provider:      provider,
+	}
+}
+
+func (handler *LoginHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page := newLoginPage(handler.provider, r.PostFormValue("next"))
+	page.Username = strings.TrimSpace(r.PostFormValue("username"))
+	token, session, _, err := handler.authenticator.Login(ctx, page.Username, r.PostFormValue("password"))
+	var invalidCredentialsErr *auth.InvalidCredentialsError
+	if errors.As(err, &invalidCredentialsErr) {
+		page.Error = "The username or password is wrong."
+		render(w, r, "login", http.StatusUnauthorized, page)
+		return
+	}
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	auth.SetSessionCookie(w, r, token, session.ExpiresAt)
+	seeOther(w, r, page.Next)
+}
+
+// LogoutHandler ends the session of the cookie and returns to the login page.
+type LogoutHandler struct {
+	authenticator *auth.Authenticator
+}
+
+func NewLogoutHandler(authenticator *auth.Authenticator) *LogoutHandler {
+	return &LogoutHandler{
+		authenticator: authenticator,
+	}
+}
+
+func (handler *LogoutHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err := handler.authenticator.Logout(ctx, auth.RequestToken(r))
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	auth.ClearSessionCookie(w, r)
+	seeOther(w, r, "/login")
+}
+
+// localPath returns the path if it stays on this site, otherwise the recipe list. It keeps the
+// login from redirecting to another site.
+func localPath(path string) string {
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") || strings.HasPrefix(path, "/\\") {
+		return "/recipes"
+	}
+
+	return path
+}
@@ -0,0 +1,125 @@
+package web
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/phlashdev/recipe-keeper-api/auth"
+)
+
+// OIDCLoginHandler sends the user to the OpenID Connect provider to sign in, the query parameter
+// next is the page to return to.
+type OIDCLoginHandler struct {
+	provider *auth.OIDCProvider
+}
+
+func NewOIDCLoginHandler(provider *auth.OIDCProvider) *OIDCLoginHandler {
+	return &OIDCLoginHandler{
+		provider: provider,
+	}
+}
+
+func (handler *OIDCLoginHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	state, err := auth.NewOIDCState(localPath(r.URL.Query().Get("next")))
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	authURL, err := handler.provider.AuthCodeURL(ctx, state)
+	if err != nil {
+		renderLoginFailed(w, r, handler.provider, state.Next, err)
+		return
+	}
+
+	if err := auth.SetOIDCStateCookie(w, r, state); err != nil {
+		renderError(w, r, err)
+		return
+	}
+	seeOther(w, r, authURL)
+}
+
+// OIDCCallbackHandler completes the login when the provider redirects back with a code. The user
+// is added on the first login.
+type OIDCCallbackHandler struct {
+	provider      *auth.OIDCProvider
+	authenticator *auth.Authenticator
+}
+
+func NewOIDCCallbackHandler(provider *auth.OIDCProvider, authenticator *auth.Authenticator) *OIDCCallbackHandler {
+	return &OIDCCallbackHandler{
+		provider:      provider,
+		authenticator: authenticator,
+	}
+}
+
+func (handler *OIDCCallbackHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// the state is used once, a failed login starts over
+	state, ok := auth.OIDCStateFromCookie(r)
+	auth.ClearOIDCStateCookie(w, r)
+
+	query := r.URL.Query()
+	if !ok || subtle.ConstantTimeCompare([]byte(query.Get("state")), []byte(state.State)) != 1 {
+		page := newLoginPage(handler.provider, state.Next)
+		page.Error = "The login expired, please try again."
+		render(w, r, "login", http.StatusBadRequest, page)
+		return
+	}
+	if providerErr := query.Get("error"); len(providerErr) > 0 {
+		renderLoginFailed(w, r, handler.provider, state.Next, &auth.IdentityProviderError{
+			Message: providerErr + " " + query.Get("error_description"),
+		})
+		return
+	}
+
+	claims, err := handler.provider.Exchange(ctx, query.Get("code"), state)
+	if err != nil {
+		renderLoginFailed(w, r, handler.provider, state.Next, err)
+		return
+	}
+
+	identity, err := handler.provider.Identity(claims)
+	if err != nil {
+		renderLoginFailed(w, r, handler.provider, state.Next, err)
+		return
+	}
+
+	token, session, _, err := handler.authenticator.LoginWithIdentity(ctx, identity)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	auth.SetSessionCookie(w, r, token, session.ExpiresAt)
+	seeOther(w, r, state.Next)
+}
+
+// renderLoginFailed shows the login page again. The details are only logged, they may tell more
+// about the provider than users should see.
+func renderLoginFailed(w http.ResponseWriter, r *http.Request, provider *auth.OIDCProvider, next string, err error) {
+	var providerErr *auth.IdentityProviderError
+	var noRoleErr *auth.NoRoleError
+	page := newLoginPage(provider, next)
+	switch {
+	case errors.As(err, &noRoleErr):
+		log.Print(err)
+		page.Error = "Your account has no access to Recipe Keeper."
+		render(w, r, "login", http.StatusForbidden, page)
+	case errors.As(err, &providerErr):
+		log.Print(err)
+		page.Error = "The login with " + provider.Name() + " failed, please try again."
+		render(w, r, "login", http.StatusUnauthorized, page)
+	default:
+		renderError(w, r, err)
+	}
+}
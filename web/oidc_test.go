@@ -0,0 +1,61 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/phlashdev/recipe-keeper-api/auth"
+)
+
+func TestOIDCCallbackChecksState(t *testing.T) {
+	// the provider must not be asked to redeem a code before the state matched
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("provider called with %s %s", r.Method, r.URL)
+		http.Error(w, "unexpected", http.StatusInternalServerError)
+	}))
+	defer provider.Close()
+	handler := NewOIDCCallbackHandler(auth.NewOIDCProvider(auth.OIDCConfig{Name: "Company account", Issuer: provider.URL}, provider.Client()), nil)
+
+	state, err := auth.NewOIDCState("/recipes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		query  url.Values
+		cookie bool
+		status int
+	}{
+		{"state mismatch", url.Values{"code": {"code-1"}, "state": {"another-state"}}, true, http.StatusBadRequest},
+		{"state missing", url.Values{"code": {"code-1"}}, true, http.StatusBadRequest},
+		{"cookie missing", url.Values{"code": {"code-1"}, "state": {state.State}}, false, http.StatusBadRequest},
+		{"login denied", url.Values{"error": {"access_denied"}, "state": {state.State}}, true, http.StatusUnauthorized},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/login/oidc/callback?"+test.query.Encode(), nil)
+			if test.cookie {
+				cookies := httptest.NewRecorder()
+				if err := auth.SetOIDCStateCookie(cookies, r, state); err != nil {
+					t.Fatal(err)
+				}
+				r.Header.Set("Cookie", strings.Split(cookies.Header().Get("Set-Cookie"), ";")[0])
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, r)
+
+			if w.Code != test.status {
+				t.Errorf("status = %d, want %d", w.Code, test.status)
+			}
+			// a state is used once, also by failed logins
+			if cookie := w.Header().Get("Set-Cookie"); !strings.Contains(cookie, "Max-Age=0") {
+				t.Errorf("Set-Cookie = %q, want the state cookie removed", cookie)
+			}
+		})
+	}
+}
@@ -0,0 +1,514 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/phlashdev/recipe-keeper-api/core"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const recipesPageSize = 50
+
+// Empty rows added to the recipe form, so ingredients and steps can be added without JavaScript.
+const (
+	blankIngredientRows = 3
+	blankStepRows       = 2
+)
+
+type recipeListItem struct {
+	ID       string
+	Title    string
+	Category string
+	Source   string
+	Tags     []string
+}
+
+type recipesPage struct {
+	Filter      core.RecipeFilter
+	Sources     []core.Source
+	Recipes     []recipeListItem
+	PreviousURL string
+	NextURL     string
+}
+
+type RecipesHandler struct {
+	recipeRepository core.RecipeRepository
+	sourceRepository core.SourceRepository
+}
+
+func NewRecipesHandler(recipeRepository core.RecipeRepository, sourceRepository core.SourceRepository) *RecipesHandler {
+	return &RecipesHandler{
+		recipeRepository: recipeRepository,
+		sourceRepository: sourceRepository,
+	}
+}
+
+func (handler *RecipesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := r.URL.Query()
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	// one more than shown tells whether there is a next page
+	filter := core.RecipeFilter{
+		Title:    strings.TrimSpace(query.Get("title")),
+		Category: strings.TrimSpace(query.Get("category")),
+		SourceID: query.Get("source"),
+		Tag:      strings.TrimSpace(query.Get("tag")),
+		Offset:   (page - 1) * recipesPageSize,
+		Limit:    recipesPageSize + 1,
+	}
+	recipes, err := handler.recipeRepository.GetRecipes(ctx, filter)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	sources, err := handler.sourceRepository.GetSources(ctx)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+	sourceTitles := make(map[primitive.ObjectID]string, len(sources))
+	for _, source := range sources {
+		sourceTitles[source.ID] = source.Title
+	}
+
+	data := recipesPage{
+		Filter:  filter,
+		Sources: sources,
+	}
+	if page > 1 {
+		data.PreviousURL = pageURL(query, page-1)
+	}
+	if len(recipes) > recipesPageSize {
+		recipes = recipes[:recipesPageSize]
+		data.NextURL = pageURL(query, page+1)
+	}
+	for _, recipe := range recipes {
+		data.Recipes = append(data.Recipes, recipeListItem{
+			ID:       recipe.ID.Hex(),
+			Title:    recipe.Title,
+			Category: recipe.Category,
+			Source:   sourceTitles[recipe.Source],
+			Tags:     recipe.Tags,
+		})
+	}
+
+	render(w, r, "recipes", http.StatusOK, data)
+}
+
+func pageURL(query url.Values, page int) string {
+	values := url.Values{}
+	for key, value := range query {
+		values[key] = value
+	}
+	values.Set("page", strconv.Itoa(page))
+
+	return "/recipes?" + values.Encode()
+}
+
+type recipePage struct {
+	Recipe core.Recipe
+	Source *core.Source
+}
+
+type RecipeHandler struct {
+	recipeRepository core.RecipeRepository
+	sourceRepository core.SourceRepository
+}
+
+func NewRecipeHandler(recipeRepository core.RecipeRepository, sourceRepository core.SourceRepository) *RecipeHandler {
+	return &RecipeHandler{
+		recipeRepository: recipeRepository,
+		sourceRepository: sourceRepository,
+	}
+}
+
+func (handler *RecipeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	vars := mux.Vars(r)
+	recipe, err := handler.recipeRepository.GetRecipeByID(ctx, vars["id"])
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	data := recipePage{Recipe: recipe}
+	if !recipe.Source.IsZero() {
+		source, err := handler.sourceRepository.GetSourceByID(ctx, recipe.Source.Hex())
+		if err != nil && !isNotFoundError(err) {
+			renderError(w, r, err)
+			return
+		}
+		if err == nil {
+			data.Source = &source
+		}
+	}
+
+	render(w, r, "recipe", http.StatusOK, data)
+}
+
+type ingredientRow struct {
+	Quantity string
+	Unit     string
+	Name     string
+}
+
+type stepRow struct {
+	Text        string
+	Ingredients string
+	Timers      string
+}
+
+// recipeForm holds the form values as entered, so a form with errors is shown again unchanged.
+type recipeForm struct {
+	ID               string
+	Version          int
+	Title            string
+	SourceID         string
+	SourceAnnotation string
+	Category         string
+	Servings         string
+	Allergens        string
+	Tags             string
+	Cookware         string
+	Ingredients      []ingredientRow
+	Steps            []stepRow
+	Sources          []core.Source
+	Errors           []core.FieldError
+}
+
+func newRecipeForm(recipe core.Recipe) recipeForm {
+	form := recipeForm{
+		Version:          recipe.Version,
+		Title:            recipe.Title,
+		SourceAnnotation: recipe.SourceAnnotation,
+		Category:         recipe.Category,
+		Allergens:        strings.Join(recipe.Allergens, ", "),
+		Tags:             strings.Join(recipe.Tags, ", "),
+		Cookware:         strings.Join(recipe.Cookware, ", "),
+	}
+	if !recipe.ID.IsZero() {
+		form.ID = recipe.ID.Hex()
+	}
+	if !recipe.Source.IsZero() {
+		form.SourceID = recipe.Source.Hex()
+	}
+	if recipe.Servings > 0 {
+		form.Servings = strconv.Itoa(recipe.Servings)
+	}
+
+	for _, ingredient := range recipe.Ingredients {
+		form.Ingredients = append(form.Ingredients, ingredientRow{
+			Quantity: formatQuantity(ingredient.Quantity),
+			Unit:     ingredient.Unit,
+			Name:     ingredient.Name,
+		})
+	}
+	for _, step := range recipe.Steps {
+		timers := make([]string, 0, len(step.Timers))
+		for _, timer := range step.Timers {
+			timers = append(timers, strings.TrimSpace(timer.Name+" "+formatDuration(timer.Duration)))
+		}
+		form.Steps = append(form.Steps, stepRow{
+			Text:        step.Text,
+			Ingredients: strings.Join(step.Ingredients, ", "),
+			Timers:      strings.Join(timers, ", "),
+		})
+	}
+
+	return form
+}
+
+// withBlankRows appends the empty rows for new ingredients and steps.
+func (form recipeForm) withBlankRows() recipeForm {
+	for i := 0; i < blankIngredientRows; i++ {
+		form.Ingredients = append(form.Ingredients, ingredientRow{})
+	}
+	for i := 0; i < blankStepRows; i++ {
+		form.Steps = append(form.Steps, stepRow{})
+	}
+
+	return form
+}
+
+// parseRecipeForm reads the posted form. Rows left empty are dropped, values that cannot be
+// converted are reported as field errors.
+func parseRecipeForm(r *http.Request) (recipeForm, core.Recipe, []core.FieldError) {
+	form := recipeForm{
+		Title:            r.PostFormValue("title"),
+		SourceID:         r.PostFormValue("source"),
+		SourceAnnotation: r.PostFormValue("sourceAnnotation"),
+		Category:         r.PostFormValue("category"),
+		Servings:         r.PostFormValue("servings"),
+		Allergens:        r.PostFormValue("allergens"),
+		Tags:             r.PostFormValue("tags"),
+		Cookware:         r.PostFormValue("cookware"),
+	}
+	form.Version, _ = strconv.Atoi(r.PostFormValue("version"))
+
+	recipe := core.Recipe{
+		Title:            strings.TrimSpace(form.Title),
+		SourceAnnotation: strings.TrimSpace(form.SourceAnnotation),
+		Category:         strings.TrimSpace(form.Category),
+		Allergens:        splitList(form.Allergens),
+		Tags:             splitList(form.Tags),
+		Cookware:         splitList(form.Cookware),
+	}
+
+	var errs []core.FieldError
+	if len(form.SourceID) > 0 {
+		sourceID, err := primitive.ObjectIDFromHex(form.SourceID)
+		if err != nil {
+			errs = append(errs, core.FieldError{Field: "sourceId", Message: "unknown source"})
+		}
+		recipe.Source = sourceID
+	}
+	if servings := strings.TrimSpace(form.Servings); len(servings) > 0 {
+		var err error
+		recipe.Servings, err = strconv.Atoi(servings)
+		if err != nil {
+			errs = append(errs, core.FieldError{Field: "servings", Message: "must be a whole number"})
+		}
+	}
+
+	quantities := r.PostForm["ingredientQuantity"]
+	units := r.PostForm["ingredientUnit"]
+	names := r.PostForm["ingredientName"]
+	for i := range names {
+		row := ingredientRow{Quantity: formValue(quantities, i), Unit: formValue(units, i), Name: names[i]}
+		if len(strings.TrimSpace(row.Quantity+row.Unit+row.Name)) == 0 {
+			continue
+		}
+		form.Ingredients = append(form.Ingredients, row)
+
+		ingredient := core.Ingredient{Name: strings.TrimSpace(row.Name), Unit: strings.TrimSpace(row.Unit)}
+		if quantity := strings.TrimSpace(row.Quantity); len(quantity) > 0 {
+			var err error
+			ingredient.Quantity, err = strconv.ParseFloat(strings.Replace(quantity, ",", ".", 1), 64)
+			if err != nil {
+				errs = append(errs, core.FieldError{
+					Field:   fmt.Sprintf("ingredients[%d].quantity", len(recipe.Ingredients)),
+					Message: "must be a number",
+				})
+			}
+		}
+		recipe.Ingredients = append(recipe.Ingredients, ingredient)
+	}
+
+	texts := r.PostForm["stepText"]
+	stepIngredients := r.PostForm["stepIngredients"]
+	stepTimers := r.PostForm["stepTimers"]
+	for i := range texts {
+		row := stepRow{Text: texts[i], Ingredients: formValue(stepIngredients, i), Timers: formValue(stepTimers, i)}
+		if len(strings.TrimSpace(row.Text+row.Ingredients+row.Timers)) == 0 {
+			continue
+		}
+		form.Steps = append(form.Steps, row)
+
+		timers, err := parseTimers(row.Timers)
+		if err != nil {
+			errs = append(errs, core.FieldError{
+				Field:   fmt.Sprintf("steps[%d].timers", len(recipe.Steps)),
+				Message: err.Error(),
+			})
+		}
+		recipe.Steps = append(recipe.Steps, core.Step{
+			Text:        strings.TrimSpace(row.Text),
+			Ingredients: splitList(row.Ingredients),
+			Timers:      timers,
+		})
+	}
+
+	return form, recipe, errs
+}
+
+func formValue(values []string, i int) string {
+	if i < len(values) {
+		return values[i]
+	}
+
+	return ""
+}
+
+// parseTimers reads timers written as "Bake 25m, Rest 5m", the name is optional.
+func parseTimers(value string) ([]core.Timer, error) {
+	var timers []core.Timer
+	for _, entry := range splitList(value) {
+		fields := strings.Fields(entry)
+		duration, err := time.ParseDuration(fields[len(fields)-1])
+		if err != nil {
+			return nil, fmt.Errorf("'%s' does not end with a duration like 10m or 1h30m", entry)
+		}
+		timers = append(timers, core.Timer{
+			Name:     strings.Join(fields[:len(fields)-1], " "),
+			Duration: duration,
+		})
+	}
+
+	return timers, nil
+}
+
+// RecipeFormHandler shows the form for a new recipe, or for the recipe with the id to edit.
+type RecipeFormHandler struct {
+	recipeRepository core.RecipeRepository
+	sourceRepository core.SourceRepository
+}
+
+func NewRecipeFormHandler(recipeRepository core.RecipeRepository, sourceRepository core.SourceRepository) *RecipeFormHandler {
+	return &RecipeFormHandler{
+		recipeRepository: recipeRepository,
+		sourceRepository: sourceRepository,
+	}
+}
+
+func (handler *RecipeFormHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	recipe := core.Recipe{}
+	if id, ok := mux.Vars(r)["id"]; ok {
+		var err error
+		recipe, err = handler.recipeRepository.GetRecipeByID(ctx, id)
+		if err != nil {
+			renderError(w, r, err)
+			return
+		}
+	}
+
+	sources, err := handler.sourceRepository.GetSources(ctx)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	form := newRecipeForm(recipe).withBlankRows()
+	form.Sources = sources
+	render(w, r, "recipeform", http.StatusOK, form)
+}
+
+// SaveRecipeHandler adds the posted recipe, or updates the recipe with the id. The form is shown
+// again with the errors if the recipe cannot be stored.
+type SaveRecipeHandler struct {
+	recipeRepository   core.RecipeRepository
+	sourceRepository   core.SourceRepository
+	revisionRepository core.RevisionRepository
+}
+
+func NewSaveRecipeHandler(recipeRepository core.RecipeRepository, sourceRepository core.SourceRepository, revisionRepository core.RevisionRepository) *SaveRecipeHandler {
+	return &SaveRecipeHandler{
+		recipeRepository:   recipeRepository,
+		sourceRepository:   sourceRepository,
+		revisionRepository: revisionRepository,
+	}
+}
+
+func (handler *SaveRecipeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	form, recipe, errs := parseRecipeForm(r)
+
+	var previous *core.Recipe
+	id, ok := mux.Vars(r)["id"]
+	if ok {
+		stored, err := handler.recipeRepository.GetRecipeByID(ctx, id)
+		if err != nil {
+			renderError(w, r, err)
+			return
+		}
+		previous = &stored
+		form.ID = id
+		recipe.ID = stored.ID
+		// the version from the form detects changes made since the form was opened
+		recipe.Version = form.Version
+	}
+
+	var err error
+	if len(errs) == 0 {
+		if previous == nil {
+			err = handler.recipeRepository.AddRecipe(ctx, &recipe)
+		} else {
+			err = handler.recipeRepository.UpdateRecipe(ctx, recipe)
+		}
+		if err == nil {
+			err = core.RecordRevision(ctx, handler.revisionRepository, previous, recipe, requestAuthor(r))
+		}
+		if err == nil {
+			seeOther(w, r, "/recipes/"+recipe.ID.Hex())
+			return
+		}
+	}
+
+	if err != nil {
+		var ok bool
+		errs, ok = formErrors(err)
+		if !ok {
+			renderError(w, r, err)
+			return
+		}
+	} else if validationErrs, ok := formErrors(core.ValidateRecipe(recipe)); ok {
+		// show the remaining problems as well, not only the values that could not be converted
+		errs = append(errs, validationErrs...)
+	}
+
+	sources, err := handler.sourceRepository.GetSources(ctx)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	form = form.withBlankRows()
+	form.Sources = sources
+	form.Errors = errs
+	render(w, r, "recipeform", http.StatusUnprocessableEntity, form)
+}
+
+// DeleteRecipeHandler moves the recipe to the trash and returns to the recipe list.
+type DeleteRecipeHandler struct {
+	recipeRepository core.RecipeRepository
+}
+
+func NewDeleteRecipeHandler(recipeRepository core.RecipeRepository) *DeleteRecipeHandler {
+	return &DeleteRecipeHandler{
+		recipeRepository: recipeRepository,
+	}
+}
+
+func (handler *DeleteRecipeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	vars := mux.Vars(r)
+	recipe, err := handler.recipeRepository.GetRecipeByID(ctx, vars["id"])
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	err = handler.recipeRepository.DeleteRecipe(ctx, recipe)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	seeOther(w, r, "/recipes")
+}
@@ -0,0 +1,204 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/phlashdev/recipe-keeper-api/core"
+)
+
+var sourceTypes = []string{core.SourceTypeBook, core.SourceTypeUrl, core.SourceTypeCustom}
+
+// sourceForm holds the form values as entered, so a form with errors is shown again unchanged.
+type sourceForm struct {
+	ID      string
+	Version int
+	Title   string
+	Type    string
+	Types   []string
+	Errors  []core.FieldError
+}
+
+func newSourceForm(source core.Source) sourceForm {
+	form := sourceForm{
+		Version: source.Version,
+		Title:   source.Title,
+		Type:    source.Type,
+		Types:   sourceTypes,
+	}
+	if !source.ID.IsZero() {
+		form.ID = source.ID.Hex()
+	}
+
+	return form
+}
+
+type sourcesPage struct {
+	Sources []core.Source
+	Form    sourceForm
+}
+
+// SourcesHandler lists the sources together with the form to add one.
+type SourcesHandler struct {
+	sourceRepository core.SourceRepository
+}
+
+func NewSourcesHandler(sourceRepository core.SourceRepository) *SourcesHandler {
+	return &SourcesHandler{
+		sourceRepository: sourceRepository,
+	}
+}
+
+func (handler *SourcesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	sources, err := handler.sourceRepository.GetSources(ctx)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render(w, r, "sources", http.StatusOK, sourcesPage{
+		Sources: sources,
+		Form:    newSourceForm(core.Source{Type: core.SourceTypeBook}),
+	})
+}
+
+// SourceFormHandler shows the form to edit the source with the id.
+type SourceFormHandler struct {
+	sourceRepository core.SourceRepository
+}
+
+func NewSourceFormHandler(sourceRepository core.SourceRepository) *SourceFormHandler {
+	return &SourceFormHandler{
+		sourceRepository: sourceRepository,
+	}
+}
+
+func (handler *SourceFormHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	vars := mux.Vars(r)
+	source, err := handler.sourceRepository.GetSourceByID(ctx, vars["id"])
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render(w, r, "sourceform", http.StatusOK, newSourceForm(source))
+}
+
+// SaveSourceHandler adds the posted source, or updates the source with the id. The form is shown
+// again with the errors if the source cannot be stored.
+type SaveSourceHandler struct {
+	sourceRepository core.SourceRepository
+}
+
+func NewSaveSourceHandler(sourceRepository core.SourceRepository) *SaveSourceHandler {
+	return &SaveSourceHandler{
+		sourceRepository: sourceRepository,
+	}
+}
+
+func (handler *SaveSourceHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	form := sourceForm{
+		Title: r.PostFormValue("title"),
+		Type:  r.PostFormValue("type"),
+		Types: sourceTypes,
+	}
+	form.Version, _ = strconv.Atoi(r.PostFormValue("version"))
+	source := core.Source{
+		Title: strings.TrimSpace(form.Title),
+		Type:  form.Type,
+	}
+
+	id, ok := mux.Vars(r)["id"]
+	var err error
+	if ok {
+		var stored core.Source
+		stored, err = handler.sourceRepository.GetSourceByID(ctx, id)
+		if err != nil {
+			renderError(w, r, err)
+			return
+		}
+		form.ID = id
+		source.ID = stored.ID
+		// the version from the form detects changes made since the form was opened
+		source.Version = form.Version
+		err = handler.sourceRepository.UpdateSource(ctx, source)
+	} else {
+		err = handler.sourceRepository.AddSource(ctx, &source)
+	}
+	if err == nil {
+		seeOther(w, r, "/sources")
+		return
+	}
+
+	errs, isFormErr := formErrors(err)
+	if !isFormErr {
+		renderError(w, r, err)
+		return
+	}
+	form.Errors = errs
+
+	if ok {
+		render(w, r, "sourceform", http.StatusUnprocessableEntity, form)
+		return
+	}
+
+	// a new source is added on the list page, so it is shown again with the list
+	sources, err := handler.sourceRepository.GetSources(ctx)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+	render(w, r, "sources", http.StatusUnprocessableEntity, sourcesPage{
+		Sources: sources,
+		Form:    form,
+	})
+}
+
+// DeleteSourceHandler moves the source to the trash and returns to the source list.
+type DeleteSourceHandler struct {
+	sourceRepository core.SourceRepository
+}
+
+func NewDeleteSourceHandler(sourceRepository core.SourceRepository) *DeleteSourceHandler {
+	return &DeleteSourceHandler{
+		sourceRepository: sourceRepository,
+	}
+}
+
+func (handler *DeleteSourceHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	vars := mux.Vars(r)
+	source, err := handler.sourceRepository.GetSourceByID(ctx, vars["id"])
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	err = handler.sourceRepository.DeleteSource(ctx, source)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	seeOther(w, r, "/sources")
+}
@@ -0,0 +1,108 @@
+// Keeps the timers of cook mode in sync with the server, which sends all timers of the recipe
+// whenever one is started, finishes or is removed on any device.
+(function () {
+  "use strict";
+
+  var list = document.getElementById("timers");
+  if (!list || !window.EventSource) {
+    return;
+  }
+
+  var recipeId = list.dataset.recipe;
+  var step = list.dataset.step;
+  var timers = [];
+
+  function format(seconds) {
+    seconds = Math.ceil(seconds);
+    var hours = Math.floor(seconds / 3600);
+    var minutes = Math.floor((seconds % 3600) / 60);
+    var rest = seconds % 60;
+    var text = (hours > 0 ? hours + ":" + String(minutes).padStart(2, "0") : String(minutes)) + ":" + String(rest).padStart(2, "0");
+    return text;
+  }
+
+  function remainingSeconds(timer) {
+    return Math.max(0, (timer.endsAtLocal - Date.now()) / 1000);
+  }
+
+  function render() {
+    list.textContent = "";
+    if (timers.length === 0) {
+      var empty = document.createElement("li");
+      empty.className = "empty";
+      empty.textContent = "No timers running.";
+      list.appendChild(empty);
+      return;
+    }
+
+    timers.forEach(function (timer) {
+      var item = document.createElement("li");
+
+      var name = document.createElement("span");
+      name.className = "timer-name";
+      name.textContent = "Step " + (timer.stepIndex + 1) + (timer.name ? ": " + timer.name : "");
+      item.appendChild(name);
+
+      var remaining = document.createElement("span");
+      remaining.className = "timer-remaining";
+      item.appendChild(remaining);
+
+      var form = document.createElement("form");
+      form.method = "post";
+      form.action = "/recipes/" + encodeURIComponent(recipeId) + "/cook/timers/" + encodeURIComponent(timer.id) + "/remove";
+      var stepInput = document.createElement("input");
+      stepInput.type = "hidden";
+      stepInput.name = "step";
+      stepInput.value = step;
+      form.appendChild(stepInput);
+      var button = document.createElement("button");
+      button.type = "submit";
+      form.appendChild(button);
+      item.appendChild(form);
+
+      timer.item = item;
+      timer.remaining = remaining;
+      timer.button = button;
+      list.appendChild(item);
+    });
+    tick();
+  }
+
+  function tick() {
+    timers.forEach(function (timer) {
+      var seconds = remainingSeconds(timer);
+      var finished = seconds === 0;
+      if (finished && !timer.alerted) {
+        timer.alerted = true;
+        if (navigator.vibrate) {
+          navigator.vibrate([300, 200, 300]);
+        }
+      }
+      timer.item.classList.toggle("finished", finished);
+      timer.remaining.textContent = finished ? "done" : format(seconds);
+      timer.button.textContent = finished ? "Dismiss" : "Cancel";
+    });
+  }
+
+  var events = new EventSource("/api/recipes/" + encodeURIComponent(recipeId) + "/cook/events");
+  events.addEventListener("timers", function (event) {
+    var received = Date.now();
+    var alerted = {};
+    timers.forEach(function (timer) {
+      alerted[timer.id] = timer.alerted;
+    });
+    // the remaining time avoids depending on the clock of the device
+    timers = JSON.parse(event.data).map(function (timer) {
+      timer.endsAtLocal = received + timer.remainingSeconds * 1000;
+      timer.alerted = alerted[timer.id] || timer.finished;
+      return timer;
+    });
+    render();
+  });
+  setInterval(tick, 1000);
+
+  // keep the screen on while cooking where the browser allows it
+  if (navigator.wakeLock) {
+    navigator.wakeLock.request("screen").catch(function () {});
+  }
+})();
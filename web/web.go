@@ -0,0 +1,205 @@
+// Package web serves the HTML user interface. Pages are rendered on the server and work without
+// JavaScript, changes are posted as forms and stored through the same repositories as the API.
+package web
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/phlashdev/recipe-keeper-api/auth"
+	"github.com/phlashdev/recipe-keeper-api/cooking"
+	"github.com/phlashdev/recipe-keeper-api/core"
+)
+
+//go:embed templates static
+var content embed.FS
+
+var templateFuncs = template.FuncMap{
+	"join":     strings.Join,
+	"quantity": formatQuantity,
+	"duration": formatDuration,
+	"label":    fieldLabel,
+	"inc":      func(i int) int { return i + 1 },
+	"dec":      func(i int) int { return i - 1 },
+	// replaced per request by render
+	"currentUser": func() *core.User { return nil },
+	"canEdit":     func() bool { return false },
+}
+
+var pages = parsePages("recipes", "recipe", "recipeform", "cook", "sources", "sourceform", "login", "error")
+
+// parsePages combines every page with the layout and the partials shared by pages, each page
+// defines the "title" and "content" templates.
+func parsePages(names ...string) map[string]*template.Template {
+	pages := make(map[string]*template.Template, len(names))
+	for _, name := range names {
+		pages[name] = template.Must(template.New(name).Funcs(templateFuncs).ParseFS(content, "templates/layout.html", "templates/partials.html", "templates/"+name+".html"))
+	}
+
+	return pages
+}
+
+// NewStaticHandler serves the style sheet and other files of the pages below prefix.
+func NewStaticHandler(prefix string) http.Handler {
+	static, err := fs.Sub(content, "static")
+	if err != nil {
+		panic(err)
+	}
+
+	return http.StripPrefix(prefix, http.FileServer(http.FS(static)))
+}
+
+// render executes a copy of the page with the functions of the signed in user, the parsed pages
+// are never executed so they can be copied.
+func render(w http.ResponseWriter, r *http.Request, name string, status int, data interface{}) {
+	page, err := pages[name].Clone()
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	var currentUser *core.User
+	if user, ok := auth.UserFromContext(r.Context()); ok {
+		currentUser = &user
+	}
+	page.Funcs(template.FuncMap{
+		"currentUser": func() *core.User { return currentUser },
+		"canEdit":     func() bool { return currentUser != nil && currentUser.HasRole(core.RoleEditor) },
+	})
+
+	var buffer bytes.Buffer
+	err = page.ExecuteTemplate(&buffer, "layout", data)
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	_, err = w.Write(buffer.Bytes())
+	if err != nil {
+		log.Print(err)
+	}
+}
+
+type errorPage struct {
+	Title   string
+	Message string
+}
+
+// renderError shows the error page, unexpected errors are logged and not shown.
+func renderError(w http.ResponseWriter, r *http.Request, err error) {
+	if isNotFoundError(err) {
+		render(w, r, "error", http.StatusNotFound, errorPage{
+			Title:   "Not found",
+			Message: "The page does not exist, the recipe or source may have been deleted.",
+		})
+		return
+	}
+
+	log.Print(err)
+	render(w, r, "error", http.StatusInternalServerError, errorPage{
+		Title:   "Something went wrong",
+		Message: "The page could not be loaded, please try again later.",
+	})
+}
+
+func isNotFoundError(err error) bool {
+	var recipeNotFoundErr *core.RecipeNotFoundError
+	var recipeIDErr *core.RecipeIDNotValidError
+	var sourceNotFoundErr *core.SourceNotFoundError
+	var sourceIDErr *core.SourceIDNotValidError
+	var stepNotFoundErr *cooking.StepNotFoundError
+	var stepTimerNotFoundErr *cooking.StepTimerNotFoundError
+	var timerNotFoundErr *cooking.TimerNotFoundError
+
+	return errors.As(err, &recipeNotFoundErr) || errors.As(err, &recipeIDErr) ||
+		errors.As(err, &sourceNotFoundErr) || errors.As(err, &sourceIDErr) ||
+		errors.As(err, &stepNotFoundErr) || errors.As(err, &stepTimerNotFoundErr) || errors.As(err, &timerNotFoundErr)
+}
+
+// formErrors returns the field errors to show with the form, or false if err is not caused by the input.
+func formErrors(err error) ([]core.FieldError, bool) {
+	var validationErr *core.ValidationError
+	if errors.As(err, &validationErr) {
+		return validationErr.Errors, true
+	}
+
+	var versionConflictErr *core.VersionConflictError
+	if errors.As(err, &versionConflictErr) {
+		return []core.FieldError{{
+			Message: "Someone else changed this in the meantime. Open it again to see the changes, then repeat your edit.",
+		}}, true
+	}
+
+	return nil, false
+}
+
+// seeOther redirects to the page showing the result of a posted form, so reloading it does not post again.
+func seeOther(w http.ResponseWriter, r *http.Request, url string) {
+	http.Redirect(w, r, url, http.StatusSeeOther)
+}
+
+func formatQuantity(quantity float64) string {
+	if quantity == 0 {
+		return ""
+	}
+
+	return strconv.FormatFloat(quantity, 'f', -1, 64)
+}
+
+// formatDuration omits zero minutes and seconds, "1h30m" instead of "1h30m0s".
+func formatDuration(duration time.Duration) string {
+	text := duration.String()
+	if strings.HasSuffix(text, "m0s") {
+		text = strings.TrimSuffix(text, "0s")
+	}
+	if strings.HasSuffix(text, "h0m") {
+		text = strings.TrimSuffix(text, "0m")
+	}
+
+	return text
+}
+
+var fieldIndexPattern = regexp.MustCompile(`\[(\d+)\]`)
+
+// fieldLabel turns a field name like "ingredients[2].name" into "ingredients 3 name".
+func fieldLabel(field string) string {
+	label := fieldIndexPattern.ReplaceAllStringFunc(field, func(index string) string {
+		i, _ := strconv.Atoi(index[1 : len(index)-1])
+		return fmt.Sprintf(" %d", i+1)
+	})
+
+	return strings.ReplaceAll(label, ".", " ")
+}
+
+// splitList splits a comma separated form value, dropping blank entries.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if len(item) > 0 {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+// requestAuthor names the signed in user as author of a change.
+func requestAuthor(r *http.Request) string {
+	user, _ := auth.UserFromContext(r.Context())
+	return user.Name()
+}